@@ -2,10 +2,11 @@ package api
 
 import (
 	"database/sql"
-	"errors"
 	"net/http"
 	db "simplebank/db/sqlc"
+	"simplebank/oauth"
 	"simplebank/token"
+	"slices"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
@@ -18,7 +19,7 @@ type createAccountReq struct {
 func (server *Server) createAccount(c *gin.Context) {
 	var req createAccountReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -32,12 +33,15 @@ func (server *Server) createAccount(c *gin.Context) {
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Code.Name() {
-			case "foreign_key_violation", "unique_violation":
-				c.JSON(http.StatusForbidden, errorResponse(err))
+			case "foreign_key_violation":
+				abortWithError(c, http.StatusForbidden, newError(CodeNotFound, "user %s does not exist", authPayload.Username))
+				return
+			case "unique_violation":
+				abortWithError(c, http.StatusForbidden, newError(CodeAlreadyExists, "account in %s already exists", req.Currency))
 				return
 			}
 		}
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -51,57 +55,192 @@ type getAccountReq struct {
 func (server *Server) getAccount(c *gin.Context) {
 	var req getAccountReq
 	if err := c.ShouldBindUri(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
-	account, err := server.store.GetAccount(c, req.ID)
+	account, valid := server.readableAccount(c, req.ID, oauth.PermissionBalances)
+	if !valid {
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+type listAccountReq struct {
+	pageReq
+}
+
+func (server *Server) listAccount(c *gin.Context) {
+	var req listAccountReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	cursor, err := decodeCursor(req.Cursor)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, errorResponse(err))
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	authPayload := c.MustGet(authPayloadKey).(*token.Payload)
+
+	// a third-party token only lists the accounts it has a consent to
+	var accountIDs []int64
+	consent, valid := server.activeConsent(c)
+	if !valid {
+		return
+	}
+	if consent != nil {
+		if !slices.Contains(consent.Permissions, oauth.PermissionBalances) {
+			abortWithError(c, http.StatusForbidden, newError(CodeConsentRequired, "the consent does not cover the %s of accounts", oauth.PermissionBalances))
 			return
 		}
+		accountIDs = consent.AccountIds
+	}
 
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
+	var accounts []db.Account
+	if cursor.Backward {
+		accounts, err = server.store.ListAccountsBefore(c, db.ListAccountsBeforeParams{
+			Owner:      authPayload.Username,
+			BeforeID:   cursor.ID,
+			AccountIds: accountIDs,
+			Limit:      req.PageSize + 1,
+		})
+	} else {
+		accounts, err = server.store.ListAccounts(c, db.ListAccountsParams{
+			Owner:      authPayload.Username,
+			AfterID:    cursor.ID,
+			AccountIds: accountIDs,
+			Limit:      req.PageSize + 1,
+		})
+	}
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
 
-	authPayload := c.MustGet(authPayloadKey).(*token.Payload)
-	if account.Owner != authPayload.Username {
-		err := errors.New("account doesn't belong to authorization user")
-		c.JSON(http.StatusUnauthorized, errorResponse(err))
+	c.JSON(http.StatusOK, newListRes(accounts, cursor, req.PageSize, func(a db.Account) int64 { return a.ID }))
+}
+
+type listAccountHistoryReq struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) listEntries(c *gin.Context) {
+	var uri listAccountHistoryReq
+	if err := c.ShouldBindUri(&uri); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, account)
-}
+	var req pageReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
 
-type listAccountReq struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	cursor, err := decodeCursor(req.Cursor)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, valid := server.readableAccount(c, uri.ID, oauth.PermissionTransactions); !valid {
+		return
+	}
+
+	var entries []db.Entry
+	if cursor.Backward {
+		entries, err = server.store.ListEntriesBefore(c, db.ListEntriesBeforeParams{
+			AccountID: uri.ID,
+			BeforeID:  cursor.ID,
+			Limit:     req.PageSize + 1,
+		})
+	} else {
+		entries, err = server.store.ListEntries(c, db.ListEntriesParams{
+			AccountID: uri.ID,
+			AfterID:   cursor.ID,
+			Limit:     req.PageSize + 1,
+		})
+	}
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newListRes(entries, cursor, req.PageSize, func(e db.Entry) int64 { return e.ID }))
 }
 
-func (server *Server) listAccount(c *gin.Context) {
-	var req listAccountReq
+func (server *Server) listTransfers(c *gin.Context) {
+	var uri listAccountHistoryReq
+	if err := c.ShouldBindUri(&uri); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var req pageReq
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
-	authPayload := c.MustGet(authPayloadKey).(*token.Payload)
-	arg := db.ListAccountsParams{
-		Owner:  authPayload.Username,
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+	cursor, err := decodeCursor(req.Cursor)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, valid := server.readableAccount(c, uri.ID, oauth.PermissionTransactions); !valid {
+		return
 	}
 
-	accounts, err := server.store.ListAccounts(c, arg)
+	var transfers []db.Transfer
+	if cursor.Backward {
+		transfers, err = server.store.ListTransfersBefore(c, db.ListTransfersBeforeParams{
+			ToAccountID:   uri.ID,
+			FromAccountID: uri.ID,
+			BeforeID:      cursor.ID,
+			Limit:         req.PageSize + 1,
+		})
+	} else {
+		transfers, err = server.store.ListTransfers(c, db.ListTransfersParams{
+			ToAccountID:   uri.ID,
+			FromAccountID: uri.ID,
+			AfterID:       cursor.ID,
+			Limit:         req.PageSize + 1,
+		})
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, accounts)
+	c.JSON(http.StatusOK, newListRes(transfers, cursor, req.PageSize, func(t db.Transfer) int64 { return t.ID }))
+}
+
+// ownedAccount loads the account and makes sure it belongs to the authorized user
+func (server *Server) ownedAccount(c *gin.Context, accountID int64) (db.Account, bool) {
+	account, err := server.store.GetAccount(c, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			abortWithError(c, http.StatusNotFound, newError(CodeNotFound, "account %d not found", accountID))
+			return account, false
+		}
+
+		abortWithError(c, http.StatusInternalServerError, err)
+		return account, false
+	}
+
+	authPayload := c.MustGet(authPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username {
+		err := newError(CodeAccountNotOwned, "account doesn't belong to the authenticated user")
+		abortWithError(c, http.StatusUnauthorized, err)
+		return account, false
+	}
+
+	return account, true
 }
 
 // type updateAccountReq struct {
@@ -112,11 +251,11 @@ func (server *Server) listAccount(c *gin.Context) {
 // func (server *Server) updateAccount(c *gin.Context) {
 // 	var req updateAccountReq
 // 	if err := c.ShouldBindUri(&req); err != nil {
-// 		c.JSON(http.StatusBadRequest, errorResponse(err))
+// 		abortWithError(c, http.StatusBadRequest, err)
 // 		return
 // 	}
 // 	if err := c.ShouldBindJSON(&req); err != nil {
-// 		c.JSON(http.StatusBadRequest, errorResponse(err))
+// 		abortWithError(c, http.StatusBadRequest, err)
 // 		return
 // 	}
 
@@ -126,7 +265,7 @@ func (server *Server) listAccount(c *gin.Context) {
 // 	}
 // 	_, err := server.store.UpdateAccount(c, arg)
 // 	if err != nil {
-// 		c.JSON(http.StatusInternalServerError, errorResponse(err))
+// 		abortWithError(c, http.StatusInternalServerError, err)
 // 		return
 // 	}
 
@@ -140,12 +279,12 @@ func (server *Server) listAccount(c *gin.Context) {
 // func (server *Server) deleteAccount(c *gin.Context) {
 // 	var req deleteAccountReq
 // 	if err := c.ShouldBindUri(&req); err != nil {
-// 		c.JSON(http.StatusBadRequest, errorResponse(err))
+// 		abortWithError(c, http.StatusBadRequest, err)
 // 		return
 // 	}
 
 // 	if err := server.store.DeleteAccount(c, req.ID); err != nil {
-// 		c.JSON(http.StatusInternalServerError, errorResponse(err))
+// 		abortWithError(c, http.StatusInternalServerError, err)
 // 		return
 // 	}
 
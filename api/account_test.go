@@ -62,6 +62,7 @@ func TestGetAccount(t *testing.T) {
 			},
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, w.Code)
+				requireErrorCode(t, w.Body, CodeAccountNotOwned)
 			},
 		},
 		{
@@ -92,6 +93,7 @@ func TestGetAccount(t *testing.T) {
 			},
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusNotFound, w.Code)
+				requireErrorCode(t, w.Body, CodeNotFound)
 			},
 		},
 		{
@@ -108,6 +110,7 @@ func TestGetAccount(t *testing.T) {
 			},
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, w.Code)
+				requireErrorCode(t, w.Body, CodeInternal)
 			},
 		},
 		{
@@ -137,6 +140,7 @@ func TestGetAccount(t *testing.T) {
 			store := mockdb.NewMockStore(ctrl)
 			// build stubs
 			tc.buildStubs(store)
+			stubAuthUsers(store)
 
 			// test server and send request
 			server := newTestServer(t, store)
@@ -260,6 +264,7 @@ func TestCreateAccount(t *testing.T) {
 			}
 			store := mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store, arg)
+			stubAuthUsers(store)
 
 			server := newTestServer(t, store)
 			w := httptest.NewRecorder()
@@ -277,32 +282,39 @@ func TestCreateAccount(t *testing.T) {
 
 func TestListAccount(t *testing.T) {
 	user, _ := randomUser()
-	accounts := make([]db.Account, 5)
-	for i := 0; i < 5; i++ {
-		accounts = append(accounts, randomAccount(user.Username))
+	n := 6
+	accounts := make([]db.Account, n)
+	for i := 0; i < n; i++ {
+		accounts[i] = randomAccount(user.Username)
+		accounts[i].ID = int64(i + 1)
 	}
+
+	type query struct {
+		cursor   string
+		pageSize int32
+	}
+
 	testCase := []struct {
 		setupAuth     func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request)
-		buildStubs    func(store *mockdb.MockStore, arg listAccountReq)
+		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
 		name          string
-		input         listAccountReq
+		input         query
 	}{
 		{
-			name: "OK",
-			input: listAccountReq{
-				PageID:   1,
-				PageSize: 5,
+			name: "FirstPage",
+			input: query{
+				pageSize: 5,
 			},
 			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
 				addAuthorization(t, tokenMaker, req, authTypeBearer, user.Username, time.Minute)
 			},
-			buildStubs: func(store *mockdb.MockStore, arg listAccountReq) {
+			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					ListAccounts(gomock.Any(), gomock.Eq(db.ListAccountsParams{
-						Owner:  user.Username,
-						Limit:  arg.PageSize,
-						Offset: (arg.PageID - 1) * arg.PageSize,
+						Owner:   user.Username,
+						AfterID: 0,
+						Limit:   6,
 					})).
 					Times(1).
 					Return(accounts, nil)
@@ -310,21 +322,78 @@ func TestListAccount(t *testing.T) {
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, w.Code)
 
-				var res []db.Account
-				err := json.Unmarshal(w.Body.Bytes(), &res)
-				require.NoError(t, err)
-				require.Equal(t, accounts, res)
+				res := requireBodyMatchAccountList(t, w.Body, accounts[:5])
+				require.Equal(t, encodeCursor(pageCursor{ID: accounts[4].ID}), res.NextCursor)
+				require.Empty(t, res.PrevCursor)
+			},
+		},
+		{
+			name: "NextPage",
+			input: query{
+				cursor:   encodeCursor(pageCursor{ID: accounts[2].ID}),
+				pageSize: 5,
+			},
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Eq(db.ListAccountsParams{
+						Owner:   user.Username,
+						AfterID: accounts[2].ID,
+						Limit:   6,
+					})).
+					Times(1).
+					Return(accounts[3:], nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+
+				res := requireBodyMatchAccountList(t, w.Body, accounts[3:])
+				require.Empty(t, res.NextCursor)
+				require.Equal(t, encodeCursor(pageCursor{ID: accounts[3].ID, Backward: true}), res.PrevCursor)
+			},
+		},
+		{
+			name: "PrevPage",
+			input: query{
+				cursor:   encodeCursor(pageCursor{ID: accounts[5].ID, Backward: true}),
+				pageSize: 5,
+			},
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				reversed := make([]db.Account, 0, 5)
+				for i := 4; i >= 0; i-- {
+					reversed = append(reversed, accounts[i])
+				}
+
+				store.EXPECT().
+					ListAccountsBefore(gomock.Any(), gomock.Eq(db.ListAccountsBeforeParams{
+						Owner:    user.Username,
+						BeforeID: accounts[5].ID,
+						Limit:    6,
+					})).
+					Times(1).
+					Return(reversed, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+
+				res := requireBodyMatchAccountList(t, w.Body, accounts[:5])
+				require.Equal(t, encodeCursor(pageCursor{ID: accounts[4].ID}), res.NextCursor)
+				require.Empty(t, res.PrevCursor)
 			},
 		},
 		{
 			name: "NoAuthorization",
-			input: listAccountReq{
-				PageID:   1,
-				PageSize: 5,
+			input: query{
+				pageSize: 5,
 			},
 			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
 			},
-			buildStubs: func(store *mockdb.MockStore, arg listAccountReq) {
+			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					ListAccounts(gomock.Any(), gomock.Any()).
 					Times(0)
@@ -334,33 +403,33 @@ func TestListAccount(t *testing.T) {
 			},
 		},
 		{
-			name: "InvalidPageId",
-			input: listAccountReq{
-				PageID:   0,
-				PageSize: 5,
+			name: "InvalidCursor",
+			input: query{
+				cursor:   "not-a-cursor",
+				pageSize: 5,
 			},
 			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
 				addAuthorization(t, tokenMaker, req, authTypeBearer, user.Username, time.Minute)
 			},
-			buildStubs: func(store *mockdb.MockStore, arg listAccountReq) {
+			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					ListAccounts(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, w.Code)
+				requireErrorCode(t, w.Body, CodeInvalidCursor)
 			},
 		},
 		{
 			name: "InvalidPageSize",
-			input: listAccountReq{
-				PageID:   1,
-				PageSize: 0,
+			input: query{
+				pageSize: 0,
 			},
 			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
 				addAuthorization(t, tokenMaker, req, authTypeBearer, user.Username, time.Minute)
 			},
-			buildStubs: func(store *mockdb.MockStore, arg listAccountReq) {
+			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					ListAccounts(gomock.Any(), gomock.Any()).
 					Times(0)
@@ -371,20 +440,15 @@ func TestListAccount(t *testing.T) {
 		},
 		{
 			name: "InternalServerError",
-			input: listAccountReq{
-				PageID:   1,
-				PageSize: 5,
+			input: query{
+				pageSize: 5,
 			},
 			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
 				addAuthorization(t, tokenMaker, req, authTypeBearer, user.Username, time.Minute)
 			},
-			buildStubs: func(store *mockdb.MockStore, arg listAccountReq) {
+			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					ListAccounts(gomock.Any(), gomock.Eq(db.ListAccountsParams{
-						Owner:  user.Username,
-						Limit:  arg.PageSize,
-						Offset: (arg.PageID - 1) * arg.PageSize,
-					})).
+					ListAccounts(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return([]db.Account{}, sql.ErrConnDone)
 			},
@@ -401,11 +465,122 @@ func TestListAccount(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			arg := tc.input
 			store := mockdb.NewMockStore(ctrl)
-			tc.buildStubs(store, arg)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+
+			url := fmt.Sprintf("/accounts?cursor=%s&page_size=%d", tc.input.cursor, tc.input.pageSize)
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			server := newTestServer(t, store)
+			w := httptest.NewRecorder()
+			tc.setupAuth(t, server.tokenMaker, req)
+			server.router.ServeHTTP(w, req)
+
+			tc.checkResponse(t, w)
+		})
+	}
+}
+
+func TestListEntries(t *testing.T) {
+	user, _ := randomUser()
+	account := randomAccount(user.Username)
+	entries := make([]db.Entry, 3)
+	for i := range entries {
+		entries[i] = db.Entry{
+			ID:        int64(i + 1),
+			AccountID: account.ID,
+			Amount:    util.RandomBalance(),
+		}
+	}
+
+	testCase := []struct {
+		setupAuth     func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		name          string
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					ListEntries(gomock.Any(), gomock.Eq(db.ListEntriesParams{
+						AccountID: account.ID,
+						AfterID:   0,
+						Limit:     6,
+					})).
+					Times(1).
+					Return(entries, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+
+				var res listRes[db.Entry]
+				err := json.Unmarshal(w.Body.Bytes(), &res)
+				require.NoError(t, err)
+				require.Equal(t, entries, res.Data)
+				require.Empty(t, res.NextCursor)
+				require.Empty(t, res.PrevCursor)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, "unauthorized_user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					ListEntries(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+			},
+		},
+		{
+			name: "AccountNotFound",
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().
+					ListEntries(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+	}
+
+	for i := range testCase {
+		tc := testCase[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
 
-			url := fmt.Sprintf("/accounts?page_id=%d&page_size=%d", arg.PageID, arg.PageSize)
+			url := fmt.Sprintf("/accounts/%d/entries?page_size=5", account.ID)
 			req, err := http.NewRequest(http.MethodGet, url, nil)
 			require.NoError(t, err)
 
@@ -437,3 +612,15 @@ func requireBodyMatchAccount(t *testing.T, body *bytes.Buffer, account db.Accoun
 	require.NoError(t, err)
 	require.Equal(t, account, gotAccount)
 }
+
+func requireBodyMatchAccountList(t *testing.T, body *bytes.Buffer, accounts []db.Account) listRes[db.Account] {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var res listRes[db.Account]
+	err = json.Unmarshal(data, &res)
+	require.NoError(t, err)
+	require.Equal(t, accounts, res.Data)
+
+	return res
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// apiKeyPrefix starts every API key, secret scanners and people can tell
+	// them apart from other tokens
+	apiKeyPrefix = "sbk_"
+	// apiKeyPrefixLen is how much of the key is kept in clear to recognize it
+	apiKeyPrefixLen = len(apiKeyPrefix) + 8
+)
+
+type createAPIKeyReq struct {
+	Name          string   `json:"name" binding:"required,max=64"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,unique,dive,scope"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"required,min=1,max=365"`
+}
+
+type apiKeyRes struct {
+	ExpiresAt  time.Time  `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ID         int64      `json:"id"`
+}
+
+func newAPIKeyRes(apiKey db.ApiKey) apiKeyRes {
+	res := apiKeyRes{
+		ID:        apiKey.ID,
+		Name:      apiKey.Name,
+		Prefix:    apiKey.Prefix,
+		Scopes:    apiKey.Scopes,
+		ExpiresAt: apiKey.ExpiresAt,
+		CreatedAt: apiKey.CreatedAt,
+	}
+	if !apiKey.LastUsedAt.IsZero() {
+		res.LastUsedAt = &apiKey.LastUsedAt
+	}
+	return res
+}
+
+type createAPIKeyRes struct {
+	// Key is only ever shown here, we keep its hash
+	Key string `json:"key"`
+	apiKeyRes
+}
+
+// createAPIKey issues a key a service can authenticate with as the user,
+// limited to the scopes asked for
+func (server *Server) createAPIKey(c *gin.Context) {
+	var req createAPIKeyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user, valid := server.authUser(c)
+	if !valid {
+		return
+	}
+
+	secret, _, err := util.NewSecretToken()
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+	key := apiKeyPrefix + secret
+
+	apiKey, err := server.store.CreateAPIKey(c, db.CreateAPIKeyParams{
+		Username:  user.Username,
+		Name:      req.Name,
+		Prefix:    key[:apiKeyPrefixLen],
+		KeyHash:   util.HashSecretToken(key),
+		Scopes:    req.Scopes,
+		ExpiresAt: time.Now().AddDate(0, 0, req.ExpiresInDays),
+	})
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, createAPIKeyRes{
+		Key:       key,
+		apiKeyRes: newAPIKeyRes(apiKey),
+	})
+}
+
+// listAPIKeys returns the keys of the user that are not revoked, expired ones
+// included
+func (server *Server) listAPIKeys(c *gin.Context) {
+	user, valid := server.authUser(c)
+	if !valid {
+		return
+	}
+
+	apiKeys, err := server.store.ListAPIKeys(c, user.Username)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	res := make([]apiKeyRes, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		res[i] = newAPIKeyRes(apiKey)
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+type revokeAPIKeyReq struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// revokeAPIKey stops a key of the user from working, right away
+func (server *Server) revokeAPIKey(c *gin.Context) {
+	var req revokeAPIKeyReq
+	if err := c.ShouldBindUri(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user, valid := server.authUser(c)
+	if !valid {
+		return
+	}
+
+	_, err := server.store.RevokeAPIKey(c, db.RevokeAPIKeyParams{
+		ID:       req.ID,
+		Username: user.Username,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			abortWithError(c, http.StatusNotFound, newError(CodeNotFound, "API key %d not found", req.ID))
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,437 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func randomAPIKey(t *testing.T, username string, scopes ...string) (string, db.ApiKey) {
+	secret, _, err := util.NewSecretToken()
+	require.NoError(t, err)
+	key := apiKeyPrefix + secret
+
+	return key, db.ApiKey{
+		ID:        util.RandomInt(1, 1000),
+		Username:  username,
+		Name:      util.RandomOwner(),
+		Prefix:    key[:apiKeyPrefixLen],
+		KeyHash:   util.HashSecretToken(key),
+		Scopes:    scopes,
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedAt: time.Now().Add(-time.Hour),
+	}
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	user, _ := randomUser()
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		name          string
+		body          string
+	}{
+		{
+			name: "OK",
+			body: `{"name": "payroll", "scopes": ["accounts:read", "transfers:write"], "expires_in_days": 30}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateAPIKeyParams) (db.ApiKey, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, "payroll", arg.Name)
+						require.Equal(t, []string{token.ScopeAccountsRead, token.ScopeTransfersWrite}, arg.Scopes)
+						require.True(t, strings.HasPrefix(arg.Prefix, apiKeyPrefix))
+						require.Len(t, arg.Prefix, apiKeyPrefixLen)
+						require.WithinDuration(t, time.Now().AddDate(0, 0, 30), arg.ExpiresAt, time.Second)
+						return db.ApiKey{
+							ID:        1,
+							Username:  arg.Username,
+							Name:      arg.Name,
+							Prefix:    arg.Prefix,
+							KeyHash:   arg.KeyHash,
+							Scopes:    arg.Scopes,
+							ExpiresAt: arg.ExpiresAt,
+						}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, w.Code)
+
+				var res createAPIKeyRes
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				require.True(t, strings.HasPrefix(res.Key, res.Prefix))
+				require.Nil(t, res.LastUsedAt)
+				require.NotContains(t, w.Body.String(), "key_hash")
+			},
+		},
+		{
+			name: "UnknownScope",
+			body: `{"name": "payroll", "scopes": ["users:write"], "expires_in_days": 30}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+				requireErrorCode(t, w.Body, CodeInvalidArgument)
+			},
+		},
+		{
+			name: "NoScopes",
+			body: `{"name": "payroll", "scopes": [], "expires_in_days": 30}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name: "ExpiresTooLate",
+			body: `{"name": "payroll", "scopes": ["accounts:read"], "expires_in_days": 366}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: `{"name": "payroll", "scopes": ["accounts:read"], "expires_in_days": 30}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ApiKey{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+
+			server := newTestServer(t, store)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/users/me/api-keys", bytes.NewBufferString(tc.body))
+			addAuthorization(t, server.tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, w)
+		})
+	}
+}
+
+func TestListAPIKeys(t *testing.T) {
+	user, _ := randomUser()
+	_, apiKey1 := randomAPIKey(t, user.Username, token.ScopeAccountsRead)
+	_, apiKey2 := randomAPIKey(t, user.Username, token.ScopeTransfersWrite)
+	apiKey2.LastUsedAt = time.Now().Truncate(time.Second)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListAPIKeys(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return([]db.ApiKey{apiKey2, apiKey1}, nil)
+	stubAuthUsers(store)
+
+	server := newTestServer(t, store)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/users/me/api-keys", nil)
+	addAuthorization(t, server.tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+
+	server.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var res []apiKeyRes
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Len(t, res, 2)
+	require.Equal(t, apiKey2.ID, res[0].ID)
+	require.NotNil(t, res[0].LastUsedAt)
+	require.True(t, apiKey2.LastUsedAt.Equal(*res[0].LastUsedAt))
+	require.Equal(t, apiKey1.Prefix, res[1].Prefix)
+	require.Nil(t, res[1].LastUsedAt)
+	require.NotContains(t, w.Body.String(), apiKey1.KeyHash)
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	user, _ := randomUser()
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		name          string
+		id            string
+	}{
+		{
+			name: "OK",
+			id:   "7",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevokeAPIKey(gomock.Any(), gomock.Eq(db.RevokeAPIKeyParams{ID: 7, Username: user.Username})).
+					Times(1).
+					Return(db.ApiKey{ID: 7, RevokedAt: time.Now()}, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, w.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			id:   "7",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevokeAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ApiKey{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, w.Code)
+				requireErrorCode(t, w.Body, CodeNotFound)
+			},
+		},
+		{
+			name: "InvalidID",
+			id:   "0",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			id:   "7",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevokeAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ApiKey{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+
+			server := newTestServer(t, store)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodDelete, "/users/me/api-keys/"+tc.id, nil)
+			addAuthorization(t, server.tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, w)
+		})
+	}
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	user, _ := randomUser()
+
+	testCases := []struct {
+		buildKey      func(t *testing.T) (string, db.ApiKey)
+		buildStubs    func(store *mockdb.MockStore, apiKey db.ApiKey)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		name          string
+	}{
+		{
+			name: "OK",
+			buildKey: func(t *testing.T) (string, db.ApiKey) {
+				return randomAPIKey(t, user.Username, token.ScopeAccountsRead)
+			},
+			buildStubs: func(store *mockdb.MockStore, apiKey db.ApiKey) {
+				store.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), gomock.Eq(apiKey.KeyHash)).
+					Times(1).
+					Return(apiKey, nil)
+				store.EXPECT().
+					TouchAPIKey(gomock.Any(), gomock.Eq(apiKey.ID)).
+					Times(1).
+					Return(nil)
+				// a password change doesn't revoke API keys
+				changed := user
+				changed.PasswordChangedAt = time.Now()
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(changed, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+				require.Equal(t, user.Username, w.Body.String())
+			},
+		},
+		{
+			name: "MissingScope",
+			buildKey: func(t *testing.T) (string, db.ApiKey) {
+				return randomAPIKey(t, user.Username, token.ScopeTransfersWrite)
+			},
+			buildStubs: func(store *mockdb.MockStore, apiKey db.ApiKey) {
+				store.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Any()).Times(1).Return(apiKey, nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, w.Code)
+				requireErrorCode(t, w.Body, CodeInsufficientScope)
+			},
+		},
+		{
+			name: "UnknownKey",
+			buildKey: func(t *testing.T) (string, db.ApiKey) {
+				return randomAPIKey(t, user.Username, token.ScopeAccountsRead)
+			},
+			buildStubs: func(store *mockdb.MockStore, apiKey db.ApiKey) {
+				store.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Any()).Times(1).Return(db.ApiKey{}, sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+				requireErrorCode(t, w.Body, CodeUnauthenticated)
+			},
+		},
+		{
+			name: "Revoked",
+			buildKey: func(t *testing.T) (string, db.ApiKey) {
+				key, apiKey := randomAPIKey(t, user.Username, token.ScopeAccountsRead)
+				apiKey.RevokedAt = time.Now().Add(-time.Minute)
+				return key, apiKey
+			},
+			buildStubs: func(store *mockdb.MockStore, apiKey db.ApiKey) {
+				store.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Any()).Times(1).Return(apiKey, nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+				requireErrorCode(t, w.Body, CodeTokenRevoked)
+			},
+		},
+		{
+			name: "Expired",
+			buildKey: func(t *testing.T) (string, db.ApiKey) {
+				key, apiKey := randomAPIKey(t, user.Username, token.ScopeAccountsRead)
+				apiKey.ExpiresAt = time.Now().Add(-time.Minute)
+				return key, apiKey
+			},
+			buildStubs: func(store *mockdb.MockStore, apiKey db.ApiKey) {
+				store.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Any()).Times(1).Return(apiKey, nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+				requireErrorCode(t, w.Body, CodeTokenExpired)
+			},
+		},
+		{
+			name: "InternalError",
+			buildKey: func(t *testing.T) (string, db.ApiKey) {
+				return randomAPIKey(t, user.Username, token.ScopeAccountsRead)
+			},
+			buildStubs: func(store *mockdb.MockStore, apiKey db.ApiKey) {
+				store.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Any()).Times(1).Return(db.ApiKey{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			key, apiKey := tc.buildKey(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store, apiKey)
+
+			server := newTestServer(t, store)
+			authPath := "/auth"
+			server.router.GET(
+				authPath,
+				authMiddleware(server.tokenMaker, server.store),
+				requireScopes(token.ScopeAccountsRead),
+				func(ctx *gin.Context) {
+					ctx.String(http.StatusOK, ctx.MustGet(authPayloadKey).(*token.Payload).Username)
+				},
+			)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, authPath, nil)
+			req.Header.Set("authorization", fmt.Sprintf("%s %s", authTypeAPIKey, key))
+
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, w)
+		})
+	}
+}
+
+func TestLoginOnlyMiddleware(t *testing.T) {
+	user, _ := randomUser()
+	key, apiKey := randomAPIKey(t, user.Username, token.Scopes...)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// a key must not be able to mint more keys, whatever its scopes
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Any()).Times(1).Return(apiKey, nil)
+	store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+	store.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	w := httptest.NewRecorder()
+	body := `{"name": "more", "scopes": ["accounts:read"], "expires_in_days": 30}`
+	req := httptest.NewRequest(http.MethodPost, "/users/me/api-keys", bytes.NewBufferString(body))
+	req.Header.Set("authorization", fmt.Sprintf("%s %s", authTypeAPIKey, key))
+
+	server.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+	requireErrorCode(t, w.Body, CodePermissionDenied)
+}
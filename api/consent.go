@@ -0,0 +1,127 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/oauth"
+	"simplebank/token"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// activeConsent loads the consent that lets a third-party token read
+// accounts. It is nil for logins and API keys, they read every account of the
+// user.
+func (server *Server) activeConsent(c *gin.Context) (*db.Consent, bool) {
+	authPayload := c.MustGet(authPayloadKey).(*token.Payload)
+	if !authPayload.IsThirdParty() {
+		return nil, true
+	}
+
+	consent, err := server.store.GetActiveConsent(c, db.GetActiveConsentParams{
+		Username: authPayload.Username,
+		ClientID: authPayload.ClientID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			abortWithError(c, http.StatusForbidden, newError(CodeConsentRequired, "the user has not consented to share accounts with the client"))
+			return nil, false
+		}
+		abortWithError(c, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	return &consent, true
+}
+
+// readableAccount is ownedAccount for the routes reading an account, a
+// third-party token also needs a consent to the account with permission
+func (server *Server) readableAccount(c *gin.Context, accountID int64, permission string) (db.Account, bool) {
+	consent, valid := server.activeConsent(c)
+	if !valid {
+		return db.Account{}, false
+	}
+	if consent != nil && !oauth.ConsentAllows(*consent, accountID, permission) {
+		abortWithError(c, http.StatusForbidden, newError(CodeConsentRequired, "the consent does not cover the %s of account %d", permission, accountID))
+		return db.Account{}, false
+	}
+
+	return server.ownedAccount(c, accountID)
+}
+
+type accountConsentRes struct {
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	ClientID    string    `json:"client_id"`
+	AccountIDs  []int64   `json:"account_ids"`
+	Permissions []string  `json:"permissions"`
+	ID          int64     `json:"id"`
+}
+
+func newAccountConsentRes(consent db.Consent) accountConsentRes {
+	return accountConsentRes{
+		ID:          consent.ID,
+		ClientID:    consent.ClientID,
+		AccountIDs:  consent.AccountIds,
+		Permissions: consent.Permissions,
+		ExpiresAt:   consent.ExpiresAt,
+		CreatedAt:   consent.CreatedAt,
+	}
+}
+
+// listConsents returns the consents of the user that are not revoked,
+// expired and superseded ones included
+func (server *Server) listConsents(c *gin.Context) {
+	user, valid := server.authUser(c)
+	if !valid {
+		return
+	}
+
+	consents, err := server.store.ListConsents(c, user.Username)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	res := make([]accountConsentRes, len(consents))
+	for i, consent := range consents {
+		res[i] = newAccountConsentRes(consent)
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+type revokeConsentReq struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// revokeConsent stops the client of a consent from reading its accounts,
+// right away
+func (server *Server) revokeConsent(c *gin.Context) {
+	var req revokeConsentReq
+	if err := c.ShouldBindUri(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user, valid := server.authUser(c)
+	if !valid {
+		return
+	}
+
+	_, err := server.store.RevokeConsent(c, db.RevokeConsentParams{
+		ID:       req.ID,
+		Username: user.Username,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			abortWithError(c, http.StatusNotFound, newError(CodeNotFound, "consent %d not found", req.ID))
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
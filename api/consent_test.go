@@ -0,0 +1,315 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/oauth"
+	"simplebank/token"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func randomConsent(username, clientID string, accountIDs []int64, permissions ...string) db.Consent {
+	return db.Consent{
+		ID:          util.RandomInt(1, 1000),
+		ClientID:    clientID,
+		Username:    username,
+		AccountIds:  accountIDs,
+		Permissions: permissions,
+		ExpiresAt:   time.Now().Add(time.Hour),
+		CreatedAt:   time.Now().Add(-time.Hour),
+	}
+}
+
+func TestConsentEnforcement(t *testing.T) {
+	user, _ := randomUser()
+	account := randomAccount(user.Username)
+	clientID := util.RandomString(16)
+	activeConsent := db.GetActiveConsentParams{Username: user.Username, ClientID: clientID}
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		name          string
+		url           string
+	}{
+		{
+			name: "GetAccount",
+			url:  fmt.Sprintf("/accounts/%d", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetActiveConsent(gomock.Any(), gomock.Eq(activeConsent)).
+					Times(1).
+					Return(randomConsent(user.Username, clientID, []int64{account.ID}, oauth.PermissionBalances), nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+				requireBodyMatchAccount(t, w.Body, account)
+			},
+		},
+		{
+			name: "AccountNotConsented",
+			url:  fmt.Sprintf("/accounts/%d", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetActiveConsent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(randomConsent(user.Username, clientID, []int64{account.ID + 1}, oauth.PermissionBalances), nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, w.Code)
+				requireErrorCode(t, w.Body, CodeConsentRequired)
+			},
+		},
+		{
+			name: "NoConsent",
+			url:  fmt.Sprintf("/accounts/%d", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetActiveConsent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Consent{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, w.Code)
+				requireErrorCode(t, w.Body, CodeConsentRequired)
+			},
+		},
+		{
+			name: "ListAccounts",
+			url:  "/accounts?page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetActiveConsent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(randomConsent(user.Username, clientID, []int64{account.ID}, oauth.PermissionBalances), nil)
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Eq(db.ListAccountsParams{
+						Owner:      user.Username,
+						AccountIds: []int64{account.ID},
+						Limit:      6,
+					})).
+					Times(1).
+					Return([]db.Account{account}, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name: "ListAccountsWithoutBalances",
+			url:  "/accounts?page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetActiveConsent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(randomConsent(user.Username, clientID, []int64{account.ID}, oauth.PermissionTransactions), nil)
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, w.Code)
+				requireErrorCode(t, w.Body, CodeConsentRequired)
+			},
+		},
+		{
+			name: "EntriesWithoutTransactions",
+			url:  fmt.Sprintf("/accounts/%d/entries?page_size=5", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetActiveConsent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(randomConsent(user.Username, clientID, []int64{account.ID}, oauth.PermissionBalances), nil)
+				store.EXPECT().ListEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, w.Code)
+				requireErrorCode(t, w.Body, CodeConsentRequired)
+			},
+		},
+		{
+			name: "Transfers",
+			url:  fmt.Sprintf("/accounts/%d/transfers?page_size=5", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetActiveConsent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(randomConsent(user.Username, clientID, []int64{account.ID}, oauth.PermissionTransactions), nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListTransfers(gomock.Any(), gomock.Any()).Times(1).Return([]db.Transfer{}, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name: "StatementWithoutTransactions",
+			url:  fmt.Sprintf("/accounts/%d/statements?month=2024-01", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetActiveConsent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(randomConsent(user.Username, clientID, []int64{account.ID}, oauth.PermissionBalances), nil)
+				store.EXPECT().ListEntriesInPeriod(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, w.Code)
+				requireErrorCode(t, w.Body, CodeConsentRequired)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+
+			server := newTestServer(t, store)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			addClientAuthorization(t, server.tokenMaker, req, user.Username, clientID, token.ScopeAccountsRead, token.ScopeTransfersRead)
+
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, w)
+		})
+	}
+}
+
+func TestConsentNotNeededForLogin(t *testing.T) {
+	user, _ := randomUser()
+	account := randomAccount(user.Username)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetActiveConsent(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+	stubAuthUsers(store)
+
+	server := newTestServer(t, store)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d", account.ID), nil)
+	addAuthorization(t, server.tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+
+	server.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestListConsents(t *testing.T) {
+	user, _ := randomUser()
+	consent := randomConsent(user.Username, util.RandomString(16), []int64{1, 2}, oauth.PermissionBalances)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListConsents(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return([]db.Consent{consent}, nil)
+	stubAuthUsers(store)
+
+	server := newTestServer(t, store)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/users/me/consents", nil)
+	addAuthorization(t, server.tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+
+	server.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var res []accountConsentRes
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Len(t, res, 1)
+	require.Equal(t, consent.ID, res[0].ID)
+	require.Equal(t, consent.ClientID, res[0].ClientID)
+	require.Equal(t, consent.AccountIds, res[0].AccountIDs)
+	require.Equal(t, consent.Permissions, res[0].Permissions)
+}
+
+func TestRevokeConsent(t *testing.T) {
+	user, _ := randomUser()
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		name          string
+		id            string
+	}{
+		{
+			name: "OK",
+			id:   "7",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevokeConsent(gomock.Any(), gomock.Eq(db.RevokeConsentParams{ID: 7, Username: user.Username})).
+					Times(1).
+					Return(db.Consent{ID: 7, RevokedAt: time.Now()}, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, w.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			id:   "7",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevokeConsent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Consent{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, w.Code)
+				requireErrorCode(t, w.Body, CodeNotFound)
+			},
+		},
+		{
+			name: "InvalidID",
+			id:   "0",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeConsent(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+
+			server := newTestServer(t, store)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodDelete, "/users/me/consents/"+tc.id, nil)
+			addAuthorization(t, server.tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, w)
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"simplebank/oauth"
+	"simplebank/payment"
+	"simplebank/token"
+	"simplebank/totp"
+	"simplebank/util"
+	"simplebank/verification"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Error codes are part of the API contract, clients switch on them instead of
+// parsing messages. Never change the meaning of an existing code.
+const (
+	CodeInvalidArgument    = "invalid_argument"
+	CodeInvalidCursor      = "invalid_cursor"
+	CodeInvalidFile        = "invalid_file"
+	CodeCurrencyMismatch   = "currency_mismatch"
+	CodeUnauthenticated    = "unauthenticated"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeWeakPassword       = "weak_password"
+	CodeTokenExpired       = "token_expired"
+	CodeTokenRevoked       = "token_revoked"
+	CodeUserLocked         = "user_locked"
+	CodeTOTPRequired       = "totp_required"
+	CodeInvalidTOTP        = "invalid_totp"
+	CodeTOTPNotEnabled     = "totp_not_enabled"
+	CodePermissionDenied   = "permission_denied"
+	CodeAccountNotOwned    = "account_not_owned"
+	CodeInsufficientScope  = "insufficient_scope"
+	CodeConsentRequired    = "consent_required"
+	CodeEmailNotVerified   = "email_not_verified"
+	CodeNotFound           = "not_found"
+	CodeAlreadyExists      = "already_exists"
+	CodeConflict           = "conflict"
+	CodePayloadTooLarge    = "payload_too_large"
+	CodeRateLimited        = "rate_limited"
+	CodeUnavailable        = "unavailable"
+	CodeInternal           = "internal"
+)
+
+// statusCodes is the fallback code of errors without a more specific one
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            CodeInvalidArgument,
+	http.StatusUnauthorized:          CodeUnauthenticated,
+	http.StatusForbidden:             CodePermissionDenied,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusConflict:              CodeConflict,
+	http.StatusRequestEntityTooLarge: CodePayloadTooLarge,
+	http.StatusTooManyRequests:       CodeRateLimited,
+}
+
+// ErrorDetail points at the request field an error is about
+type ErrorDetail struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// ErrorBody is the error model of every failed request
+type ErrorBody struct {
+	Code      string        `json:"code"`
+	Message   string        `json:"message"`
+	Details   []ErrorDetail `json:"details,omitempty"`
+	RequestID string        `json:"request_id,omitempty"`
+}
+
+type errorRes struct {
+	Error ErrorBody `json:"error"`
+}
+
+// apiError is a domain error whose message is safe to show to clients
+type apiError struct {
+	code    string
+	message string
+}
+
+func newError(code, format string, args ...any) *apiError {
+	return &apiError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+func (err *apiError) Error() string {
+	return err.message
+}
+
+// abortWithError answers the request with status and the error model of err.
+// The cause is kept on the gin context for the logs, clients only see codes and
+// messages we wrote ourselves.
+func abortWithError(c *gin.Context, status int, err error) {
+	_ = c.Error(err)
+	c.AbortWithStatusJSON(status, errorResponse(c, status, err))
+}
+
+func errorResponse(c *gin.Context, status int, err error) errorRes {
+	body := newErrorBody(status, err)
+	body.RequestID = c.GetString(requestIDKey)
+	return errorRes{Error: body}
+}
+
+func newErrorBody(status int, err error) ErrorBody {
+	var (
+		apiErr         *apiError
+		validationErrs validator.ValidationErrors
+		pqErr          *pq.Error
+		syntaxErr      *json.SyntaxError
+		typeErr        *json.UnmarshalTypeError
+		numErr         *strconv.NumError
+		oauthErr       *oauth.Error
+	)
+
+	switch {
+	case errors.As(err, &apiErr):
+		return ErrorBody{Code: apiErr.code, Message: apiErr.message}
+	case errors.As(err, &validationErrs):
+		body := ErrorBody{Code: CodeInvalidArgument, Message: "invalid request parameters"}
+		for _, fieldErr := range validationErrs {
+			reason := fieldErr.Tag()
+			if fieldErr.Param() != "" {
+				reason += "=" + fieldErr.Param()
+			}
+			body.Details = append(body.Details, ErrorDetail{Field: fieldErr.Field(), Reason: reason})
+		}
+		return body
+	case errors.As(err, &typeErr):
+		return ErrorBody{
+			Code:    CodeInvalidArgument,
+			Message: "invalid request parameters",
+			Details: []ErrorDetail{{Field: typeErr.Field, Reason: "type=" + typeErr.Type.String()}},
+		}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrorBody{Code: CodeInvalidArgument, Message: "request body is not valid JSON"}
+	case errors.As(err, &numErr):
+		return ErrorBody{Code: CodeInvalidArgument, Message: fmt.Sprintf("%q is not a valid number", numErr.Num)}
+	case errors.As(err, &oauthErr):
+		return ErrorBody{Code: CodeInvalidArgument, Message: oauthErr.Description}
+	case errors.As(err, &pqErr):
+		return newPQErrorBody(status, pqErr)
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrorBody{Code: CodeNotFound, Message: "resource not found"}
+	case errors.Is(err, http.ErrMissingFile):
+		return ErrorBody{Code: CodeInvalidArgument, Message: "multipart field \"file\" is missing"}
+	case errors.Is(err, payment.ErrInvalidFile):
+		return ErrorBody{Code: CodeInvalidFile, Message: err.Error()}
+	case errors.Is(err, errInvalidCursor):
+		return ErrorBody{Code: CodeInvalidCursor, Message: err.Error()}
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return ErrorBody{Code: CodeInvalidCredentials, Message: "incorrect username or password"}
+	case errors.Is(err, util.ErrWeakPassword):
+		return ErrorBody{Code: CodeWeakPassword, Message: err.Error()}
+	case errors.Is(err, totp.ErrRequired):
+		return ErrorBody{Code: CodeTOTPRequired, Message: err.Error()}
+	case errors.Is(err, totp.ErrInvalidCode):
+		return ErrorBody{Code: CodeInvalidTOTP, Message: err.Error()}
+	case errors.Is(err, totp.ErrNotEnabled):
+		return ErrorBody{Code: CodeTOTPNotEnabled, Message: err.Error()}
+	case errors.Is(err, verification.ErrInvalidToken):
+		return ErrorBody{Code: CodeInvalidArgument, Message: err.Error()}
+	case errors.Is(err, verification.ErrAlreadyVerified):
+		return ErrorBody{Code: CodeConflict, Message: err.Error()}
+	case errors.Is(err, token.ErrExpiredToken):
+		return ErrorBody{Code: CodeTokenExpired, Message: err.Error()}
+	case errors.Is(err, token.ErrInvalidToken):
+		return ErrorBody{Code: CodeUnauthenticated, Message: err.Error()}
+	}
+
+	return newStatusErrorBody(status, err)
+}
+
+// newPQErrorBody never uses the message of the database, it may name tables,
+// constraints or values of other users
+func newPQErrorBody(status int, pqErr *pq.Error) ErrorBody {
+	if status < http.StatusInternalServerError {
+		switch pqErr.Code.Name() {
+		case "unique_violation":
+			return ErrorBody{Code: CodeAlreadyExists, Message: "resource already exists"}
+		case "foreign_key_violation":
+			return ErrorBody{Code: CodeNotFound, Message: "referenced resource does not exist"}
+		}
+	}
+
+	return newStatusErrorBody(status, pqErr)
+}
+
+func newStatusErrorBody(status int, err error) ErrorBody {
+	code, ok := statusCodes[status]
+	if !ok || status >= http.StatusInternalServerError {
+		return ErrorBody{Code: CodeInternal, Message: "internal server error"}
+	}
+
+	if _, ok := err.(*pq.Error); ok {
+		return ErrorBody{Code: code, Message: http.StatusText(status)}
+	}
+	return ErrorBody{Code: code, Message: err.Error()}
+}
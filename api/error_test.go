@@ -0,0 +1,192 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"simplebank/oauth"
+	"simplebank/payment"
+	"simplebank/token"
+	"simplebank/util"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func requireErrorCode(t *testing.T, body *bytes.Buffer, code string) ErrorBody {
+	var res errorRes
+	require.NoError(t, json.Unmarshal(body.Bytes(), &res))
+	require.Equal(t, code, res.Error.Code)
+	require.NotEmpty(t, res.Error.Message)
+	return res.Error
+}
+
+func TestNewErrorBody(t *testing.T) {
+	pqErr := &pq.Error{
+		Code:       "23505",
+		Message:    `duplicate key value violates unique constraint "owner_currency_key"`,
+		Constraint: "owner_currency_key",
+	}
+
+	testCases := []struct {
+		err     error
+		name    string
+		code    string
+		message string
+		status  int
+	}{
+		{
+			name:    "APIError",
+			status:  http.StatusBadRequest,
+			err:     newError(CodeCurrencyMismatch, "account [%d] currency mismatch: %s vs %s", 1, "USD", "EUR"),
+			code:    CodeCurrencyMismatch,
+			message: "account [1] currency mismatch: USD vs EUR",
+		},
+		{
+			name:    "WrappedAPIError",
+			status:  http.StatusNotFound,
+			err:     fmt.Errorf("load: %w", newError(CodeNotFound, "account 1 not found")),
+			code:    CodeNotFound,
+			message: "account 1 not found",
+		},
+		{
+			name:    "UniqueViolation",
+			status:  http.StatusForbidden,
+			err:     pqErr,
+			code:    CodeAlreadyExists,
+			message: "resource already exists",
+		},
+		{
+			name:    "ForeignKeyViolation",
+			status:  http.StatusForbidden,
+			err:     &pq.Error{Code: "23503", Message: `insert or update on table "accounts" violates foreign key constraint`},
+			code:    CodeNotFound,
+			message: "referenced resource does not exist",
+		},
+		{
+			name:    "PQErrorInternal",
+			status:  http.StatusInternalServerError,
+			err:     pqErr,
+			code:    CodeInternal,
+			message: "internal server error",
+		},
+		{
+			name:    "PQErrorOtherStatus",
+			status:  http.StatusBadRequest,
+			err:     &pq.Error{Code: "23514", Message: `new row for relation "accounts" violates check constraint`},
+			code:    CodeInvalidArgument,
+			message: "Bad Request",
+		},
+		{
+			name:    "NoRows",
+			status:  http.StatusNotFound,
+			err:     sql.ErrNoRows,
+			code:    CodeNotFound,
+			message: "resource not found",
+		},
+		{
+			name:    "InvalidFile",
+			status:  http.StatusBadRequest,
+			err:     fmt.Errorf("%w: no PmtInf block", payment.ErrInvalidFile),
+			code:    CodeInvalidFile,
+			message: "invalid pain.001 file: no PmtInf block",
+		},
+		{
+			name:    "MissingFile",
+			status:  http.StatusBadRequest,
+			err:     http.ErrMissingFile,
+			code:    CodeInvalidArgument,
+			message: `multipart field "file" is missing`,
+		},
+		{
+			name:    "InvalidCursor",
+			status:  http.StatusBadRequest,
+			err:     errInvalidCursor,
+			code:    CodeInvalidCursor,
+			message: "invalid cursor",
+		},
+		{
+			name:    "WrongPassword",
+			status:  http.StatusUnauthorized,
+			err:     bcrypt.ErrMismatchedHashAndPassword,
+			code:    CodeInvalidCredentials,
+			message: "incorrect username or password",
+		},
+		{
+			name:    "WeakPassword",
+			status:  http.StatusBadRequest,
+			err:     util.PasswordPolicy{MinLength: 10}.Validate("secret"),
+			code:    CodeWeakPassword,
+			message: "password is too weak: must contain at least 10 characters",
+		},
+		{
+			name:    "OAuthError",
+			status:  http.StatusBadRequest,
+			err:     &oauth.Error{Code: oauth.ErrCodeInvalidScope, Description: "unknown scope users:write"},
+			code:    CodeInvalidArgument,
+			message: "unknown scope users:write",
+		},
+		{
+			name:    "ExpiredToken",
+			status:  http.StatusUnauthorized,
+			err:     token.ErrExpiredToken,
+			code:    CodeTokenExpired,
+			message: "token has expired",
+		},
+		{
+			name:    "InvalidJSON",
+			status:  http.StatusBadRequest,
+			err:     json.Unmarshal([]byte(`{"amount":`), &struct{}{}),
+			code:    CodeInvalidArgument,
+			message: "request body is not valid JSON",
+		},
+		{
+			name:    "StatusFallback",
+			status:  http.StatusUnauthorized,
+			err:     errors.New("authorization header is not provided"),
+			code:    CodeUnauthenticated,
+			message: "authorization header is not provided",
+		},
+		{
+			name:    "Internal",
+			status:  http.StatusInternalServerError,
+			err:     sql.ErrConnDone,
+			code:    CodeInternal,
+			message: "internal server error",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			body := newErrorBody(tc.status, tc.err)
+			require.Equal(t, tc.code, body.Code)
+			require.Equal(t, tc.message, body.Message)
+		})
+	}
+}
+
+func TestErrorResponseValidation(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(`{"username": "user#1", "password": "secret"}`))
+	req.Header.Set(requestIDHeader, "test-request-id")
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	body := requireErrorCode(t, w.Body, CodeInvalidArgument)
+	require.Equal(t, "test-request-id", body.RequestID)
+	require.ElementsMatch(t, []ErrorDetail{
+		{Field: "username", Reason: "alphanum"},
+		{Field: "full_name", Reason: "required"},
+		{Field: "email", Reason: "required"},
+	}, body.Details)
+}
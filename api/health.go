@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readiness checks give up after this long, the orchestrator has its own timeout
+const readinessTimeout = 2 * time.Second
+
+type healthRes struct {
+	Status        string `json:"status"`
+	SchemaVersion int64  `json:"schema_version,omitempty"`
+}
+
+// healthz tells the orchestrator the process is alive, it doesn't touch any dependency
+// so a database outage doesn't get every instance restarted
+func (server *Server) healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, healthRes{Status: "ok"})
+}
+
+// readyz tells the orchestrator whether to route traffic here: the database must
+// be reachable and migrated to at least the schema version this binary embeds
+func (server *Server) readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c, readinessTimeout)
+	defer cancel()
+
+	if err := server.store.Ping(ctx); err != nil {
+		_ = c.Error(err)
+		abortWithError(c, http.StatusServiceUnavailable, newError(CodeUnavailable, "database is not reachable"))
+		return
+	}
+
+	version, dirty, err := server.store.SchemaVersion(ctx)
+	if err != nil {
+		_ = c.Error(err)
+		abortWithError(c, http.StatusServiceUnavailable, newError(CodeUnavailable, "database schema version is unknown"))
+		return
+	}
+
+	if dirty || version < server.schemaVersion {
+		err := newError(CodeUnavailable, "database schema is at version %d (dirty: %t), expected %d", version, dirty, server.schemaVersion)
+		abortWithError(c, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, healthRes{Status: "ready", SchemaVersion: version})
+}
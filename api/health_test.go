@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthz(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().Ping(gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"status": "ok"}`, w.Body.String())
+}
+
+func TestReadyz(t *testing.T) {
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore, version int64)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder, version int64)
+		name          string
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore, version int64) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().SchemaVersion(gomock.Any()).Times(1).Return(version, false, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder, version int64) {
+				require.Equal(t, http.StatusOK, w.Code)
+
+				var res healthRes
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				require.Equal(t, healthRes{Status: "ready", SchemaVersion: version}, res)
+			},
+		},
+		{
+			name: "DatabaseUnreachable",
+			buildStubs: func(store *mockdb.MockStore, version int64) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(sql.ErrConnDone)
+				store.EXPECT().SchemaVersion(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder, version int64) {
+				require.Equal(t, http.StatusServiceUnavailable, w.Code)
+				requireErrorCode(t, w.Body, CodeUnavailable)
+			},
+		},
+		{
+			name: "NoSchemaMigrations",
+			buildStubs: func(store *mockdb.MockStore, version int64) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().SchemaVersion(gomock.Any()).Times(1).Return(int64(0), false, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder, version int64) {
+				require.Equal(t, http.StatusServiceUnavailable, w.Code)
+				requireErrorCode(t, w.Body, CodeUnavailable)
+			},
+		},
+		{
+			name: "SchemaBehind",
+			buildStubs: func(store *mockdb.MockStore, version int64) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().SchemaVersion(gomock.Any()).Times(1).Return(version-1, false, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder, version int64) {
+				require.Equal(t, http.StatusServiceUnavailable, w.Code)
+				requireErrorCode(t, w.Body, CodeUnavailable)
+			},
+		},
+		{
+			name: "SchemaDirty",
+			buildStubs: func(store *mockdb.MockStore, version int64) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().SchemaVersion(gomock.Any()).Times(1).Return(version, true, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder, version int64) {
+				require.Equal(t, http.StatusServiceUnavailable, w.Code)
+				requireErrorCode(t, w.Body, CodeUnavailable)
+			},
+		},
+		{
+			name: "SchemaAhead",
+			buildStubs: func(store *mockdb.MockStore, version int64) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().SchemaVersion(gomock.Any()).Times(1).Return(version+1, false, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder, version int64) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.buildStubs(store, server.schemaVersion)
+
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			tc.checkResponse(t, w, server.schemaVersion)
+		})
+	}
+}
+
+func TestServerShutdown(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- server.Start("127.0.0.1:0")
+	}()
+
+	// give Start a moment to listen, Shutdown also covers a server that isn't serving yet
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, server.Shutdown(context.Background()))
+
+	select {
+	case err := <-errc:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Start didn't return after Shutdown")
+	}
+}
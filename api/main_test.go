@@ -1,28 +1,47 @@
 package api
 
 import (
+	"context"
 	"os"
+	mockdb "simplebank/db/mock"
 	db "simplebank/db/sqlc"
+	"simplebank/ratelimit"
 	"simplebank/util"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 )
 
 func newTestServer(t *testing.T, store db.Store) *Server {
 	config := util.Config{
-		TokenSymmetricKey:   util.RandomString(32),
-		AccessTokenDuration: time.Minute,
+		TokenSymmetricKey:     util.RandomString(32),
+		AccessTokenDuration:   time.Minute,
+		TOTPEncryptionKey:     util.RandomString(32),
+		MailFileDir:           t.TempDir(),
+		PasswordResetDuration: time.Minute,
+		PasswordRejectCommon:  true,
 	}
 
-	server, err := NewServer(config, store)
+	server, err := NewServer(config, store, ratelimit.NewMemoryLimiter(), ratelimit.Limits{})
 	require.NoError(t, err)
 
 	return server
 }
 
+// stubAuthUsers lets authMiddleware load whichever user a test authenticates
+// as, tests stubbing GetUser themselves must do it first
+func stubAuthUsers(store *mockdb.MockStore) {
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ context.Context, username string) (db.User, error) {
+			return db.User{Username: username, EmailVerified: true}, nil
+		})
+}
+
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode)
 	os.Exit(m.Run())
@@ -1,51 +1,294 @@
 package api
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
+	"log/slog"
+	"math"
 	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
+	"simplebank/metrics"
+	"simplebank/ratelimit"
 	"simplebank/token"
+	"simplebank/util"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	authPayloadKey = "auth_payload_key"
+	authUserKey    = "auth_user_key"
+	authAPIKeyKey  = "auth_api_key_key"
 	authTypeBearer = "Bearer"
+	authTypeAPIKey = "ApiKey"
 )
 
-func authMiddleware(tokenMaker *token.PasetoMaker) gin.HandlerFunc {
+// authMiddleware authenticates the request by an access token or, for
+// services, by an API key, and loads the user it acts for
+func authMiddleware(tokenMaker *token.PasetoMaker, store db.Store) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader("authorization")
 		if len(authorizationHeader) == 0 {
 			err := errors.New("authorization header is not provided")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			abortWithError(ctx, http.StatusUnauthorized, err)
 			return
 		}
 
 		fields := strings.Fields(authorizationHeader)
 		if len(fields) < 2 {
 			err := errors.New("invalid authorization header format")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			abortWithError(ctx, http.StatusUnauthorized, err)
 			return
 		}
 
-		authType := fields[0]
-		if authType != authTypeBearer {
+		var (
+			payload *token.Payload
+			valid   bool
+		)
+		switch authType := fields[0]; authType {
+		case authTypeBearer:
+			var err error
+			payload, err = tokenMaker.VerifyToken(fields[1])
+			if err != nil {
+				abortWithError(ctx, http.StatusUnauthorized, err)
+				return
+			}
+			if !payload.IsFor(token.AudienceHTTP) {
+				abortWithError(ctx, http.StatusForbidden, newError(CodePermissionDenied, "token is not meant for the HTTP API"))
+				return
+			}
+			if payload.Session && !authenticateSession(ctx, store, payload) {
+				return
+			}
+		case authTypeAPIKey:
+			if payload, valid = authenticateAPIKey(ctx, store, fields[1]); !valid {
+				return
+			}
+		default:
 			err := fmt.Errorf("unsupposed authorization type %s", authType)
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			abortWithError(ctx, http.StatusUnauthorized, err)
 			return
 		}
 
-		accessToken := fields[1]
-		payload, err := tokenMaker.VerifyToken(accessToken)
+		user, err := store.GetUser(ctx, payload.Username)
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			if errors.Is(err, sql.ErrNoRows) {
+				abortWithError(ctx, http.StatusUnauthorized, newError(CodeUnauthenticated, "user of the token does not exist"))
+				return
+			}
+			abortWithError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+
+		// changing or resetting the password signs out every session opened with
+		// the old one, API keys stay until they are revoked
+		if _, isAPIKey := ctx.Get(authAPIKeyKey); !isAPIKey && payload.IssuedAt.Before(user.PasswordChangedAt) {
+			abortWithError(ctx, http.StatusUnauthorized, newError(CodeTokenRevoked, "token was issued before the last password change"))
 			return
 		}
 
 		ctx.Set(authPayloadKey, payload)
+		ctx.Set(authUserKey, user)
+		ctx.Request = ctx.Request.WithContext(logging.With(ctx.Request.Context(), logging.KeyUsername, payload.Username))
+		ctx.Next()
+	}
+}
+
+// authenticateAPIKey looks up an API key by its hash and records its use. The
+// payload it returns stands in for an access token of the key owner with the
+// scopes of the key.
+func authenticateAPIKey(ctx *gin.Context, store db.Store, key string) (*token.Payload, bool) {
+	apiKey, err := store.GetAPIKeyByHash(ctx, util.HashSecretToken(key))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			abortWithError(ctx, http.StatusUnauthorized, newError(CodeUnauthenticated, "API key is invalid"))
+			return nil, false
+		}
+		abortWithError(ctx, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	if !apiKey.RevokedAt.IsZero() {
+		abortWithError(ctx, http.StatusUnauthorized, newError(CodeTokenRevoked, "API key was revoked"))
+		return nil, false
+	}
+	if time.Now().After(apiKey.ExpiresAt) {
+		abortWithError(ctx, http.StatusUnauthorized, newError(CodeTokenExpired, "API key has expired"))
+		return nil, false
+	}
+
+	if err := store.TouchAPIKey(ctx, apiKey.ID); err != nil {
+		abortWithError(ctx, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	ctx.Set(authAPIKeyKey, apiKey)
+	return &token.Payload{
+		Username:  apiKey.Username,
+		Audience:  token.AudienceHTTP,
+		Scopes:    apiKey.Scopes,
+		IssuedAt:  apiKey.CreatedAt,
+		ExpiredAt: apiKey.ExpiresAt,
+	}, true
+}
+
+// requireScopes keeps tokens and API keys without all of scopes away from
+// the route, tokens without scopes have them all
+func requireScopes(scopes ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := ctx.MustGet(authPayloadKey).(*token.Payload)
+		for _, scope := range scopes {
+			if !payload.HasScope(scope) {
+				abortWithError(ctx, http.StatusForbidden, newError(CodeInsufficientScope, "the %s scope is required", scope))
+				return
+			}
+		}
+		ctx.Next()
+	}
+}
+
+// loginOnlyMiddleware keeps scoped tokens, API keys and third-party tokens
+// away from routes managing the user, their credentials and consents, a
+// leaked one must not be able to mint more
+func loginOnlyMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if payload := ctx.MustGet(authPayloadKey).(*token.Payload); payload.IsScoped() {
+			abortWithError(ctx, http.StatusForbidden, newError(CodePermissionDenied, "only a login without scopes can manage the user"))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// verifiedEmailMiddleware keeps users who didn't verify their email address
+// away from opening accounts and moving money
+func verifiedEmailMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user := ctx.MustGet(authUserKey).(db.User)
+		if !user.EmailVerified {
+			abortWithError(ctx, http.StatusForbidden, newError(CodeEmailNotVerified, "email address %s is not verified", user.Email))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+const (
+	requestIDHeader = "X-Request-ID"
+	requestIDKey    = "request_id"
+)
+
+// requestIDMiddleware keeps the request id of the caller or assigns a new one,
+// it's echoed in the response header and in error responses
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := logging.RequestID(ctx.GetHeader(requestIDHeader))
+
+		ctx.Set(requestIDKey, requestID)
+		ctx.Header(requestIDHeader, requestID)
+		ctx.Next()
+	}
+}
+
+// loggerMiddleware puts a logger with the request id and route in the request
+// context, the store and the handlers log through it, and writes one access
+// log line per request when it's done
+func loggerMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		reqLogger := logger.With(
+			logging.KeyRequestID, ctx.GetString(requestIDKey),
+			logging.KeyRoute, ctx.FullPath(),
+		)
+		if spanContext := trace.SpanContextFromContext(ctx.Request.Context()); spanContext.IsValid() {
+			reqLogger = reqLogger.With(logging.KeyTraceID, spanContext.TraceID().String())
+		}
+		ctx.Request = ctx.Request.WithContext(logging.WithContext(ctx.Request.Context(), reqLogger))
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", ctx.Request.Method),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("client_ip", ctx.ClientIP()),
+			slog.Int("size", ctx.Writer.Size()),
+		}
+		if len(ctx.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", strings.Join(ctx.Errors.Errors(), "; ")))
+		}
+
+		// the auth middleware may have added the username since
+		logging.FromContext(ctx.Request.Context()).LogAttrs(ctx.Request.Context(), logging.StatusLevel(status), "request", attrs...)
+	}
+}
+
+// metricsMiddleware records the duration of every request by route and status
+func metricsMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
 		ctx.Next()
+		metrics.ObserveHTTPRequest(ctx.Request.Method, ctx.FullPath(), ctx.Writer.Status(), time.Since(start))
+	}
+}
+
+// clientIPKey limits public routes by caller address
+func clientIPKey(ctx *gin.Context) string {
+	return "ip:" + ctx.ClientIP()
+}
+
+// usernameKey limits authenticated routes by user, whatever address they use
+func usernameKey(ctx *gin.Context) string {
+	authPayload := ctx.MustGet(authPayloadKey).(*token.Payload)
+	return "user:" + authPayload.Username
+}
+
+// setRetryAfter tells the client in whole seconds when to try again
+func setRetryAfter(ctx *gin.Context, wait time.Duration) int {
+	seconds := max(int(math.Ceil(wait.Seconds())), 1)
+	ctx.Header("Retry-After", strconv.Itoa(seconds))
+	return seconds
+}
+
+// rateLimitMiddleware takes a token from the bucket of the caller in group,
+// requests over the limit are answered 429 with a Retry-After header. The
+// limiter failing must not take the API down, the request goes through then.
+func rateLimitMiddleware(limiter ratelimit.Limiter, group string, limit ratelimit.Limit, key func(*gin.Context) string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if allowRequest(ctx, limiter, group, limit, key, 1) {
+			ctx.Next()
+		}
+	}
+}
+
+// allowRequest takes n tokens from the bucket of the caller in group, the
+// request is aborted with 429 when fewer are left
+func allowRequest(ctx *gin.Context, limiter ratelimit.Limiter, group string, limit ratelimit.Limit, key func(*gin.Context) string, n int) bool {
+	if !limit.Enabled() {
+		return true
+	}
+
+	result, err := limiter.AllowN(ctx, group+":"+key(ctx), limit, n)
+	if err != nil {
+		logging.FromContext(ctx).Warn("rate limiter failed, request let through", "group", group, "error", err)
+		return true
+	}
+
+	ctx.Header("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+	ctx.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	if !result.Allowed {
+		retryAfter := setRetryAfter(ctx, result.RetryAfter)
+		err := newError(CodeRateLimited, "too many requests, retry in %d seconds", retryAfter)
+		abortWithError(ctx, http.StatusTooManyRequests, err)
+		return false
 	}
+	return true
 }
@@ -1,15 +1,36 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/gapi"
+	"simplebank/logging"
+	"simplebank/metrics"
+	"simplebank/pb"
+	"simplebank/ratelimit"
 	"simplebank/token"
+	"simplebank/util"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
 )
 
 func addAuthorization(
@@ -20,7 +41,7 @@ func addAuthorization(
 	username string,
 	duration time.Duration,
 ) {
-	token, err := tokenMaker.CreateToken(username, duration)
+	token, _, err := tokenMaker.CreateToken(username, duration, token.Options{})
 	require.NoError(t, err)
 
 	authorizationHeader := fmt.Sprintf("%s %s", authType, token)
@@ -30,6 +51,7 @@ func addAuthorization(
 func TestAuthMiddleware(t *testing.T) {
 	testCase := []struct {
 		setupAuth     func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request)
+		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
 		name          string
 	}{
@@ -38,6 +60,12 @@ func TestAuthMiddleware(t *testing.T) {
 			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
 				addAuthorization(t, tokenMaker, req, authTypeBearer, "user", time.Minute)
 			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq("user")).
+					Times(1).
+					Return(db.User{Username: "user", PasswordChangedAt: time.Now().Add(-time.Hour)}, nil)
+			},
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, w.Code)
 			},
@@ -46,6 +74,9 @@ func TestAuthMiddleware(t *testing.T) {
 			name: "NoAuthorization",
 			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
 			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, w.Code)
 			},
@@ -55,6 +86,9 @@ func TestAuthMiddleware(t *testing.T) {
 			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
 				addAuthorization(t, tokenMaker, req, "unsupposed", "user", time.Minute)
 			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, w.Code)
 			},
@@ -64,6 +98,9 @@ func TestAuthMiddleware(t *testing.T) {
 			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
 				addAuthorization(t, tokenMaker, req, "", "user", time.Minute)
 			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, w.Code)
 			},
@@ -73,8 +110,58 @@ func TestAuthMiddleware(t *testing.T) {
 			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
 				addAuthorization(t, tokenMaker, req, authTypeBearer, "user", -time.Minute)
 			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+			},
+		},
+		{
+			name: "PasswordChangedSince",
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, "user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq("user")).
+					Times(1).
+					Return(db.User{Username: "user", PasswordChangedAt: time.Now().Add(time.Second)}, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+				requireErrorCode(t, w.Body, CodeTokenRevoked)
+			},
+		},
+		{
+			name: "UserNotFound",
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, "user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq("user")).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+			},
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, w.Code)
+				requireErrorCode(t, w.Body, CodeUnauthenticated)
+			},
+		},
+		{
+			name: "InternalError",
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, "user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq("user")).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, w.Code)
 			},
 		},
 	}
@@ -83,12 +170,18 @@ func TestAuthMiddleware(t *testing.T) {
 		tc := testCase[i]
 
 		t.Run(tc.name, func(t *testing.T) {
-			server := newTestServer(t, nil)
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
 
 			authPath := "/auth"
 			server.router.GET(
 				authPath,
-				authMiddleware(server.tokenMaker),
+				authMiddleware(server.tokenMaker, server.store),
 				func(ctx *gin.Context) {
 					ctx.JSON(http.StatusOK, gin.H{})
 				},
@@ -105,3 +198,386 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestRequireScopes(t *testing.T) {
+	user, _ := randomUser()
+	account := randomAccount(user.Username)
+
+	testCases := []struct {
+		buildStubs func(store *mockdb.MockStore)
+		name       string
+		method     string
+		url        string
+		body       string
+		options    token.Options
+		status     int
+		code       string
+	}{
+		{
+			name:    "ReadOnly",
+			method:  http.MethodGet,
+			url:     fmt.Sprintf("/accounts/%d", account.ID),
+			options: token.Options{Scopes: []string{token.ScopeAccountsRead}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:    "ReadOnlyTransfer",
+			method:  http.MethodPost,
+			url:     "/transfers",
+			body:    `{"from_account_id": 1, "to_account_id": 2, "amount": 10, "currency": "USD"}`,
+			options: token.Options{Scopes: []string{token.ScopeAccountsRead}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			status: http.StatusForbidden,
+			code:   CodeInsufficientScope,
+		},
+		{
+			name:       "ScopedLoginManagingUser",
+			method:     http.MethodGet,
+			url:        "/users/me",
+			options:    token.Options{Scopes: token.Scopes},
+			buildStubs: func(store *mockdb.MockStore) {},
+			status:     http.StatusForbidden,
+			code:       CodePermissionDenied,
+		},
+		{
+			name:       "GRPCAudience",
+			method:     http.MethodGet,
+			url:        fmt.Sprintf("/accounts/%d", account.ID),
+			options:    token.Options{Audience: token.AudienceGRPC},
+			buildStubs: func(store *mockdb.MockStore) {},
+			status:     http.StatusForbidden,
+			code:       CodePermissionDenied,
+		},
+		{
+			name:    "HTTPAudience",
+			method:  http.MethodGet,
+			url:     fmt.Sprintf("/accounts/%d", account.ID),
+			options: token.Options{Audience: token.AudienceHTTP},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			status: http.StatusOK,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+
+			server := newTestServer(t, store)
+			accessToken, _, err := server.tokenMaker.CreateToken(user.Username, time.Minute, tc.options)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, tc.url, bytes.NewBufferString(tc.body))
+			req.Header.Set("authorization", fmt.Sprintf("%s %s", authTypeBearer, accessToken))
+
+			server.router.ServeHTTP(w, req)
+			require.Equal(t, tc.status, w.Code)
+			if tc.code != "" {
+				requireErrorCode(t, w.Body, tc.code)
+			}
+		})
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	testCases := []struct {
+		name      string
+		requestID string
+		keep      bool
+	}{
+		{name: "Propagated", requestID: "4f1c2a9e-req", keep: true},
+		{name: "Missing", requestID: ""},
+		{name: "TooLong", requestID: string(bytes.Repeat([]byte("a"), 65))},
+		{name: "ControlCharacters", requestID: "id\nforged log line"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(requestIDMiddleware())
+
+			var requestID string
+			router.GET("/", func(c *gin.Context) {
+				requestID = c.GetString(requestIDKey)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(requestIDHeader, tc.requestID)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.NotEmpty(t, requestID)
+			require.Equal(t, requestID, w.Header().Get(requestIDHeader))
+			if tc.keep {
+				require.Equal(t, tc.requestID, requestID)
+			} else {
+				require.NotEqual(t, tc.requestID, requestID)
+			}
+		})
+	}
+}
+
+func TestLoggerMiddleware(t *testing.T) {
+	tokenMaker, err := token.NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	stubAuthUsers(store)
+
+	var buf bytes.Buffer
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.Use(requestIDMiddleware(), loggerMiddleware(logging.New(&buf, "info")))
+
+	var handlerLogger *slog.Logger
+	router.GET("/accounts/:id", authMiddleware(tokenMaker, store), func(c *gin.Context) {
+		handlerLogger = logging.FromContext(c)
+		abortWithError(c, http.StatusNotFound, newError(CodeNotFound, "account 1 not found"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+	req.Header.Set(requestIDHeader, "test-request-id")
+	addAuthorization(t, tokenMaker, req, authTypeBearer, "alice", time.Minute)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.NotEqual(t, slog.Default(), handlerLogger)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "request", entry["msg"])
+	require.Equal(t, "WARN", entry["level"])
+	require.Equal(t, "test-request-id", entry[logging.KeyRequestID])
+	require.Equal(t, "/accounts/:id", entry[logging.KeyRoute])
+	require.Equal(t, "alice", entry[logging.KeyUsername])
+	require.Equal(t, float64(http.StatusNotFound), entry["status"])
+	require.Equal(t, "account 1 not found", entry["error"])
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/accounts/1", nil))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// the metrics are served on their own port, not by the API
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Contains(t, w.Body.String(), `simple_bank_http_request_duration_seconds_count{method="GET",route="/accounts/:id",status="401"}`)
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	server := newTestServer(t, nil)
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "/accounts/:id", spans[0].Name())
+	require.Equal(t, traceID, spans[0].SpanContext().TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	config := util.Config{
+		TokenSymmetricKey:   util.RandomString(32),
+		AccessTokenDuration: time.Minute,
+		TOTPEncryptionKey:   util.RandomString(32),
+		RateLimitPublic:     "2/1m",
+		RateLimitAPIByIP:    "3/1m",
+		RateLimitAPI:        "2/1m",
+		RateLimitTransfers:  "1/1m",
+	}
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	stubAuthUsers(store)
+	limiter, limits, err := ratelimit.New(config, store)
+	require.NoError(t, err)
+	server, err := NewServer(config, store, limiter, limits)
+	require.NoError(t, err)
+
+	type request struct {
+		method     string
+		path       string
+		remoteAddr string
+		username   string
+		status     int
+	}
+
+	testCases := []struct {
+		name     string
+		requests []request
+	}{
+		{
+			name: "PublicByIP",
+			requests: []request{
+				{method: http.MethodPost, path: "/users/login", remoteAddr: "10.0.0.1:1234", status: http.StatusBadRequest},
+				{method: http.MethodPost, path: "/users", remoteAddr: "10.0.0.1:1235", status: http.StatusBadRequest},
+				{method: http.MethodPost, path: "/users/login", remoteAddr: "10.0.0.1:1236", status: http.StatusTooManyRequests},
+				{method: http.MethodPost, path: "/users/login", remoteAddr: "10.0.0.2:1234", status: http.StatusBadRequest},
+			},
+		},
+		{
+			name: "APIByUsername",
+			requests: []request{
+				{method: http.MethodGet, path: "/accounts", remoteAddr: "10.0.1.1:1234", username: "alice", status: http.StatusBadRequest},
+				{method: http.MethodGet, path: "/accounts", remoteAddr: "10.0.1.2:1234", username: "alice", status: http.StatusBadRequest},
+				{method: http.MethodGet, path: "/accounts", remoteAddr: "10.0.1.3:1234", username: "alice", status: http.StatusTooManyRequests},
+				{method: http.MethodGet, path: "/accounts", remoteAddr: "10.0.1.1:1234", username: "bob", status: http.StatusBadRequest},
+			},
+		},
+		{
+			name: "APIByIPBeforeAuth",
+			requests: []request{
+				{method: http.MethodGet, path: "/accounts", remoteAddr: "10.0.3.1:1234", status: http.StatusUnauthorized},
+				{method: http.MethodGet, path: "/accounts", remoteAddr: "10.0.3.1:1235", status: http.StatusUnauthorized},
+				{method: http.MethodGet, path: "/accounts", remoteAddr: "10.0.3.1:1236", status: http.StatusUnauthorized},
+				{method: http.MethodGet, path: "/accounts", remoteAddr: "10.0.3.1:1237", status: http.StatusTooManyRequests},
+				{method: http.MethodGet, path: "/accounts", remoteAddr: "10.0.3.2:1234", status: http.StatusUnauthorized},
+			},
+		},
+		{
+			name: "Transfers",
+			requests: []request{
+				{method: http.MethodPost, path: "/transfers", remoteAddr: "10.0.2.1:1234", username: "carol", status: http.StatusBadRequest},
+				{method: http.MethodPost, path: "/transfers", remoteAddr: "10.0.2.1:1234", username: "carol", status: http.StatusTooManyRequests},
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			for _, r := range tc.requests {
+				req := httptest.NewRequest(r.method, r.path, nil)
+				req.RemoteAddr = r.remoteAddr
+				if r.username != "" {
+					addAuthorization(t, server.tokenMaker, req, authTypeBearer, r.username, time.Minute)
+				}
+				w := httptest.NewRecorder()
+				server.router.ServeHTTP(w, req)
+
+				require.Equal(t, r.status, w.Code)
+				if r.status == http.StatusTooManyRequests {
+					requireErrorCode(t, w.Body, CodeRateLimited)
+					require.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+					require.NotEmpty(t, w.Header().Get("Retry-After"))
+				} else {
+					require.Empty(t, w.Header().Get("Retry-After"))
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitSharedWithGRPC(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	config := util.Config{
+		TokenSymmetricKey:   util.RandomString(32),
+		AccessTokenDuration: time.Minute,
+		TOTPEncryptionKey:   util.RandomString(32),
+	}
+	// the servers main starts, all on the one limiter
+	limiter := ratelimit.NewMemoryLimiter()
+	limits := ratelimit.Limits{Public: ratelimit.Limit{Rate: 1.0 / 3600, Burst: 1}}
+
+	server, err := NewServer(config, store, limiter, limits)
+	require.NoError(t, err)
+	grpcServer, err := gapi.NewServer(config, store, limiter, limits)
+	require.NoError(t, err)
+
+	// a login over gRPC spends the bucket of the address
+	info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_LoginUser_FullMethodName}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 4000}})
+	handled := 0
+	_, err = grpcServer.RateLimiter()(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+		handled++
+		return nil, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, handled)
+
+	// so the next one over HTTP from the same address is over the limit
+	req := httptest.NewRequest(http.MethodPost, "/users/login", nil)
+	req.RemoteAddr = "192.0.2.1:4001"
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	requireErrorCode(t, w.Body, CodeRateLimited)
+}
+
+func TestRateLimitMiddlewareFailOpen(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		DeleteRateLimitBucketsBefore(gomock.Any(), gomock.Any()).
+		AnyTimes()
+	store.EXPECT().
+		TakeRateLimitToken(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(0.0, sql.ErrConnDone)
+
+	router := gin.New()
+	limit := ratelimit.Limit{Rate: 1, Burst: 1}
+	router.GET("/", rateLimitMiddleware(ratelimit.NewPostgresLimiter(store), "public", limit, clientIPKey), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestNewServerInvalidTrustedProxy(t *testing.T) {
+	config := util.Config{
+		TokenSymmetricKey: util.RandomString(32),
+		TOTPEncryptionKey: util.RandomString(32),
+		TrustedProxies:    []string{"not-an-ip"},
+	}
+
+	_, err := NewServer(config, nil, ratelimit.NewMemoryLimiter(), ratelimit.Limits{})
+	require.Error(t, err)
+}
@@ -0,0 +1,278 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/oauth"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type registerOAuthClientReq struct {
+	Name         string   `json:"name" binding:"required,max=64"`
+	RedirectURIs []string `json:"redirect_uris" binding:"required,min=1,max=5,dive,url"`
+	Scopes       []string `json:"scopes" binding:"required,min=1,unique,dive,scope"`
+}
+
+type oauthClientRes struct {
+	CreatedAt    time.Time `json:"created_at"`
+	ClientID     string    `json:"client_id"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+}
+
+func newOAuthClientRes(client db.OauthClient) oauthClientRes {
+	return oauthClientRes{
+		ClientID:     client.ID,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectUris,
+		Scopes:       client.Scopes,
+		CreatedAt:    client.CreatedAt,
+	}
+}
+
+type registerOAuthClientRes struct {
+	// ClientSecret is only ever shown here, we keep its hash
+	ClientSecret string `json:"client_secret"`
+	oauthClientRes
+}
+
+// registerOAuthClient registers a third-party app owned by the user
+func (server *Server) registerOAuthClient(c *gin.Context) {
+	var req registerOAuthClientReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user, valid := server.authUser(c)
+	if !valid {
+		return
+	}
+
+	client, secret, err := server.oauth.RegisterClient(c, oauth.RegisterClientParams{
+		Owner:        user.Username,
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		Scopes:       req.Scopes,
+	})
+	if err != nil {
+		abortWithError(c, oauthErrorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, registerOAuthClientRes{
+		ClientSecret:   secret,
+		oauthClientRes: newOAuthClientRes(client),
+	})
+}
+
+// listOAuthClients returns the apps the user registered
+func (server *Server) listOAuthClients(c *gin.Context) {
+	user, valid := server.authUser(c)
+	if !valid {
+		return
+	}
+
+	clients, err := server.store.ListOAuthClients(c, user.Username)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	res := make([]oauthClientRes, len(clients))
+	for i, client := range clients {
+		res[i] = newOAuthClientRes(client)
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+type authorizeReq struct {
+	ResponseType        string `form:"response_type" json:"response_type" binding:"required"`
+	ClientID            string `form:"client_id" json:"client_id" binding:"required"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge" binding:"required"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method" binding:"required"`
+}
+
+func (req authorizeReq) authorizeRequest() oauth.AuthorizeRequest {
+	return oauth.AuthorizeRequest{
+		ResponseType:        req.ResponseType,
+		ClientID:            req.ClientID,
+		RedirectURI:         req.RedirectURI,
+		Scope:               req.Scope,
+		State:               req.State,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+	}
+}
+
+type consentRes struct {
+	ClientID    string   `json:"client_id"`
+	ClientName  string   `json:"client_name"`
+	RedirectURI string   `json:"redirect_uri"`
+	State       string   `json:"state,omitempty"`
+	Scopes      []string `json:"scopes"`
+}
+
+// getAuthorize validates the authorization request the app sent the user
+// with and describes what the consent screen has to show
+func (server *Server) getAuthorize(c *gin.Context) {
+	var req authorizeReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	consent, err := server.oauth.Authorize(c, req.authorizeRequest())
+	if err != nil {
+		abortWithError(c, oauthErrorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, consentRes{
+		ClientID:    consent.Client.ID,
+		ClientName:  consent.Client.Name,
+		RedirectURI: consent.RedirectURI,
+		State:       consent.State,
+		Scopes:      consent.Scopes,
+	})
+}
+
+type postAuthorizeReq struct {
+	authorizeReq
+	// AccountIDs, Permissions and ConsentDays are the consent to read
+	// accounts the user gives along with an approval, if any
+	AccountIDs  []int64  `json:"account_ids" binding:"omitempty,max=20,unique,dive,min=1"`
+	Permissions []string `json:"permissions" binding:"required_with=AccountIDs,omitempty,unique,dive,oneof=balances transactions"`
+	ConsentDays int      `json:"consent_days" binding:"required_with=AccountIDs,omitempty,min=1,max=90"`
+	Approved    bool     `json:"approved"`
+}
+
+type postAuthorizeRes struct {
+	RedirectTo string `json:"redirect_to"`
+}
+
+// postAuthorize records the decision of the user on the consent screen. The
+// client learns it through the redirect_to URI, with an authorization code
+// when approved. An approval may give the client a consent to read accounts.
+func (server *Server) postAuthorize(c *gin.Context) {
+	var req postAuthorizeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user, valid := server.authUser(c)
+	if !valid {
+		return
+	}
+
+	var (
+		redirectTo string
+		err        error
+	)
+	if req.Approved {
+		// the user can only share accounts of their own
+		for _, accountID := range req.AccountIDs {
+			if _, valid := server.ownedAccount(c, accountID); !valid {
+				return
+			}
+		}
+
+		redirectTo, err = server.oauth.Approve(c, user.Username, req.authorizeRequest(), oauth.AccountAccess{
+			AccountIDs:  req.AccountIDs,
+			Permissions: req.Permissions,
+			Duration:    time.Duration(req.ConsentDays) * 24 * time.Hour,
+		})
+	} else {
+		redirectTo, err = server.oauth.Deny(c, req.authorizeRequest())
+	}
+	if err != nil {
+		abortWithError(c, oauthErrorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, postAuthorizeRes{RedirectTo: redirectTo})
+}
+
+type tokenReq struct {
+	GrantType    string `form:"grant_type" binding:"required"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	Scope        string `form:"scope"`
+}
+
+// oauthErrorRes is the error response of RFC 6749 section 5.2, OAuth
+// libraries expect it from the token endpoint instead of our error model
+type oauthErrorRes struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+// oauthToken is the token endpoint, clients authenticate with HTTP Basic or
+// with client_id and client_secret in the form
+func (server *Server) oauthToken(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var req tokenReq
+	if err := c.ShouldBind(&req); err != nil {
+		_ = c.Error(err)
+		c.AbortWithStatusJSON(http.StatusBadRequest, oauthErrorRes{
+			Error:       oauth.ErrCodeInvalidRequest,
+			Description: "grant_type is required",
+		})
+		return
+	}
+	if clientID, clientSecret, ok := c.Request.BasicAuth(); ok {
+		req.ClientID, req.ClientSecret = clientID, clientSecret
+	}
+
+	res, err := server.oauth.Token(c, oauth.TokenRequest{
+		GrantType:    req.GrantType,
+		ClientID:     req.ClientID,
+		ClientSecret: req.ClientSecret,
+		Code:         req.Code,
+		RedirectURI:  req.RedirectURI,
+		CodeVerifier: req.CodeVerifier,
+		Scope:        req.Scope,
+	})
+	if err != nil {
+		var oauthErr *oauth.Error
+		if !errors.As(err, &oauthErr) {
+			abortWithError(c, http.StatusInternalServerError, err)
+			return
+		}
+		status := http.StatusBadRequest
+		if oauthErr.Code == oauth.ErrCodeInvalidClient {
+			status = http.StatusUnauthorized
+		}
+		_ = c.Error(err)
+		c.AbortWithStatusJSON(status, oauthErrorRes{
+			Error:       oauthErr.Code,
+			Description: oauthErr.Description,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// oauthErrorStatus is the status of an error of the registration and consent
+// routes, the user is signed in there whatever the client did wrong
+func oauthErrorStatus(err error) int {
+	var oauthErr *oauth.Error
+	if errors.As(err, &oauthErr) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
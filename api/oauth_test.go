@@ -0,0 +1,601 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/oauth"
+	"simplebank/token"
+	"simplebank/util"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+const testCodeVerifier = "dBjftJeZ4CVP-mJ92K1riWB8UgwDjSL1XiVgd3qoWSLJAIWGlMT8T0"
+
+func randomOAuthClient(t *testing.T, owner string) (db.OauthClient, string) {
+	secret, _, err := util.NewSecretToken()
+	require.NoError(t, err)
+	secret = "sbs_" + secret
+
+	return db.OauthClient{
+		ID:           util.RandomString(16),
+		Owner:        owner,
+		Name:         util.RandomOwner(),
+		SecretHash:   util.HashSecretToken(secret),
+		RedirectUris: []string{"https://app.example.com/callback"},
+		Scopes:       []string{token.ScopeAccountsRead, token.ScopeTransfersRead},
+		CreatedAt:    time.Now(),
+	}, secret
+}
+
+func addClientAuthorization(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request, username, clientID string, scopes ...string) {
+	accessToken, _, err := tokenMaker.CreateToken(username, time.Minute, token.Options{
+		Audience: token.AudienceHTTP,
+		ClientID: clientID,
+		Scopes:   scopes,
+	})
+	require.NoError(t, err)
+	req.Header.Set("authorization", fmt.Sprintf("%s %s", authTypeBearer, accessToken))
+}
+
+func TestRegisterOAuthClient(t *testing.T) {
+	user, _ := randomUser()
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		name          string
+		body          string
+	}{
+		{
+			name: "OK",
+			body: `{"name": "budget app", "redirect_uris": ["https://app.example.com/callback"], "scopes": ["accounts:read"]}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateOAuthClient(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateOAuthClientParams) (db.OauthClient, error) {
+						require.Equal(t, user.Username, arg.Owner)
+						require.Equal(t, "budget app", arg.Name)
+						return db.OauthClient{
+							ID:           arg.ID,
+							Owner:        arg.Owner,
+							Name:         arg.Name,
+							SecretHash:   arg.SecretHash,
+							RedirectUris: arg.RedirectUris,
+							Scopes:       arg.Scopes,
+							CreatedAt:    time.Now(),
+						}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, w.Code)
+
+				var res registerOAuthClientRes
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				require.True(t, strings.HasPrefix(res.ClientSecret, "sbs_"))
+				require.NotEmpty(t, res.ClientID)
+				require.Equal(t, []string{token.ScopeAccountsRead}, res.Scopes)
+				require.NotContains(t, w.Body.String(), util.HashSecretToken(res.ClientSecret))
+			},
+		},
+		{
+			name: "PlainHTTPRedirectURI",
+			body: `{"name": "budget app", "redirect_uris": ["http://app.example.com/callback"], "scopes": ["accounts:read"]}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateOAuthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+				requireErrorCode(t, w.Body, CodeInvalidArgument)
+			},
+		},
+		{
+			name: "UnknownScope",
+			body: `{"name": "budget app", "redirect_uris": ["https://app.example.com/callback"], "scopes": ["users:write"]}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateOAuthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+
+			server := newTestServer(t, store)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/oauth/clients", bytes.NewBufferString(tc.body))
+			addAuthorization(t, server.tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, w)
+		})
+	}
+}
+
+func TestListOAuthClients(t *testing.T) {
+	user, _ := randomUser()
+	client, _ := randomOAuthClient(t, user.Username)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListOAuthClients(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return([]db.OauthClient{client}, nil)
+	stubAuthUsers(store)
+
+	server := newTestServer(t, store)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/oauth/clients", nil)
+	addAuthorization(t, server.tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+
+	server.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var res []oauthClientRes
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Len(t, res, 1)
+	require.Equal(t, client.ID, res[0].ClientID)
+	require.NotContains(t, w.Body.String(), client.SecretHash)
+}
+
+func TestGetAuthorize(t *testing.T) {
+	user, _ := randomUser()
+	client, _ := randomOAuthClient(t, util.RandomOwner())
+
+	query := func(clientID, scope string) string {
+		return url.Values{
+			"response_type":         {"code"},
+			"client_id":             {clientID},
+			"scope":                 {scope},
+			"state":                 {"xyz"},
+			"code_challenge":        {oauth.CodeChallenge(testCodeVerifier)},
+			"code_challenge_method": {"S256"},
+		}.Encode()
+	}
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		name          string
+		query         string
+	}{
+		{
+			name:  "OK",
+			query: query(client.ID, token.ScopeAccountsRead),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ID)).Times(1).Return(client, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+
+				var res consentRes
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				require.Equal(t, client.Name, res.ClientName)
+				require.Equal(t, client.RedirectUris[0], res.RedirectURI)
+				require.Equal(t, "xyz", res.State)
+				require.Equal(t, []string{token.ScopeAccountsRead}, res.Scopes)
+			},
+		},
+		{
+			name:  "UnknownClient",
+			query: query("unknown", ""),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(1).Return(db.OauthClient{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+				requireErrorCode(t, w.Body, CodeInvalidArgument)
+			},
+		},
+		{
+			name:  "MissingChallenge",
+			query: "response_type=code&client_id=" + client.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+
+			server := newTestServer(t, store)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+tc.query, nil)
+			addAuthorization(t, server.tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, w)
+		})
+	}
+}
+
+func TestPostAuthorize(t *testing.T) {
+	user, _ := randomUser()
+	client, _ := randomOAuthClient(t, util.RandomOwner())
+	account := randomAccount(user.Username)
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		name          string
+		body          gin.H
+	}{
+		{
+			name: "Approved",
+			body: gin.H{"approved": true},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ID)).Times(1).Return(client, nil)
+				store.EXPECT().CreateConsent(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					CreateOAuthAuthorizationCode(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateOAuthAuthorizationCodeParams) (db.OauthAuthorizationCode, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, client.ID, arg.ClientID)
+						return db.OauthAuthorizationCode{}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				redirectTo := requireRedirectTo(t, w)
+				require.NotEmpty(t, redirectTo.Query().Get("code"))
+				require.Equal(t, "xyz", redirectTo.Query().Get("state"))
+			},
+		},
+		{
+			name: "ApprovedWithConsent",
+			body: gin.H{
+				"approved":     true,
+				"account_ids":  []int64{account.ID},
+				"permissions":  []string{oauth.PermissionBalances},
+				"consent_days": 30,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ID)).Times(1).Return(client, nil)
+				store.EXPECT().
+					CreateConsent(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateConsentParams) (db.Consent, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, client.ID, arg.ClientID)
+						require.Equal(t, []int64{account.ID}, arg.AccountIds)
+						require.Equal(t, []string{oauth.PermissionBalances}, arg.Permissions)
+						require.WithinDuration(t, time.Now().AddDate(0, 0, 30), arg.ExpiresAt, time.Second)
+						return db.Consent{}, nil
+					})
+				store.EXPECT().CreateOAuthAuthorizationCode(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				redirectTo := requireRedirectTo(t, w)
+				require.NotEmpty(t, redirectTo.Query().Get("code"))
+			},
+		},
+		{
+			name: "ConsentToAccountNotOwned",
+			body: gin.H{
+				"approved":     true,
+				"account_ids":  []int64{account.ID},
+				"permissions":  []string{oauth.PermissionBalances},
+				"consent_days": 30,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				other := account
+				other.Owner = util.RandomOwner()
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(other, nil)
+				store.EXPECT().CreateConsent(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateOAuthAuthorizationCode(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+				requireErrorCode(t, w.Body, CodeAccountNotOwned)
+			},
+		},
+		{
+			name: "ConsentWithoutPermissions",
+			body: gin.H{
+				"approved":    true,
+				"account_ids": []int64{account.ID},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateConsent(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name: "Denied",
+			body: gin.H{"approved": false},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ID)).Times(1).Return(client, nil)
+				store.EXPECT().CreateOAuthAuthorizationCode(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				redirectTo := requireRedirectTo(t, w)
+				require.Empty(t, redirectTo.Query().Get("code"))
+				require.Equal(t, oauth.ErrCodeAccessDenied, redirectTo.Query().Get("error"))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+
+			params := gin.H{
+				"response_type":         "code",
+				"client_id":             client.ID,
+				"state":                 "xyz",
+				"code_challenge":        oauth.CodeChallenge(testCodeVerifier),
+				"code_challenge_method": "S256",
+			}
+			for key, value := range tc.body {
+				params[key] = value
+			}
+			body, err := json.Marshal(params)
+			require.NoError(t, err)
+
+			server := newTestServer(t, store)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/oauth/authorize", bytes.NewReader(body))
+			addAuthorization(t, server.tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, w)
+		})
+	}
+}
+
+func requireRedirectTo(t *testing.T, w *httptest.ResponseRecorder) *url.URL {
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var res postAuthorizeRes
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	redirectTo, err := url.Parse(res.RedirectTo)
+	require.NoError(t, err)
+	require.Equal(t, "app.example.com", redirectTo.Host)
+	return redirectTo
+}
+
+func TestOAuthToken(t *testing.T) {
+	owner := util.RandomOwner()
+	client, secret := randomOAuthClient(t, owner)
+	code := util.RandomString(32)
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore)
+		setupRequest  func(req *http.Request)
+		checkResponse func(t *testing.T, server *Server, w *httptest.ResponseRecorder)
+		name          string
+		form          url.Values
+	}{
+		{
+			name: "AuthorizationCode",
+			form: url.Values{
+				"grant_type":    {oauth.GrantTypeAuthorizationCode},
+				"client_id":     {client.ID},
+				"client_secret": {secret},
+				"code":          {code},
+				"redirect_uri":  {client.RedirectUris[0]},
+				"code_verifier": {testCodeVerifier},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ID)).Times(1).Return(client, nil)
+				store.EXPECT().
+					UseOAuthAuthorizationCode(gomock.Any(), gomock.Eq(util.HashSecretToken(code))).
+					Times(1).
+					Return(db.OauthAuthorizationCode{
+						ClientID:      client.ID,
+						Username:      "alice",
+						RedirectUri:   client.RedirectUris[0],
+						Scopes:        []string{token.ScopeAccountsRead},
+						CodeChallenge: oauth.CodeChallenge(testCodeVerifier),
+					}, nil)
+			},
+			setupRequest: func(req *http.Request) {},
+			checkResponse: func(t *testing.T, server *Server, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+				require.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+				var res oauth.TokenResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				require.Equal(t, "Bearer", res.TokenType)
+				require.Equal(t, token.ScopeAccountsRead, res.Scope)
+
+				payload, err := server.tokenMaker.VerifyToken(res.AccessToken)
+				require.NoError(t, err)
+				require.Equal(t, "alice", payload.Username)
+				require.Equal(t, client.ID, payload.ClientID)
+				require.True(t, payload.IsThirdParty())
+			},
+		},
+		{
+			name: "ClientCredentialsBasicAuth",
+			form: url.Values{
+				"grant_type": {oauth.GrantTypeClientCredentials},
+				"scope":      {token.ScopeTransfersRead},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ID)).Times(1).Return(client, nil)
+			},
+			setupRequest: func(req *http.Request) {
+				req.SetBasicAuth(client.ID, secret)
+			},
+			checkResponse: func(t *testing.T, server *Server, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+
+				var res oauth.TokenResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				payload, err := server.tokenMaker.VerifyToken(res.AccessToken)
+				require.NoError(t, err)
+				require.Equal(t, owner, payload.Username)
+				require.Equal(t, []string{token.ScopeTransfersRead}, payload.Scopes)
+			},
+		},
+		{
+			name: "InvalidClient",
+			form: url.Values{"grant_type": {oauth.GrantTypeClientCredentials}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(1).Return(client, nil)
+			},
+			setupRequest: func(req *http.Request) {
+				req.SetBasicAuth(client.ID, "sbs_wrong")
+			},
+			checkResponse: func(t *testing.T, server *Server, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+
+				var res oauthErrorRes
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				require.Equal(t, oauth.ErrCodeInvalidClient, res.Error)
+			},
+		},
+		{
+			name: "UnsupportedGrantType",
+			form: url.Values{"grant_type": {"password"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			setupRequest: func(req *http.Request) {},
+			checkResponse: func(t *testing.T, server *Server, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+
+				var res oauthErrorRes
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				require.Equal(t, oauth.ErrCodeUnsupportedGrantType, res.Error)
+			},
+		},
+		{
+			name: "MissingGrantType",
+			form: url.Values{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			setupRequest: func(req *http.Request) {},
+			checkResponse: func(t *testing.T, server *Server, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+
+				var res oauthErrorRes
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				require.Equal(t, oauth.ErrCodeInvalidRequest, res.Error)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(tc.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			tc.setupRequest(req)
+
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, server, w)
+		})
+	}
+}
+
+func TestThirdPartyToken(t *testing.T) {
+	user, _ := randomUser()
+
+	testCases := []struct {
+		name   string
+		method string
+		url    string
+		body   string
+		scopes []string
+		code   string
+	}{
+		{
+			name:   "MissingScope",
+			method: http.MethodPost,
+			url:    "/accounts",
+			body:   `{"currency": "USD"}`,
+			scopes: []string{token.ScopeAccountsRead},
+			code:   CodeInsufficientScope,
+		},
+		{
+			name:   "LoginOnly",
+			method: http.MethodGet,
+			url:    "/oauth/clients",
+			scopes: token.Scopes,
+			code:   CodePermissionDenied,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			stubAuthUsers(store)
+
+			server := newTestServer(t, store)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, tc.url, bytes.NewBufferString(tc.body))
+			addClientAuthorization(t, server.tokenMaker, req, user.Username, util.RandomString(16), tc.scopes...)
+
+			server.router.ServeHTTP(w, req)
+			require.Equal(t, http.StatusForbidden, w.Code)
+			requireErrorCode(t, w.Body, tc.code)
+		})
+	}
+}
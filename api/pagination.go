@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor is the decoded form of the opaque cursor handed out to clients.
+// ID is the sort key of the row the page starts after (or before, when Backward is set)
+type pageCursor struct {
+	ID       int64 `json:"id"`
+	Backward bool  `json:"backward,omitempty"`
+}
+
+func encodeCursor(cursor pageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	var cursor pageCursor
+	if s == "" {
+		return cursor, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor, errInvalidCursor
+	}
+
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID < 1 {
+		return pageCursor{}, errInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+// pageReq holds the query parameters shared by every list endpoint
+type pageReq struct {
+	Cursor   string `form:"cursor"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// listRes is the response envelope shared by every list endpoint
+type listRes[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// newListRes builds the envelope for one page of rows. rows must be fetched with
+// a limit of pageSize+1 in the direction of the cursor, the extra row only tells
+// whether there is anything beyond this page.
+func newListRes[T any](rows []T, cursor pageCursor, pageSize int32, idOf func(T) int64) listRes[T] {
+	hasMore := len(rows) > int(pageSize)
+	if hasMore {
+		rows = rows[:pageSize]
+	}
+
+	if cursor.Backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	res := listRes[T]{Data: rows}
+	if len(rows) == 0 {
+		return res
+	}
+
+	first, last := idOf(rows[0]), idOf(rows[len(rows)-1])
+	if cursor.Backward {
+		// we came from a later page, so there is always a next one
+		res.NextCursor = encodeCursor(pageCursor{ID: last})
+		if hasMore {
+			res.PrevCursor = encodeCursor(pageCursor{ID: first, Backward: true})
+		}
+		return res
+	}
+
+	if hasMore {
+		res.NextCursor = encodeCursor(pageCursor{ID: last})
+	}
+	if cursor.ID > 0 {
+		res.PrevCursor = encodeCursor(pageCursor{ID: first, Backward: true})
+	}
+	return res
+}
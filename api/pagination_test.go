@@ -0,0 +1,25 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	cursor := pageCursor{ID: 42, Backward: true}
+
+	decoded, err := decodeCursor(encodeCursor(cursor))
+	require.NoError(t, err)
+	require.Equal(t, cursor, decoded)
+
+	decoded, err = decodeCursor("")
+	require.NoError(t, err)
+	require.Zero(t, decoded)
+
+	_, err = decodeCursor("%%%")
+	require.ErrorIs(t, err, errInvalidCursor)
+
+	_, err = decodeCursor(encodeCursor(pageCursor{ID: -1}))
+	require.ErrorIs(t, err, errInvalidCursor)
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
+	"simplebank/mail"
+	"simplebank/token"
+	"simplebank/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type changePasswordReq struct {
+	CurrentPassword string `json:"current_password" binding:"required,min=6"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+// changePassword sets a new password once the user proved they know the
+// current one. Every other token of the user stops working, the response
+// carries a fresh one for the caller.
+func (server *Server) changePassword(c *gin.Context) {
+	var req changePasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := server.config.PasswordPolicy().Validate(req.NewPassword); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user, valid := server.authUser(c)
+	if !valid || !server.notLocked(c, user) {
+		return
+	}
+
+	err := util.CheckPassword(req.CurrentPassword, user.HashedPassword)
+	if err != nil {
+		if err := server.recordFailedLogin(c, user); err != nil {
+			abortWithError(c, http.StatusInternalServerError, err)
+			return
+		}
+		abortWithError(c, http.StatusUnauthorized, newError(CodeInvalidCredentials, "current password is incorrect"))
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(req.NewPassword)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	user, err = server.store.UpdateUserPassword(c, db.UpdateUserPasswordParams{
+		Username:          user.Username,
+		HashedPassword:    hashedPassword,
+		PasswordChangedAt: time.Now(),
+	})
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	accessToken, err := server.createSessionToken(c, user.Username, token.Options{})
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, loginUserRes{
+		AccessToken: accessToken,
+		User:        newUserRes(user),
+	})
+}
+
+type forgotPasswordReq struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// forgotPassword emails a link with a single-use reset token to the user of
+// the address. The answer is the same whether the address is known or not,
+// it can't be used to find out who banks with us.
+func (server *Server) forgotPassword(c *gin.Context) {
+	var req forgotPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user, err := server.store.GetUserByEmail(c, req.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.Status(http.StatusAccepted)
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	resetToken, tokenHash, err := util.NewSecretToken()
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	_, err = server.store.CreatePasswordResetToken(c, db.CreatePasswordResetTokenParams{
+		TokenHash: tokenHash,
+		Username:  user.Username,
+		ExpiresAt: time.Now().Add(server.config.PasswordResetDuration),
+	})
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	// the user can ask again, a failing mailer must not tell the caller the
+	// address exists
+	err = server.mailer.Send(c, mail.Email{
+		To:      user.Email,
+		Subject: "Reset your SimpleBank password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nopen the link below within %s to choose a new password:\n\n%s/reset-password?token=%s\n\nIf you did not ask for it, ignore this email, your password stays the same.\n",
+			user.FullName, server.config.PasswordResetDuration, server.config.AppURL, url.QueryEscape(resetToken),
+		),
+	})
+	if err != nil {
+		logging.FromContext(c).Error("can not send password reset email", "user", user.Username, "error", err)
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+type resetPasswordReq struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// resetPassword sets the password of the user a reset token was emailed to.
+// It also lifts a lockout, the user proved they own the email address.
+func (server *Server) resetPassword(c *gin.Context) {
+	var req resetPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := server.config.PasswordPolicy().Validate(req.NewPassword); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(req.NewPassword)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	user, err := server.store.ResetPasswordTx(c, db.ResetPasswordTxParams{
+		TokenHash:         util.HashSecretToken(req.Token),
+		HashedPassword:    hashedPassword,
+		PasswordChangedAt: time.Now(),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			abortWithError(c, http.StatusBadRequest, newError(CodeInvalidArgument, "reset token is invalid or expired"))
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newUserRes(user))
+}
+
+// rehashPassword stores a new hash of the password user just logged in with
+// when the stored one is bcrypt or argon2id of older parameters. It never
+// fails the login, the next one tries again.
+func (server *Server) rehashPassword(c *gin.Context, user db.User, password string) {
+	if !util.NeedsRehash(user.HashedPassword) {
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(password)
+	if err == nil {
+		err = server.store.RehashUserPassword(c, db.RehashUserPasswordParams{
+			Username: user.Username,
+			OldHash:  user.HashedPassword,
+			NewHash:  hashedPassword,
+		})
+	}
+	if err != nil {
+		logging.FromContext(c).Error("can not rehash password", "user", user.Username, "error", err)
+	}
+}
@@ -0,0 +1,464 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/mail"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// newPasswordMatcher matches the params of UpdateUserPasswordParams and
+// ResetPasswordTxParams whose hash is of password and set just now
+type newPasswordMatcher struct {
+	password string
+	key      string
+}
+
+func (e newPasswordMatcher) Matches(x interface{}) bool {
+	var key, hashedPassword string
+	var changedAt time.Time
+	switch arg := x.(type) {
+	case db.UpdateUserPasswordParams:
+		key, hashedPassword, changedAt = arg.Username, arg.HashedPassword, arg.PasswordChangedAt
+	case db.ResetPasswordTxParams:
+		key, hashedPassword, changedAt = arg.TokenHash, arg.HashedPassword, arg.PasswordChangedAt
+	default:
+		return false
+	}
+
+	return key == e.key &&
+		util.CheckPassword(e.password, hashedPassword) == nil &&
+		time.Since(changedAt) < time.Minute
+}
+
+func (e newPasswordMatcher) String() string {
+	return fmt.Sprintf("matches key %v and password %v", e.key, e.password)
+}
+
+type recordingMailer struct {
+	emails []mail.Email
+	err    error
+}
+
+func (m *recordingMailer) Send(ctx context.Context, email mail.Email) error {
+	m.emails = append(m.emails, email)
+	return m.err
+}
+
+func TestChangePassword(t *testing.T) {
+	user, password := randomUser()
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+	user.HashedPassword = hashedPassword
+	newPassword := util.RandomString(8)
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, server *Server, w *httptest.ResponseRecorder)
+		input         changePasswordReq
+		name          string
+	}{
+		{
+			name:  "OK",
+			input: changePasswordReq{CurrentPassword: password, NewPassword: newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserPassword(gomock.Any(), newPasswordMatcher{password: newPassword, key: user.Username}).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateUserPasswordParams) (db.User, error) {
+						changed := user
+						changed.HashedPassword = arg.HashedPassword
+						changed.PasswordChangedAt = arg.PasswordChangedAt
+						return changed, nil
+					})
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, nil)
+			},
+			checkResponse: func(t *testing.T, server *Server, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+
+				var res loginUserRes
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				require.Equal(t, user.Username, res.User.Username)
+
+				payload, err := server.tokenMaker.VerifyToken(res.AccessToken)
+				require.NoError(t, err)
+				require.False(t, payload.IssuedAt.Before(res.User.PasswordChangedAt))
+				require.True(t, payload.Session)
+			},
+		},
+		{
+			name:  "WrongCurrentPassword",
+			input: changePasswordReq{CurrentPassword: "wrong-password", NewPassword: newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					RecordFailedLogin(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserPassword(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+				requireErrorCode(t, w.Body, CodeInvalidCredentials)
+			},
+		},
+		{
+			name:  "WeakNewPassword",
+			input: changePasswordReq{CurrentPassword: password, NewPassword: "iloveyou"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserPassword(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+				requireErrorCode(t, w.Body, CodeWeakPassword)
+			},
+		},
+		{
+			name:  "Locked",
+			input: changePasswordReq{CurrentPassword: password, NewPassword: newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				locked := user
+				locked.LockedUntil = time.Now().Add(time.Minute)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(locked, nil)
+				store.EXPECT().
+					UpdateUserPassword(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusLocked, w.Code)
+				requireErrorCode(t, w.Body, CodeUserLocked)
+			},
+		},
+		{
+			name:  "InvalidNewPassword",
+			input: changePasswordReq{CurrentPassword: password, NewPassword: "short"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserPassword(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+				requireErrorCode(t, w.Body, CodeInvalidArgument)
+			},
+		},
+		{
+			name:  "InternalError",
+			input: changePasswordReq{CurrentPassword: password, NewPassword: newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserPassword(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, server *Server, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			body, err := json.Marshal(tc.input)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/users/me/password", bytes.NewReader(body))
+			addAuthorization(t, server.tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, server, w)
+		})
+	}
+}
+
+func TestForgotPassword(t *testing.T) {
+	user, _ := randomUser()
+	tokenPattern := regexp.MustCompile(`/reset-password\?token=(\S+)`)
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder, mailer *recordingMailer)
+		mailErr       error
+		name          string
+		email         string
+	}{
+		{
+			name:  "OK",
+			email: user.Email,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreatePasswordResetToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreatePasswordResetTokenParams) (db.PasswordResetToken, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.WithinDuration(t, time.Now().Add(time.Minute), arg.ExpiresAt, time.Second)
+						return db.PasswordResetToken{TokenHash: arg.TokenHash}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder, mailer *recordingMailer) {
+				require.Equal(t, http.StatusAccepted, w.Code)
+				require.Len(t, mailer.emails, 1)
+				require.Equal(t, user.Email, mailer.emails[0].To)
+
+				match := tokenPattern.FindStringSubmatch(mailer.emails[0].Body)
+				require.Len(t, match, 2)
+				resetToken, err := url.QueryUnescape(match[1])
+				require.NoError(t, err)
+				require.Len(t, resetToken, 43)
+			},
+		},
+		{
+			name:  "UnknownEmail",
+			email: user.Email,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreatePasswordResetToken(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder, mailer *recordingMailer) {
+				require.Equal(t, http.StatusAccepted, w.Code)
+				require.Empty(t, mailer.emails)
+			},
+		},
+		{
+			name:    "MailerError",
+			email:   user.Email,
+			mailErr: errors.New("smtp is down"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreatePasswordResetToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PasswordResetToken{}, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder, mailer *recordingMailer) {
+				require.Equal(t, http.StatusAccepted, w.Code)
+				require.Len(t, mailer.emails, 1)
+			},
+		},
+		{
+			name:  "InvalidEmail",
+			email: "not-an-email",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder, mailer *recordingMailer) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+				requireErrorCode(t, w.Body, CodeInvalidArgument)
+			},
+		},
+		{
+			name:  "InternalError",
+			email: user.Email,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder, mailer *recordingMailer) {
+				require.Equal(t, http.StatusInternalServerError, w.Code)
+				require.Empty(t, mailer.emails)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			mailer := &recordingMailer{err: tc.mailErr}
+			server.mailer = mailer
+
+			body, err := json.Marshal(forgotPasswordReq{Email: tc.email})
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/users/password/forgot", bytes.NewReader(body))
+
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, w, mailer)
+		})
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	user, _ := randomUser()
+	resetToken, tokenHash, err := util.NewSecretToken()
+	require.NoError(t, err)
+	newPassword := util.RandomString(8)
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		input         resetPasswordReq
+		name          string
+	}{
+		{
+			name:  "OK",
+			input: resetPasswordReq{Token: resetToken, NewPassword: newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), newPasswordMatcher{password: newPassword, key: tokenHash}).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+
+				var res userRes
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				require.Equal(t, user.Username, res.Username)
+			},
+		},
+		{
+			name:  "InvalidOrExpiredToken",
+			input: resetPasswordReq{Token: resetToken, NewPassword: newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+				body := requireErrorCode(t, w.Body, CodeInvalidArgument)
+				require.Equal(t, "reset token is invalid or expired", body.Message)
+			},
+		},
+		{
+			name:  "InvalidNewPassword",
+			input: resetPasswordReq{Token: resetToken, NewPassword: "short"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name:  "WeakNewPassword",
+			input: resetPasswordReq{Token: resetToken, NewPassword: "qwerty123"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+				requireErrorCode(t, w.Body, CodeWeakPassword)
+			},
+		},
+		{
+			name:  "InternalError",
+			input: resetPasswordReq{Token: resetToken, NewPassword: newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			body, err := json.Marshal(tc.input)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/users/password/reset", bytes.NewReader(body))
+
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, w)
+		})
+	}
+}
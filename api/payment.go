@@ -0,0 +1,191 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/payment"
+	"simplebank/token"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// maxPaymentBatchBodySize bounds the whole multipart body of a payment batch,
+// the file plus its part headers and the totp_code field
+const maxPaymentBatchBodySize = payment.MaxFileSize + 64<<10
+
+// createPaymentBatch executes the credit transfers of an uploaded pain.001 file
+// and answers with a pain.002 status report
+func (server *Server) createPaymentBatch(c *gin.Context) {
+	// stop reading a body that can't hold an acceptable file before it's
+	// parsed and spilled to temporary files
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxPaymentBatchBodySize)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			err := newError(CodePayloadTooLarge, "file is larger than %d bytes", payment.MaxFileSize)
+			abortWithError(c, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if fileHeader.Size > payment.MaxFileSize {
+		err := newError(CodePayloadTooLarge, "file is larger than %d bytes", payment.MaxFileSize)
+		abortWithError(c, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+	defer file.Close()
+
+	initiation, err := payment.ParsePain001(file)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if !server.allowTransfers(c, initiation.TransferCount()) {
+		return
+	}
+
+	// the whole file counts towards the threshold, per currency, splitting a
+	// large amount into small lines doesn't avoid the code
+	var largestTotal int64
+	for _, total := range initiation.TotalAmounts() {
+		largestTotal = max(largestTotal, total)
+	}
+	if !server.requireFreshTOTP(c, largestTotal, c.PostForm("totp_code")) {
+		return
+	}
+
+	authPayload := c.MustGet(authPayloadKey).(*token.Payload)
+	report := payment.Report{
+		CreatedAt: time.Now(),
+		Original:  initiation,
+	}
+
+	batch, err := server.store.CreatePaymentBatch(c, db.CreatePaymentBatchParams{
+		Owner:     authPayload.Username,
+		MessageID: initiation.MessageID,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			report.MessageID = fmt.Sprintf("STS-DUP-%d", report.CreatedAt.Unix())
+			report.Reason = &payment.StatusReason{
+				Code: payment.ReasonDuplicateMessage,
+				Info: fmt.Sprintf("message %s was already submitted", initiation.MessageID),
+			}
+			server.writePain002(c, http.StatusConflict, report)
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	report.MessageID = fmt.Sprintf("STS-%d", batch.ID)
+	for _, info := range initiation.PaymentInfos {
+		for _, transfer := range info.Transfers {
+			status := payment.TransferStatus{
+				PaymentInfoID: info.ID,
+				Transfer:      transfer,
+			}
+			status.TransferID, status.Reason = server.executeCreditTransfer(c, authPayload.Username, info.DebtorAccountID, transfer)
+			report.Statuses = append(report.Statuses, status)
+		}
+	}
+
+	server.writePain002(c, http.StatusOK, report)
+}
+
+// allowTransfers takes a token of the transfers limit for each of the n lines
+// of a batch, like as many calls of POST /transfers would. A batch needing more
+// tokens than the limit ever holds has to be split.
+func (server *Server) allowTransfers(c *gin.Context, n int) bool {
+	limit := server.rateLimits.Transfers
+	if limit.Enabled() && n > limit.Burst {
+		err := newError(CodePayloadTooLarge, "batch has %d transfers, the transfers limit allows %d at once", n, limit.Burst)
+		abortWithError(c, http.StatusRequestEntityTooLarge, err)
+		return false
+	}
+	return allowRequest(c, server.limiter, "transfers", limit, usernameKey, n)
+}
+
+// executeCreditTransfer runs one line of a pain.001 file, it returns the id of the
+// executed transfer or the reason the line was rejected
+func (server *Server) executeCreditTransfer(c *gin.Context, username string, debtorAccountID int64, transfer payment.CreditTransfer) (int64, *payment.StatusReason) {
+	if transfer.Err != nil {
+		return 0, transfer.Err
+	}
+
+	fromAccount, reason := server.paymentAccount(c, debtorAccountID, transfer.Currency)
+	if reason != nil {
+		return 0, reason
+	}
+
+	if fromAccount.Owner != username {
+		return 0, &payment.StatusReason{
+			Code: payment.ReasonForbidden,
+			Info: fmt.Sprintf("account %d doesn't belong to authenticated user", debtorAccountID),
+		}
+	}
+
+	if _, reason := server.paymentAccount(c, transfer.CreditorAccountID, transfer.Currency); reason != nil {
+		return 0, reason
+	}
+
+	result, err := server.store.TransferTx(c, db.TransferTxParams{
+		FromAccountID: debtorAccountID,
+		ToAccountID:   transfer.CreditorAccountID,
+		Amount:        transfer.Amount,
+	})
+	if err != nil {
+		return 0, &payment.StatusReason{Code: payment.ReasonNotSpecified, Info: "transfer could not be executed"}
+	}
+
+	return result.Transfer.ID, nil
+}
+
+// paymentAccount is the status report counterpart of validAccount
+func (server *Server) paymentAccount(c *gin.Context, accountID int64, currency string) (db.Account, *payment.StatusReason) {
+	account, err := server.store.GetAccount(c, accountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return account, &payment.StatusReason{
+				Code: payment.ReasonIncorrectAccount,
+				Info: fmt.Sprintf("account %d does not exist", accountID),
+			}
+		}
+		return account, &payment.StatusReason{Code: payment.ReasonNotSpecified, Info: "account could not be loaded"}
+	}
+
+	if account.Currency != currency {
+		return account, &payment.StatusReason{
+			Code: payment.ReasonNotAllowedCurrency,
+			Info: fmt.Sprintf("account %d currency mismatch: %s vs %s", accountID, account.Currency, currency),
+		}
+	}
+
+	return account, nil
+}
+
+func (server *Server) writePain002(c *gin.Context, status int, report payment.Report) {
+	var buf bytes.Buffer
+	if err := payment.WritePain002(&buf, report); err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Data(status, "application/xml", buf.Bytes())
+}
@@ -0,0 +1,446 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/payment"
+	"simplebank/ratelimit"
+	"simplebank/token"
+	"simplebank/util"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+// pain001Template is a minimal pain.001.001.03 file with one credit transfer,
+// filled with the message id, debtor, creditor, amount and currency
+const pain001Template = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03">
+  <CstmrCdtTrfInitn>
+    <GrpHdr>
+      <MsgId>%s</MsgId>
+      <CreDtTm>2026-09-30T08:00:00</CreDtTm>
+      <NbOfTxs>1</NbOfTxs>
+    </GrpHdr>
+    <PmtInf>
+      <PmtInfId>PMT-1</PmtInfId>
+      <PmtMtd>TRF</PmtMtd>
+      <DbtrAcct><Id><Othr><Id>%d</Id></Othr></Id></DbtrAcct>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-1</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="%s">%s</InstdAmt></Amt>
+        <CdtrAcct><Id><Othr><Id>%d</Id></Othr></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+  </CstmrCdtTrfInitn>
+</Document>
+`
+
+func TestCreatePaymentBatch(t *testing.T) {
+	user1, _ := randomUser()
+	user2, _ := randomUser()
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+	if account2.ID == account1.ID {
+		account2.ID++
+	}
+
+	messageID := util.RandomString(12)
+	amount := int64(1050)
+	file := fmt.Sprintf(pain001Template, messageID, account1.ID, util.USD, util.FormatAmount(amount), account2.ID)
+
+	testCases := []struct {
+		setupAuth     func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		name          string
+		file          string
+	}{
+		{
+			name: "OK",
+			file: file,
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreatePaymentBatch(gomock.Any(), gomock.Eq(db.CreatePaymentBatchParams{
+						Owner:     user1.Username,
+						MessageID: messageID,
+					})).
+					Times(1).
+					Return(db.PaymentBatch{ID: 7, Owner: user1.Username, MessageID: messageID}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Eq(db.TransferTxParams{
+						FromAccountID: account1.ID,
+						ToAccountID:   account2.ID,
+						Amount:        amount,
+					})).
+					Times(1).
+					Return(db.TransferTxResult{Transfer: db.Transfer{ID: 42}}, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+				require.Equal(t, "application/xml", w.Header().Get("Content-Type"))
+				body := w.Body.String()
+				require.Contains(t, body, "<MsgId>STS-7</MsgId>")
+				require.Contains(t, body, "<GrpSts>ACSC</GrpSts>")
+				require.Contains(t, body, "<StsId>42</StsId>")
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			file: fmt.Sprintf(pain001Template, messageID, account1.ID, util.EUR, "10.50", account2.ID),
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreatePaymentBatch(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PaymentBatch{ID: 7}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+				body := w.Body.String()
+				require.Contains(t, body, "<GrpSts>RJCT</GrpSts>")
+				require.Contains(t, body, "<Cd>AM03</Cd>")
+			},
+		},
+		{
+			name: "DebtorAccountNotOwned",
+			file: file,
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, user2.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreatePaymentBatch(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PaymentBatch{ID: 7}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+				require.Contains(t, w.Body.String(), "<Cd>AG01</Cd>")
+			},
+		},
+		{
+			name: "CreditorAccountNotFound",
+			file: file,
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreatePaymentBatch(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PaymentBatch{ID: 7}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+				require.Contains(t, w.Body.String(), "<Cd>AC01</Cd>")
+			},
+		},
+		{
+			name: "DuplicateMessage",
+			file: file,
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreatePaymentBatch(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PaymentBatch{}, &pq.Error{Code: "23505"})
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, w.Code)
+				body := w.Body.String()
+				require.Contains(t, body, "<GrpSts>RJCT</GrpSts>")
+				require.Contains(t, body, "<Cd>DU01</Cd>")
+			},
+		},
+		{
+			name: "InvalidFile",
+			file: strings.Replace(file, "<NbOfTxs>1</NbOfTxs>", "<NbOfTxs>2</NbOfTxs>", 1),
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreatePaymentBatch(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name: "FileTooLarge",
+			file: strings.Repeat(" ", payment.MaxFileSize+1),
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreatePaymentBatch(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+				requireErrorCode(t, w.Body, CodePayloadTooLarge)
+			},
+		},
+		{
+			name: "BodyTooLarge",
+			file: strings.Repeat(" ", 4*maxPaymentBatchBodySize),
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreatePaymentBatch(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+				requireErrorCode(t, w.Body, CodePayloadTooLarge)
+			},
+		},
+		{
+			name: "InternalServerError",
+			file: file,
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, user1.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreatePaymentBatch(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PaymentBatch{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			file: file,
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreatePaymentBatch(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			part, err := form.CreateFormFile("file", "pain001.xml")
+			require.NoError(t, err)
+			_, err = part.Write([]byte(tc.file))
+			require.NoError(t, err)
+			require.NoError(t, form.Close())
+
+			req, err := http.NewRequest(http.MethodPost, "/transfers/batches", &body)
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", form.FormDataContentType())
+
+			server := newTestServer(t, store)
+			w := httptest.NewRecorder()
+			tc.setupAuth(t, server.tokenMaker, req)
+			server.router.ServeHTTP(w, req)
+
+			tc.checkResponse(t, w)
+		})
+	}
+}
+
+// newPain001File builds a pain.001.001.03 file of lines credit transfers of
+// 1.00 USD from debtor to creditor
+func newPain001File(messageID string, debtor, creditor int64, lines int) string {
+	var transfers strings.Builder
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&transfers, `
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-%d</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="USD">1.00</InstdAmt></Amt>
+        <CdtrAcct><Id><Othr><Id>%d</Id></Othr></Id></CdtrAcct>
+      </CdtTrfTxInf>`, i+1, creditor)
+	}
+
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03">
+  <CstmrCdtTrfInitn>
+    <GrpHdr>
+      <MsgId>%s</MsgId>
+      <CreDtTm>2026-09-30T08:00:00</CreDtTm>
+      <NbOfTxs>%d</NbOfTxs>
+    </GrpHdr>
+    <PmtInf>
+      <PmtInfId>PMT-1</PmtInfId>
+      <PmtMtd>TRF</PmtMtd>
+      <DbtrAcct><Id><Othr><Id>%d</Id></Othr></Id></DbtrAcct>%s
+    </PmtInf>
+  </CstmrCdtTrfInitn>
+</Document>
+`, messageID, lines, debtor, transfers.String())
+}
+
+func TestCreatePaymentBatchRateLimit(t *testing.T) {
+	user, _ := randomUser()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	// the debtor doesn't exist, the lines are rejected after taking their tokens
+	store.EXPECT().
+		CreatePaymentBatch(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.PaymentBatch{ID: 7, Owner: user.Username}, nil)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).AnyTimes().Return(db.Account{}, sql.ErrNoRows)
+	stubAuthUsers(store)
+
+	server := newTestServer(t, store)
+	server.rateLimits.Transfers = ratelimit.Limit{Rate: 1.0 / 3600, Burst: 2}
+
+	upload := func(file string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("file", "pain001.xml")
+		require.NoError(t, err)
+		_, err = part.Write([]byte(file))
+		require.NoError(t, err)
+		require.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/transfers/batches", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		addAuthorization(t, server.tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+
+	// more lines than the limit ever allows at once
+	w := upload(newPain001File(util.RandomString(12), 1, 2, 3))
+	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	requireErrorCode(t, w.Body, CodePayloadTooLarge)
+
+	// two lines take the two tokens of the bucket
+	w = upload(newPain001File(util.RandomString(12), 1, 2, 2))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+	// so even a single line is over the limit now
+	w = upload(newPain001File(util.RandomString(12), 1, 2, 1))
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	requireErrorCode(t, w.Body, CodeRateLimited)
+	require.NotEmpty(t, w.Header().Get("Retry-After"))
+}
+
+func TestCreatePaymentBatchTOTP(t *testing.T) {
+	user, _ := randomUser()
+	user.EmailVerified = true
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		name          string
+		required      bool
+	}{
+		{
+			// an API key can't send a fresh code, users without TOTP upload
+			// large batches with it all the same
+			name: "NotEnabled",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreatePaymentBatch(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PaymentBatch{ID: 7, Owner: user.Username}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).AnyTimes().Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name:     "NotEnabledRequired",
+			required: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreatePaymentBatch(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, w.Code)
+				requireErrorCode(t, w.Body, CodeTOTPNotEnabled)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			key, apiKey := randomAPIKey(t, user.Username, token.ScopeTransfersWrite)
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Eq(apiKey.KeyHash)).Times(1).Return(apiKey, nil)
+			store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Eq(apiKey.ID)).Times(1).Return(nil)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			// the two lines of 1.00 are over the threshold together
+			server.config.TOTPTransferThreshold = 150
+			server.config.TOTPTransferRequired = tc.required
+
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			part, err := form.CreateFormFile("file", "pain001.xml")
+			require.NoError(t, err)
+			_, err = part.Write([]byte(newPain001File(util.RandomString(12), 1, 2, 2)))
+			require.NoError(t, err)
+			require.NoError(t, form.Close())
+
+			req := httptest.NewRequest(http.MethodPost, "/transfers/batches", &body)
+			req.Header.Set("Content-Type", form.FormDataContentType())
+			req.Header.Set("authorization", fmt.Sprintf("%s %s", authTypeAPIKey, key))
+			w := httptest.NewRecorder()
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, w)
+		})
+	}
+}
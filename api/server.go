@@ -1,67 +1,198 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"simplebank/db/migration"
 	db "simplebank/db/sqlc"
+	"simplebank/mail"
+	"simplebank/oauth"
+	"simplebank/ratelimit"
 	"simplebank/token"
+	"simplebank/totp"
+	"simplebank/tracing"
 	"simplebank/util"
+	"simplebank/verification"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type Server struct {
 	store      db.Store
 	tokenMaker *token.PasetoMaker
 	router     *gin.Engine
+	httpServer *http.Server
 	config     util.Config
+	// schemaVersion is the latest embedded migration, readyz expects the database at it
+	schemaVersion int64
+	limiter       ratelimit.Limiter
+	rateLimits    ratelimit.Limits
+	totp          *totp.Verifier
+	mailer        mail.Sender
+	verifier      *verification.Verifier
+	oauth         *oauth.Provider
 }
 
-func NewServer(config util.Config, store db.Store) (*Server, error) {
+// NewServer creates the HTTP server. limiter is shared with the gRPC server, a
+// client gets the same limits over either API.
+func NewServer(config util.Config, store db.Store, limiter ratelimit.Limiter, limits ratelimit.Limits) (*Server, error) {
 	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
 	if err != nil {
 		return nil, fmt.Errorf("can not create token maker: %w", err)
 	}
 
+	schemaVersion, err := migration.LatestVersion(migration.FS)
+	if err != nil {
+		return nil, fmt.Errorf("can not read schema version: %w", err)
+	}
+
+	totpVerifier, err := totp.NewVerifier(store, config.TOTPEncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("can not create totp verifier: %w", err)
+	}
+
+	mailer, err := mail.NewSender(mail.Config{
+		Sender:       config.MailSender,
+		From:         config.MailFrom,
+		FileDir:      config.MailFileDir,
+		SMTPHost:     config.SMTPHost,
+		SMTPPort:     config.SMTPPort,
+		SMTPUsername: config.SMTPUsername,
+		SMTPPassword: config.SMTPPassword,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can not create mail sender: %w", err)
+	}
+
 	server := &Server{
-		config:     config,
-		tokenMaker: tokenMaker,
-		store:      store,
+		config:        config,
+		tokenMaker:    tokenMaker,
+		store:         store,
+		schemaVersion: schemaVersion,
+		limiter:       limiter,
+		rateLimits:    limits,
+		totp:          totpVerifier,
+		mailer:        mailer,
+		verifier:      verification.NewVerifier(store, mailer, config.AppURL, config.EmailVerifyDuration),
+		oauth:         oauth.NewProvider(store, tokenMaker, config.AccessTokenDuration),
 	}
 
 	server.setupRouter()
+	// without trusted proxies ClientIP is the peer address, X-Forwarded-For
+	// could be set by anyone to dodge the per IP limits
+	if err := server.router.SetTrustedProxies(config.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	server.httpServer = &http.Server{Handler: server.router}
 	return server, nil
 }
 
 func (server *Server) setupRouter() {
-	router := gin.Default()
+	router := gin.New()
+	// handlers pass the gin context to the store, let it reach the logger
+	// stored in the request context
+	router.ContextWithFallback = true
+	router.Use(
+		otelgin.Middleware(tracing.ServiceName),
+		requestIDMiddleware(),
+		loggerMiddleware(slog.Default()),
+		metricsMiddleware(),
+		gin.Recovery(),
+	)
 
 	// binding.Validator.Engine() get the current validator that gin is using
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		_ = v.RegisterValidation("currency", validCurrency)
+		_ = v.RegisterValidation("scope", validScope)
+		v.RegisterTagNameFunc(requestFieldName)
 	}
 
-	router.POST("/users", server.createUser)
-	router.POST("/users/login", server.loginUser)
+	router.GET("/healthz", server.healthz)
+	router.GET("/readyz", server.readyz)
+
+	publicRoutes := router.Group("/").Use(rateLimitMiddleware(server.limiter, "public", server.rateLimits.Public, clientIPKey))
+	publicRoutes.POST("/users", server.createUser)
+	publicRoutes.POST("/users/login", server.loginUser)
+	publicRoutes.POST("/users/password/forgot", server.forgotPassword)
+	publicRoutes.POST("/users/password/reset", server.resetPassword)
+	publicRoutes.POST("/users/verify-email", server.verifyEmail)
+	publicRoutes.POST("/oauth/token", server.oauthToken)
+
+	// the address is limited before the token is checked, requests with
+	// invalid tokens cost queries too
+	authRoutes := router.Group("/").Use(
+		rateLimitMiddleware(server.limiter, "api_ip", server.rateLimits.APIByIP, clientIPKey),
+		authMiddleware(server.tokenMaker, server.store),
+		rateLimitMiddleware(server.limiter, "api", server.rateLimits.API, usernameKey),
+	)
+	transferLimit := rateLimitMiddleware(server.limiter, "transfers", server.rateLimits.Transfers, usernameKey)
+	verifiedEmail := verifiedEmailMiddleware()
+	loginOnly := loginOnlyMiddleware()
+
+	// users
+	authRoutes.GET("/users/me", loginOnly, server.getUser)
+	authRoutes.PATCH("/users/me", loginOnly, server.updateUser)
+	authRoutes.GET("/users/me/logins", loginOnly, server.listLogins)
+	authRoutes.GET("/users/me/sessions", loginOnly, server.listSessions)
+	authRoutes.DELETE("/users/me/sessions/:id", loginOnly, server.revokeSession)
+	authRoutes.POST("/users/me/password", loginOnly, server.changePassword)
+	authRoutes.POST("/users/me/verify-email", loginOnly, server.resendVerification)
+	authRoutes.GET("/users/me/totp", loginOnly, server.getTOTP)
+	authRoutes.POST("/users/me/totp", loginOnly, server.enrollTOTP)
+	authRoutes.POST("/users/me/totp/confirm", loginOnly, server.confirmTOTP)
+	authRoutes.POST("/users/me/api-keys", loginOnly, server.createAPIKey)
+	authRoutes.GET("/users/me/api-keys", loginOnly, server.listAPIKeys)
+	authRoutes.DELETE("/users/me/api-keys/:id", loginOnly, server.revokeAPIKey)
+	authRoutes.GET("/users/me/consents", loginOnly, server.listConsents)
+	authRoutes.DELETE("/users/me/consents/:id", loginOnly, server.revokeConsent)
 
-	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
+	// oauth
+	authRoutes.POST("/oauth/clients", loginOnly, server.registerOAuthClient)
+	authRoutes.GET("/oauth/clients", loginOnly, server.listOAuthClients)
+	authRoutes.GET("/oauth/authorize", loginOnly, server.getAuthorize)
+	authRoutes.POST("/oauth/authorize", loginOnly, server.postAuthorize)
 
 	// accounts
-	authRoutes.POST("/accounts", server.createAccount)
-	authRoutes.GET("/accounts/:id", server.getAccount)
-	authRoutes.GET("/accounts", server.listAccount)
+	accountsRead := requireScopes(token.ScopeAccountsRead)
+	authRoutes.POST("/accounts", requireScopes(token.ScopeAccountsWrite), verifiedEmail, server.createAccount)
+	authRoutes.GET("/accounts/:id", accountsRead, server.getAccount)
+	authRoutes.GET("/accounts", accountsRead, server.listAccount)
+	authRoutes.GET("/accounts/:id/entries", accountsRead, server.listEntries)
+	authRoutes.GET("/accounts/:id/transfers", requireScopes(token.ScopeTransfersRead), server.listTransfers)
+	authRoutes.GET("/accounts/:id/statements", accountsRead, server.getStatement)
 
 	// transfer
-	authRoutes.POST("/transfers", server.createTransfer)
+	transfersWrite := requireScopes(token.ScopeTransfersWrite)
+	authRoutes.POST("/transfers", transfersWrite, verifiedEmail, transferLimit, server.createTransfer)
+	// a batch takes a token of the transfers limit per line, once it's parsed
+	authRoutes.POST("/transfers/batches", transfersWrite, verifiedEmail, server.createPaymentBatch)
 
 	server.router = router
 }
 
+// Start serves HTTP requests on address until Shutdown is called
 func (server *Server) Start(address string) error {
-	return server.router.Run(address)
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	err = server.httpServer.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
 
-func errorResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
+// Shutdown stops accepting connections and waits for in-flight requests to
+// finish, until ctx is done
+func (server *Server) Shutdown(ctx context.Context) error {
+	return server.httpServer.Shutdown(ctx)
 }
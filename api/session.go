@@ -0,0 +1,135 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// createSessionToken creates an access token of a login for username and
+// records its session, the device the request came from
+func (server *Server) createSessionToken(c *gin.Context, username string, options token.Options) (string, error) {
+	options.Session = true
+	accessToken, payload, err := server.tokenMaker.CreateToken(username, server.config.AccessTokenDuration, options)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = server.store.CreateSession(c, db.CreateSessionParams{
+		ID:        payload.ID,
+		Username:  username,
+		ClientIp:  c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		ExpiresAt: payload.ExpiredAt,
+		CreatedAt: payload.IssuedAt,
+	})
+	if err != nil {
+		return "", err
+	}
+	return accessToken, nil
+}
+
+// authenticateSession refuses the token of a revoked session and records
+// that the session was seen
+func authenticateSession(ctx *gin.Context, store db.Store, payload *token.Payload) bool {
+	session, err := store.GetSession(ctx, payload.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			abortWithError(ctx, http.StatusUnauthorized, newError(CodeUnauthenticated, "session of the token does not exist"))
+			return false
+		}
+		abortWithError(ctx, http.StatusInternalServerError, err)
+		return false
+	}
+
+	if !session.RevokedAt.IsZero() {
+		abortWithError(ctx, http.StatusUnauthorized, newError(CodeTokenRevoked, "session was revoked"))
+		return false
+	}
+
+	if err := store.TouchSession(ctx, session.ID); err != nil {
+		abortWithError(ctx, http.StatusInternalServerError, err)
+		return false
+	}
+	return true
+}
+
+type sessionRes struct {
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	ClientIP   string    `json:"client_ip"`
+	UserAgent  string    `json:"user_agent"`
+	ID         uuid.UUID `json:"id"`
+	Current    bool      `json:"current"`
+}
+
+// listSessions returns the sessions of the user a token can still be used in,
+// the one of the request marked current, last seen first
+func (server *Server) listSessions(c *gin.Context) {
+	user, valid := server.authUser(c)
+	if !valid {
+		return
+	}
+
+	sessions, err := server.store.ListSessions(c, user.Username)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	authPayload := c.MustGet(authPayloadKey).(*token.Payload)
+	res := make([]sessionRes, len(sessions))
+	for i, session := range sessions {
+		res[i] = sessionRes{
+			ID:         session.ID,
+			ClientIP:   session.ClientIp,
+			UserAgent:  session.UserAgent,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			CreatedAt:  session.CreatedAt,
+			Current:    authPayload.Session && session.ID == authPayload.ID,
+		}
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+type revokeSessionReq struct {
+	ID string `uri:"id" binding:"required,uuid"`
+}
+
+// revokeSession signs a device of the user out, its token stops working right
+// away. Revoking the current session logs out.
+func (server *Server) revokeSession(c *gin.Context) {
+	var req revokeSessionReq
+	if err := c.ShouldBindUri(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user, valid := server.authUser(c)
+	if !valid {
+		return
+	}
+
+	_, err := server.store.RevokeSession(c, db.RevokeSessionParams{
+		ID:       uuid.MustParse(req.ID),
+		Username: user.Username,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			abortWithError(c, http.StatusNotFound, newError(CodeNotFound, "session %s not found", req.ID))
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
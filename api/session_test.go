@@ -0,0 +1,237 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// addSessionAuthorization authorizes req with the token of a login, the
+// session it returns is the one of the token
+func addSessionAuthorization(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request, username string) db.Session {
+	accessToken, payload, err := tokenMaker.CreateToken(username, time.Minute, token.Options{Session: true})
+	require.NoError(t, err)
+	req.Header.Set("authorization", fmt.Sprintf("%s %s", authTypeBearer, accessToken))
+
+	return db.Session{
+		ID:         payload.ID,
+		Username:   username,
+		ClientIp:   "192.0.2.1",
+		UserAgent:  "test-agent",
+		ExpiresAt:  payload.ExpiredAt,
+		LastSeenAt: time.Now().Truncate(time.Second),
+		CreatedAt:  payload.IssuedAt.Truncate(time.Second),
+	}
+}
+
+func TestSessionAuth(t *testing.T) {
+	user, _ := randomUser()
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore, session db.Session)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		name          string
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().TouchSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(nil)
+				store.EXPECT().ListSessions(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return([]db.Session{}, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name: "Revoked",
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				session.RevokedAt = time.Now()
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().TouchSession(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListSessions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+				requireErrorCode(t, w.Body, CodeTokenRevoked)
+			},
+		},
+		{
+			name: "NotFound",
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{}, sql.ErrNoRows)
+				store.EXPECT().ListSessions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+				requireErrorCode(t, w.Body, CodeUnauthenticated)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{}, sql.ErrConnDone)
+				store.EXPECT().ListSessions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/users/me/sessions", nil)
+			session := addSessionAuthorization(t, server.tokenMaker, req, user.Username)
+
+			tc.buildStubs(store, session)
+			stubAuthUsers(store)
+
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, w)
+		})
+	}
+}
+
+func TestListSessions(t *testing.T) {
+	user, _ := randomUser()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/users/me/sessions", nil)
+	current := addSessionAuthorization(t, server.tokenMaker, req, user.Username)
+
+	other := current
+	other.ID = uuid.New()
+	other.UserAgent = "other-agent"
+	other.LastSeenAt = current.LastSeenAt.Add(-time.Hour)
+
+	store.EXPECT().GetSession(gomock.Any(), gomock.Eq(current.ID)).Times(1).Return(current, nil)
+	store.EXPECT().TouchSession(gomock.Any(), gomock.Eq(current.ID)).Times(1).Return(nil)
+	store.EXPECT().
+		ListSessions(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return([]db.Session{current, other}, nil)
+	stubAuthUsers(store)
+
+	server.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var res []sessionRes
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Len(t, res, 2)
+	require.Equal(t, current.ID, res[0].ID)
+	require.True(t, res[0].Current)
+	require.Equal(t, current.ClientIp, res[0].ClientIP)
+	require.True(t, current.LastSeenAt.Equal(res[0].LastSeenAt))
+	require.Equal(t, other.ID, res[1].ID)
+	require.False(t, res[1].Current)
+	require.Equal(t, other.UserAgent, res[1].UserAgent)
+}
+
+func TestRevokeSession(t *testing.T) {
+	user, _ := randomUser()
+	sessionID := uuid.New()
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		name          string
+		id            string
+	}{
+		{
+			name: "OK",
+			id:   sessionID.String(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevokeSession(gomock.Any(), gomock.Eq(db.RevokeSessionParams{ID: sessionID, Username: user.Username})).
+					Times(1).
+					Return(db.Session{ID: sessionID, RevokedAt: time.Now()}, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, w.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			id:   sessionID.String(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevokeSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, w.Code)
+				requireErrorCode(t, w.Body, CodeNotFound)
+			},
+		},
+		{
+			name: "InvalidID",
+			id:   "7",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			id:   sessionID.String(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevokeSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+
+			server := newTestServer(t, store)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodDelete, "/users/me/sessions/"+tc.id, nil)
+			addAuthorization(t, server.tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, w)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/oauth"
+	"simplebank/statement"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type getStatementUri struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type getStatementQuery struct {
+	Month  string `form:"month" binding:"required_without=From"`
+	From   string `form:"from" binding:"required_with=To"`
+	To     string `form:"to" binding:"required_with=From"`
+	Format string `form:"format" binding:"omitempty,oneof=csv pdf camt053 mt940"`
+}
+
+// statementFormat describes how a statement format is served
+type statementFormat struct {
+	contentType string
+	extension   string
+}
+
+var statementFormats = map[string]statementFormat{
+	"csv":     {contentType: "text/csv", extension: "csv"},
+	"pdf":     {contentType: "application/pdf", extension: "pdf"},
+	"camt053": {contentType: "application/xml", extension: "xml"},
+	"mt940":   {contentType: "text/plain", extension: "sta"},
+}
+
+func (server *Server) getStatement(c *gin.Context) {
+	var uri getStatementUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var req getStatementQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var (
+		period statement.Period
+		err    error
+		name   string
+	)
+	if req.Month != "" {
+		name = req.Month
+		period, err = statement.MonthPeriod(req.Month)
+	} else {
+		name = req.From + "_" + req.To
+		period, err = statement.DatePeriod(req.From, req.To)
+	}
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	account, valid := server.readableAccount(c, uri.ID, oauth.PermissionTransactions)
+	if !valid {
+		return
+	}
+
+	st, err := server.buildStatement(c, account, period)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	if req.Format == "" {
+		req.Format = "csv"
+	}
+
+	var buf bytes.Buffer
+	switch req.Format {
+	case "pdf":
+		err = statement.WritePDF(&buf, st)
+	case "camt053":
+		err = statement.WriteCamt053(&buf, st, time.Now())
+	case "mt940":
+		err = statement.WriteMT940(&buf, st)
+	default:
+		err = statement.WriteCSV(&buf, st)
+	}
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	format := statementFormats[req.Format]
+	filename := fmt.Sprintf("statement-%d-%s.%s", account.ID, name, format.extension)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, format.contentType, buf.Bytes())
+}
+
+// buildStatement loads the entries and transfers of account booked during period.
+// The opening balance is derived backwards from the current balance, in one
+// query so that a transfer booked meanwhile can't skew it.
+func (server *Server) buildStatement(c *gin.Context, account db.Account, period statement.Period) (statement.Statement, error) {
+	entries, err := server.store.ListEntriesInPeriod(c, db.ListEntriesInPeriodParams{
+		AccountID: account.ID,
+		FromTime:  period.From,
+		ToTime:    period.To,
+	})
+	if err != nil {
+		return statement.Statement{}, err
+	}
+
+	transfers, err := server.store.ListTransfersInPeriod(c, db.ListTransfersInPeriodParams{
+		AccountID: account.ID,
+		FromTime:  period.From,
+		ToTime:    period.To,
+	})
+	if err != nil {
+		return statement.Statement{}, err
+	}
+
+	opening, err := server.store.GetBalanceAt(c, db.GetBalanceAtParams{
+		AccountID: account.ID,
+		FromTime:  period.From,
+	})
+	if err != nil {
+		return statement.Statement{}, err
+	}
+
+	return statement.New(account, period, opening, entries, transfers), nil
+}
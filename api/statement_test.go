@@ -0,0 +1,225 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStatement(t *testing.T) {
+	user, _ := randomUser()
+	account := randomAccount(user.Username)
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	entries := []db.Entry{
+		{ID: 1, AccountID: account.ID, Amount: 100, CreatedAt: from.Add(time.Hour)},
+	}
+
+	buildStubs := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+			Times(1).
+			Return(account, nil)
+		store.EXPECT().
+			ListEntriesInPeriod(gomock.Any(), gomock.Eq(db.ListEntriesInPeriodParams{
+				AccountID: account.ID,
+				FromTime:  from,
+				ToTime:    to,
+			})).
+			Times(1).
+			Return(entries, nil)
+		store.EXPECT().
+			ListTransfersInPeriod(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return([]db.Transfer{}, nil)
+		store.EXPECT().
+			GetBalanceAt(gomock.Any(), gomock.Eq(db.GetBalanceAtParams{
+				AccountID: account.ID,
+				FromTime:  from,
+			})).
+			Times(1).
+			Return(account.Balance-100, nil)
+	}
+
+	testCase := []struct {
+		setupAuth     func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		name          string
+		query         string
+	}{
+		{
+			name:  "CSV",
+			query: "month=2026-09",
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: buildStubs,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+				require.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+				require.Contains(t, w.Header().Get("Content-Disposition"), fmt.Sprintf("statement-%d-2026-09.csv", account.ID))
+				require.True(t, strings.HasPrefix(w.Body.String(), "date,entry_id"))
+			},
+		},
+		{
+			name:  "PDF",
+			query: "month=2026-09&format=pdf",
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: buildStubs,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+				require.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+				require.True(t, strings.HasPrefix(w.Body.String(), "%PDF-"))
+			},
+		},
+		{
+			name:  "Camt053",
+			query: "from=2026-09-01&to=2026-09-30&format=camt053",
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: buildStubs,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+				require.Equal(t, "application/xml", w.Header().Get("Content-Type"))
+				require.Contains(t, w.Header().Get("Content-Disposition"), fmt.Sprintf("statement-%d-2026-09-01_2026-09-30.xml", account.ID))
+				require.Contains(t, w.Body.String(), "camt.053.001.02")
+			},
+		},
+		{
+			name:  "MT940",
+			query: "month=2026-09&format=mt940",
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: buildStubs,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+				require.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+				require.True(t, strings.HasPrefix(w.Body.String(), ":20:"))
+			},
+		},
+		{
+			name:  "MissingPeriod",
+			query: "format=csv",
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name:  "InvalidMonth",
+			query: "month=2026-9",
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name:  "InvalidFormat",
+			query: "month=2026-09&format=xls",
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name:  "UnauthorizedUser",
+			query: "month=2026-09",
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, "unauthorized_user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					ListEntriesInPeriod(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+			},
+		},
+		{
+			name:  "InternalServerError",
+			query: "month=2026-09",
+			setupAuth: func(t *testing.T, tokenMaker *token.PasetoMaker, req *http.Request) {
+				addAuthorization(t, tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					ListEntriesInPeriod(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Entry{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for i := range testCase {
+		tc := testCase[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+
+			url := fmt.Sprintf("/accounts/%d/statements?%s", account.ID, tc.query)
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			server := newTestServer(t, store)
+			w := httptest.NewRecorder()
+			tc.setupAuth(t, server.tokenMaker, req)
+			server.router.ServeHTTP(w, req)
+
+			tc.checkResponse(t, w)
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/totp"
+
+	"github.com/gin-gonic/gin"
+)
+
+type totpRes struct {
+	Enabled           bool  `json:"enabled"`
+	RecoveryCodesLeft int64 `json:"recovery_codes_left"`
+}
+
+// getTOTP tells whether the user has two-factor authentication on
+func (server *Server) getTOTP(c *gin.Context) {
+	user, valid := server.authUser(c)
+	if !valid {
+		return
+	}
+
+	res := totpRes{Enabled: user.TotpEnabled}
+	if user.TotpEnabled {
+		count, err := server.store.CountRecoveryCodes(c, user.Username)
+		if err != nil {
+			abortWithError(c, http.StatusInternalServerError, err)
+			return
+		}
+		res.RecoveryCodesLeft = count
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+type enrollTOTPRes struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+// enrollTOTP starts the enrollment, the secret is shown once for the
+// authenticator app and stays unused until confirmTOTP
+func (server *Server) enrollTOTP(c *gin.Context) {
+	user, valid := server.authUser(c)
+	if !valid {
+		return
+	}
+
+	enrollment, err := server.totp.Enroll(c, user)
+	if err != nil {
+		abortWithError(c, totpErrorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollTOTPRes{
+		Secret:     enrollment.Secret,
+		OtpauthURI: enrollment.URI,
+	})
+}
+
+type confirmTOTPReq struct {
+	Code string `json:"code" binding:"required,numeric,len=6"`
+}
+
+type confirmTOTPRes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// confirmTOTP enables the second factor once the user proved their app
+// generates the right codes
+func (server *Server) confirmTOTP(c *gin.Context) {
+	var req confirmTOTPReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user, valid := server.authUser(c)
+	if !valid {
+		return
+	}
+
+	recoveryCodes, err := server.totp.Confirm(c, user, req.Code)
+	if err != nil {
+		abortWithError(c, totpErrorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, confirmTOTPRes{RecoveryCodes: recoveryCodes})
+}
+
+// requireFreshTOTP asks users who enabled TOTP for a code of the
+// authenticator app on transfers of TOTPTransferThreshold or more, so a stolen
+// access token alone can't move large amounts. Users without TOTP are only
+// refused when TOTPTransferRequired is set. It answers the request itself
+// when the check fails.
+func (server *Server) requireFreshTOTP(c *gin.Context, amount int64, code string) bool {
+	threshold := server.config.TOTPTransferThreshold
+	if threshold <= 0 || amount < threshold {
+		return true
+	}
+
+	user, valid := server.authUser(c)
+	if !valid {
+		return false
+	}
+	if !user.TotpEnabled && !server.config.TOTPTransferRequired {
+		return true
+	}
+	if !server.notLocked(c, user) {
+		return false
+	}
+
+	err := server.totp.Verify(c, user, code)
+	if errors.Is(err, totp.ErrInvalidCode) {
+		if err := server.recordFailedLogin(c, user); err != nil {
+			abortWithError(c, http.StatusInternalServerError, err)
+			return false
+		}
+	}
+	if err != nil {
+		abortWithError(c, totpErrorStatus(err), err)
+		return false
+	}
+	return true
+}
+
+// authUser is the authenticated user, as authMiddleware loaded them
+func (server *Server) authUser(c *gin.Context) (db.User, bool) {
+	user, ok := c.MustGet(authUserKey).(db.User)
+	if !ok {
+		abortWithError(c, http.StatusInternalServerError, errors.New("authenticated user is missing"))
+	}
+	return user, ok
+}
+
+func totpErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, totp.ErrRequired), errors.Is(err, totp.ErrInvalidCode):
+		return http.StatusUnauthorized
+	case errors.Is(err, totp.ErrNotEnabled):
+		return http.StatusForbidden
+	case errors.Is(err, totp.ErrAlreadyEnabled):
+		return http.StatusConflict
+	case errors.Is(err, totp.ErrNotEnrolled):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,501 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/totp"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// enrolledUser returns user with a TOTP secret sealed with the key of server,
+// and the secret to compute codes with
+func enrolledUser(t *testing.T, server *Server, user db.User, enabled bool) (db.User, string) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		SetTOTPSecret(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.SetTOTPSecretParams) (db.User, error) {
+			user.TotpSecret = arg.TotpSecret
+			return user, nil
+		})
+
+	verifier, err := totp.NewVerifier(store, server.config.TOTPEncryptionKey)
+	require.NoError(t, err)
+	enrollment, err := verifier.Enroll(context.Background(), user)
+	require.NoError(t, err)
+
+	user.TotpEnabled = enabled
+	return user, enrollment.Secret
+}
+
+func currentCode(t *testing.T, secret string) string {
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	return code
+}
+
+func TestEnrollTOTP(t *testing.T) {
+	user, _ := randomUser()
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		name          string
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					SetTOTPSecret(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+
+				var res enrollTOTPRes
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				require.Len(t, res.Secret, 32)
+				require.Equal(t, totp.URI(user.Username, res.Secret), res.OtpauthURI)
+			},
+		},
+		{
+			name: "AlreadyEnabled",
+			buildStubs: func(store *mockdb.MockStore) {
+				enabledUser := user
+				enabledUser.TotpEnabled = true
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(enabledUser, nil)
+				store.EXPECT().
+					SetTOTPSecret(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, w.Code)
+				requireErrorCode(t, w.Body, CodeConflict)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			w := httptest.NewRecorder()
+
+			req := httptest.NewRequest(http.MethodPost, "/users/me/totp", nil)
+			addAuthorization(t, server.tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, w)
+		})
+	}
+}
+
+func TestConfirmTOTP(t *testing.T) {
+	user, _ := randomUser()
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore, enrolled db.User)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		code          func(secret string) string
+		name          string
+	}{
+		{
+			name: "OK",
+			code: func(secret string) string { return currentCode(t, secret) },
+			buildStubs: func(store *mockdb.MockStore, enrolled db.User) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(enrolled, nil)
+				store.EXPECT().
+					EnableTOTPTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(enrolled, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+
+				var res confirmTOTPRes
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				require.Len(t, res.RecoveryCodes, totp.RecoveryCodeCount)
+			},
+		},
+		{
+			name: "InvalidCode",
+			code: func(secret string) string { return "000000" },
+			buildStubs: func(store *mockdb.MockStore, enrolled db.User) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(enrolled, nil)
+				store.EXPECT().
+					EnableTOTPTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+				requireErrorCode(t, w.Body, CodeInvalidTOTP)
+			},
+		},
+		{
+			name: "NotEnrolled",
+			code: func(secret string) string { return currentCode(t, secret) },
+			buildStubs: func(store *mockdb.MockStore, enrolled db.User) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+				requireErrorCode(t, w.Body, CodeInvalidArgument)
+			},
+		},
+		{
+			name: "InvalidRequest",
+			code: func(secret string) string { return "abc" },
+			buildStubs: func(store *mockdb.MockStore, enrolled db.User) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(enrolled, nil)
+				store.EXPECT().
+					EnableTOTPTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			enrolled, secret := enrolledUser(t, server, user, false)
+			tc.buildStubs(store, enrolled)
+
+			body, err := json.Marshal(confirmTOTPReq{Code: tc.code(secret)})
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/users/me/totp/confirm", bytes.NewReader(body))
+			addAuthorization(t, server.tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, w)
+		})
+	}
+}
+
+func TestGetTOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user, _ := randomUser()
+	user.TotpEnabled = true
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(user, nil)
+	store.EXPECT().
+		CountRecoveryCodes(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(int64(7), nil)
+
+	server := newTestServer(t, store)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/users/me/totp", nil)
+	addAuthorization(t, server.tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+	server.router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var res totpRes
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Equal(t, totpRes{Enabled: true, RecoveryCodesLeft: 7}, res)
+}
+
+func TestLoginUserTOTP(t *testing.T) {
+	user, password := randomUser()
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+	user.HashedPassword = hashedPassword
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore, enabled db.User)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		input         func(secret string) loginUserReq
+		name          string
+	}{
+		{
+			name: "OK",
+			input: func(secret string) loginUserReq {
+				return loginUserReq{Username: user.Username, Password: password, TOTPCode: currentCode(t, secret)}
+			},
+			buildStubs: func(store *mockdb.MockStore, enabled db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(enabled, nil)
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+				store.EXPECT().CreateLogin(gomock.Any(), gomock.Any()).Times(1).Return(db.Login{}, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{}, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name: "RecoveryCode",
+			input: func(secret string) loginUserReq {
+				return loginUserReq{Username: user.Username, Password: password, RecoveryCode: "abcde-fghjk"}
+			},
+			buildStubs: func(store *mockdb.MockStore, enabled db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(enabled, nil)
+				store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+				store.EXPECT().CreateLogin(gomock.Any(), gomock.Any()).Times(1).Return(db.Login{}, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{}, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name: "CodeRequired",
+			input: func(secret string) loginUserReq {
+				return loginUserReq{Username: user.Username, Password: password}
+			},
+			buildStubs: func(store *mockdb.MockStore, enabled db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(enabled, nil)
+				store.EXPECT().RecordFailedLogin(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateLogin(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+				requireErrorCode(t, w.Body, CodeTOTPRequired)
+			},
+		},
+		{
+			name: "InvalidCode",
+			input: func(secret string) loginUserReq {
+				return loginUserReq{Username: user.Username, Password: password, TOTPCode: "000000"}
+			},
+			buildStubs: func(store *mockdb.MockStore, enabled db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(enabled, nil)
+				store.EXPECT().RecordFailedLogin(gomock.Any(), gomock.Any()).Times(1).Return(enabled, nil)
+				store.EXPECT().CreateLogin(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+				requireErrorCode(t, w.Body, CodeInvalidTOTP)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			enabled, secret := enrolledUser(t, server, user, true)
+			tc.buildStubs(store, enabled)
+
+			body, err := json.Marshal(tc.input(secret))
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(body))
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, w)
+		})
+	}
+}
+
+func TestCreateTransferTOTP(t *testing.T) {
+	user1, _ := randomUser()
+	user1.EmailVerified = true
+	user2, _ := randomUser()
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account2.ID = account1.ID + 1
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	const threshold = 100000
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore, enabled db.User)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		input         func(secret string) transferReq
+		name          string
+		required      bool
+	}{
+		{
+			name: "BelowThreshold",
+			input: func(secret string) transferReq {
+				return transferReq{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: threshold - 1, Currency: util.USD}
+			},
+			buildStubs: func(store *mockdb.MockStore, enabled db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.Username)).Times(1).Return(enabled, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name: "FreshCode",
+			input: func(secret string) transferReq {
+				return transferReq{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: threshold, Currency: util.USD, TOTPCode: currentCode(t, secret)}
+			},
+			buildStubs: func(store *mockdb.MockStore, enabled db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.Username)).Times(1).Return(enabled, nil)
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name: "CodeRequired",
+			input: func(secret string) transferReq {
+				return transferReq{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: threshold, Currency: util.USD}
+			},
+			buildStubs: func(store *mockdb.MockStore, enabled db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.Username)).Times(1).Return(enabled, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+				requireErrorCode(t, w.Body, CodeTOTPRequired)
+			},
+		},
+		{
+			name: "InvalidCode",
+			input: func(secret string) transferReq {
+				return transferReq{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: threshold, Currency: util.USD, TOTPCode: "000000"}
+			},
+			buildStubs: func(store *mockdb.MockStore, enabled db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.Username)).Times(1).Return(enabled, nil)
+				store.EXPECT().RecordFailedLogin(gomock.Any(), gomock.Any()).Times(1).Return(enabled, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+				requireErrorCode(t, w.Body, CodeInvalidTOTP)
+			},
+		},
+		{
+			name: "NotEnabled",
+			input: func(secret string) transferReq {
+				return transferReq{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: threshold, Currency: util.USD}
+			},
+			buildStubs: func(store *mockdb.MockStore, enabled db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.Username)).Times(1).Return(user1, nil)
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name:     "NotEnabledRequired",
+			required: true,
+			input: func(secret string) transferReq {
+				return transferReq{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: threshold, Currency: util.USD, TOTPCode: currentCode(t, secret)}
+			},
+			buildStubs: func(store *mockdb.MockStore, enabled db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.Username)).Times(1).Return(user1, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, w.Code)
+				requireErrorCode(t, w.Body, CodeTOTPNotEnabled)
+			},
+		},
+		{
+			name: "UserLocked",
+			input: func(secret string) transferReq {
+				return transferReq{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: threshold, Currency: util.USD, TOTPCode: currentCode(t, secret)}
+			},
+			buildStubs: func(store *mockdb.MockStore, enabled db.User) {
+				enabled.LockedUntil = time.Now().Add(time.Minute)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user1.Username)).Times(1).Return(enabled, nil)
+				store.EXPECT().UseTOTPStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusLocked, w.Code)
+				requireErrorCode(t, w.Body, CodeUserLocked)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			server.config.TOTPTransferThreshold = threshold
+			server.config.TOTPTransferRequired = tc.required
+			enabled, secret := enrolledUser(t, server, user1, true)
+
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).AnyTimes().Return(account1, nil)
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).AnyTimes().Return(account2, nil)
+			tc.buildStubs(store, enabled)
+
+			body, err := json.Marshal(tc.input(secret))
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(body))
+			addAuthorization(t, server.tokenMaker, req, authTypeBearer, user1.Username, time.Minute)
+
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, w)
+		})
+	}
+}
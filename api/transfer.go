@@ -2,8 +2,6 @@ package api
 
 import (
 	"database/sql"
-	"errors"
-	"fmt"
 	"net/http"
 	db "simplebank/db/sqlc"
 	"simplebank/token"
@@ -16,12 +14,14 @@ type transferReq struct {
 	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
 	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
 	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	// required from TOTP_TRANSFER_THRESHOLD on
+	TOTPCode string `json:"totp_code" binding:"omitempty,numeric,len=6"`
 }
 
 func (server *Server) createTransfer(c *gin.Context) {
 	var req transferReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -32,8 +32,8 @@ func (server *Server) createTransfer(c *gin.Context) {
 
 	authPayload := c.MustGet(authPayloadKey).(*token.Payload)
 	if fromAccount.Owner != authPayload.Username {
-		err := errors.New("from account doesn't belong to authenticated user")
-		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		err := newError(CodeAccountNotOwned, "from account doesn't belong to the authenticated user")
+		abortWithError(c, http.StatusUnauthorized, err)
 		return
 	}
 
@@ -42,6 +42,10 @@ func (server *Server) createTransfer(c *gin.Context) {
 		return
 	}
 
+	if !server.requireFreshTOTP(c, req.Amount, req.TOTPCode) {
+		return
+	}
+
 	arg := db.TransferTxParams{
 		FromAccountID: req.FromAccountID,
 		ToAccountID:   req.ToAccountID,
@@ -49,7 +53,7 @@ func (server *Server) createTransfer(c *gin.Context) {
 	}
 	result, err := server.store.TransferTx(c, arg)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -60,17 +64,17 @@ func (server *Server) validAccount(c *gin.Context, accountID int64, currency str
 	account, err := server.store.GetAccount(c, accountID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, errorResponse(err))
+			abortWithError(c, http.StatusNotFound, newError(CodeNotFound, "account %d not found", accountID))
 			return account, false
 		}
 
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(c, http.StatusInternalServerError, err)
 		return account, false
 	}
 
 	if account.Currency != currency {
-		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", accountID, account.Currency, currency)
-		c.JSON(http.StatusBadRequest, errorResponse(err))
+		err := newError(CodeCurrencyMismatch, "account [%d] currency mismatch: %s vs %s", accountID, account.Currency, currency)
+		abortWithError(c, http.StatusBadRequest, err)
 		return account, false
 	}
 
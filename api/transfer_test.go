@@ -97,6 +97,7 @@ func TestCreateTransfer(t *testing.T) {
 			},
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, w.Code)
+				requireErrorCode(t, w.Body, CodeCurrencyMismatch)
 			},
 		},
 		{
@@ -121,6 +122,7 @@ func TestCreateTransfer(t *testing.T) {
 			},
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, w.Code)
+				requireErrorCode(t, w.Body, CodeInternal)
 			},
 		},
 	}
@@ -141,6 +143,7 @@ func TestCreateTransfer(t *testing.T) {
 			w := httptest.NewRecorder()
 
 			tc.buildStubs(store, arg)
+			stubAuthUsers(store)
 
 			url := "/transfers"
 			req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonValue))
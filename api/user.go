@@ -2,8 +2,12 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 	db "simplebank/db/sqlc"
+	"simplebank/logging"
+	"simplebank/token"
+	"simplebank/totp"
 	"simplebank/util"
 	"time"
 
@@ -24,6 +28,7 @@ type userRes struct {
 	Username          string    `json:"username"`
 	FullName          string    `json:"full_name"`
 	Email             string    `json:"email"`
+	EmailVerified     bool      `json:"email_verified"`
 }
 
 func newUserRes(user db.User) userRes {
@@ -31,6 +36,7 @@ func newUserRes(user db.User) userRes {
 		Username:          user.Username,
 		FullName:          user.FullName,
 		Email:             user.Email,
+		EmailVerified:     user.EmailVerified,
 		PasswordChangedAt: user.PasswordChangedAt,
 		CreatedAt:         user.CreatedAt,
 	}
@@ -39,13 +45,17 @@ func newUserRes(user db.User) userRes {
 func (server *Server) createUser(c *gin.Context) {
 	var req createUserReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := server.config.PasswordPolicy().Validate(req.Password); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
 	hashedPassword, err := util.HashPassword(req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
 	arg := db.CreateUserParams{
@@ -59,14 +69,19 @@ func (server *Server) createUser(c *gin.Context) {
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Code.Name() {
 			case "unique_violation":
-				c.JSON(http.StatusForbidden, errorResponse(err))
+				abortWithError(c, http.StatusForbidden, newError(CodeAlreadyExists, "username or email already exists"))
 				return
 			}
 		}
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
 
+	// the user can ask for another link, signing up doesn't fail on it
+	if err := server.verifier.Send(c, user); err != nil {
+		logging.FromContext(c).Error("can not send verification email", "user", user.Username, "error", err)
+	}
+
 	res := newUserRes(user)
 	c.JSON(http.StatusOK, res)
 }
@@ -74,6 +89,12 @@ func (server *Server) createUser(c *gin.Context) {
 type loginUserReq struct {
 	Username string `json:"username" binding:"required,alphanum"`
 	Password string `json:"password" binding:"required,min=6"`
+	// one of them is required once the user enabled TOTP
+	TOTPCode     string `json:"totp_code" binding:"omitempty,numeric,len=6"`
+	RecoveryCode string `json:"recovery_code"`
+	// Scopes and Audience narrow down the token, a read-only one for example
+	Scopes   []string `json:"scopes" binding:"omitempty,unique,dive,scope"`
+	Audience string   `json:"audience" binding:"omitempty,oneof=http grpc"`
 }
 
 type loginUserRes struct {
@@ -84,35 +105,240 @@ type loginUserRes struct {
 func (server *Server) loginUser(c *gin.Context) {
 	var req loginUserReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
 	user, err := server.store.GetUser(c, req.Username)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, errorResponse(err))
+			abortWithError(c, http.StatusNotFound, newError(CodeNotFound, "user %s not found", req.Username))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	// a locked user is refused before the password is checked, guessing goes
+	// on only once the cool-down is over
+	if !server.notLocked(c, user) {
 		return
 	}
 
 	err = util.CheckPassword(req.Password, user.HashedPassword)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, errorResponse(err))
+		if err := server.recordFailedLogin(c, user); err != nil {
+			abortWithError(c, http.StatusInternalServerError, err)
+			return
+		}
+		abortWithError(c, http.StatusUnauthorized, err)
 		return
 	}
 
-	token, err := server.tokenMaker.CreateToken(user.Username, server.config.AccessTokenDuration)
+	// wrong codes count as failed logins, the lockout stops guessing them too
+	err = server.totp.VerifyLogin(c, user, req.TOTPCode, req.RecoveryCode)
+	if errors.Is(err, totp.ErrInvalidCode) {
+		if err := server.recordFailedLogin(c, user); err != nil {
+			abortWithError(c, http.StatusInternalServerError, err)
+			return
+		}
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(c, totpErrorStatus(err), err)
+		return
+	}
+
+	if user.FailedLoginAttempts > 0 {
+		if err := server.store.ResetFailedLogins(c, user.Username); err != nil {
+			abortWithError(c, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	server.rehashPassword(c, user, req.Password)
+
+	_, err = server.store.CreateLogin(c, db.CreateLoginParams{
+		Username:  user.Username,
+		ClientIp:  c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	accessToken, err := server.createSessionToken(c, user.Username, token.Options{
+		Audience: req.Audience,
+		Scopes:   req.Scopes,
+	})
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
 
 	res := loginUserRes{
-		AccessToken: token,
+		AccessToken: accessToken,
 		User:        newUserRes(user),
 	}
 	c.JSON(http.StatusOK, res)
 }
+
+// notLocked answers the request when user is locked after failed logins
+func (server *Server) notLocked(c *gin.Context, user db.User) bool {
+	lockedFor := time.Until(user.LockedUntil)
+	if lockedFor <= 0 {
+		return true
+	}
+
+	retryAfter := setRetryAfter(c, lockedFor)
+	abortWithError(c, http.StatusLocked, newError(CodeUserLocked, "too many failed login attempts, retry in %d seconds", retryAfter))
+	return false
+}
+
+// recordFailedLogin counts the wrong password of user and locks them once
+// there are too many
+func (server *Server) recordFailedLogin(c *gin.Context, user db.User) error {
+	updated, err := server.store.RecordFailedLogin(c, db.RecordFailedLoginParams{
+		Username:          user.Username,
+		MaxAttempts:       server.config.LoginMaxAttempts,
+		LockoutSeconds:    server.config.LoginLockout.Seconds(),
+		MaxLockoutSeconds: server.config.LoginMaxLockout.Seconds(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if updated.LockedUntil.After(user.LockedUntil) {
+		logging.FromContext(c).Warn("user locked after failed logins",
+			"user", user.Username,
+			"failed_login_attempts", updated.FailedLoginAttempts,
+			"locked_until", updated.LockedUntil,
+			"client_ip", c.ClientIP(),
+		)
+	}
+	return nil
+}
+
+type listLoginsReq struct {
+	Limit int32 `form:"limit" binding:"omitempty,min=1,max=50"`
+}
+
+type loginRes struct {
+	CreatedAt time.Time `json:"created_at"`
+	ClientIP  string    `json:"client_ip"`
+	UserAgent string    `json:"user_agent"`
+	ID        int64     `json:"id"`
+}
+
+// listLogins returns the latest successful sign-ins of the user, newest first
+func (server *Server) listLogins(c *gin.Context) {
+	var req listLoginsReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 20
+	}
+
+	authPayload := c.MustGet(authPayloadKey).(*token.Payload)
+	logins, err := server.store.ListLogins(c, db.ListLoginsParams{
+		Username: authPayload.Username,
+		Limit:    req.Limit,
+	})
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	res := listRes[loginRes]{Data: make([]loginRes, 0, len(logins))}
+	for _, login := range logins {
+		res.Data = append(res.Data, loginRes{
+			ID:        login.ID,
+			ClientIP:  login.ClientIp,
+			UserAgent: login.UserAgent,
+			CreatedAt: login.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// getUser returns the profile of the authenticated user
+func (server *Server) getUser(c *gin.Context) {
+	user, valid := server.authUser(c)
+	if !valid {
+		return
+	}
+
+	c.JSON(http.StatusOK, newUserRes(user))
+}
+
+type updateUserReq struct {
+	FullName *string `json:"full_name" binding:"omitempty,min=1"`
+	Email    *string `json:"email" binding:"omitempty,email"`
+	// a new email address takes over password resets, changing it needs the password
+	CurrentPassword string `json:"current_password"`
+}
+
+// updateUser changes the fields of the profile present in the body. A new
+// email address has to be verified again, a link is sent to it.
+func (server *Server) updateUser(c *gin.Context) {
+	var req updateUserReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+	if req.FullName == nil && req.Email == nil {
+		abortWithError(c, http.StatusBadRequest, newError(CodeInvalidArgument, "nothing to update, set full_name or email"))
+		return
+	}
+
+	user, valid := server.authUser(c)
+	if !valid {
+		return
+	}
+
+	if req.Email != nil && *req.Email != user.Email {
+		if !server.notLocked(c, user) {
+			return
+		}
+		if err := util.CheckPassword(req.CurrentPassword, user.HashedPassword); err != nil {
+			if err := server.recordFailedLogin(c, user); err != nil {
+				abortWithError(c, http.StatusInternalServerError, err)
+				return
+			}
+			abortWithError(c, http.StatusUnauthorized, newError(CodeInvalidCredentials, "current password is incorrect"))
+			return
+		}
+	}
+
+	arg := db.UpdateUserTxParams{
+		Username:  user.Username,
+		ClientIp:  c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	if req.FullName != nil {
+		arg.FullName = sql.NullString{String: *req.FullName, Valid: true}
+	}
+	if req.Email != nil {
+		arg.Email = sql.NullString{String: *req.Email, Valid: true}
+	}
+
+	result, err := server.store.UpdateUserTx(c, arg)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			abortWithError(c, http.StatusConflict, newError(CodeAlreadyExists, "email address is already in use"))
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	if result.EmailChanged() {
+		if err := server.verifier.Send(c, result.User); err != nil {
+			logging.FromContext(c).Error("can not send verification email", "user", user.Username, "error", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, newUserRes(result.User))
+}
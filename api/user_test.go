@@ -2,6 +2,8 @@ package api
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -9,12 +11,16 @@ import (
 	"reflect"
 	mockdb "simplebank/db/mock"
 	db "simplebank/db/sqlc"
+	"simplebank/token"
 	"simplebank/util"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type createUserMatcher struct {
@@ -71,7 +77,41 @@ func TestCreateUser(t *testing.T) {
 				store.EXPECT().
 					CreateUser(gomock.Any(), EqCreateUserParams(arg, password)).
 					Times(1).
-					Return(db.User{}, nil)
+					Return(user, nil)
+				store.EXPECT().
+					CreateEmailVerification(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateEmailVerificationParams) (db.EmailVerification, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, user.Email, arg.Email)
+						return db.EmailVerification{}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+
+				var res userRes
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				require.False(t, res.EmailVerified)
+			},
+		},
+		{
+			name: "VerificationEmailFails",
+			input: createUserReq{
+				Username: user.Username,
+				Password: password,
+				FullName: user.FullName,
+				Email:    user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateEmailVerification(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.EmailVerification{}, sql.ErrConnDone)
 			},
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, w.Code)
@@ -93,6 +133,7 @@ func TestCreateUser(t *testing.T) {
 			},
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusForbidden, w.Code)
+				requireErrorCode(t, w.Body, CodeAlreadyExists)
 			},
 		},
 		{
@@ -112,6 +153,24 @@ func TestCreateUser(t *testing.T) {
 				require.Equal(t, http.StatusBadRequest, w.Code)
 			},
 		},
+		{
+			name: "CommonPassword",
+			input: createUserReq{
+				Username: user.Username,
+				Password: "password1",
+				FullName: user.FullName,
+				Email:    user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+				requireErrorCode(t, w.Body, CodeWeakPassword)
+			},
+		},
 		{
 			name: "TooShortPassword",
 			input: createUserReq{
@@ -159,6 +218,405 @@ func TestCreateUser(t *testing.T) {
 
 }
 
+func TestLoginUser(t *testing.T) {
+	user, password := randomUser()
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+	user.HashedPassword = hashedPassword
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		input         loginUserReq
+		name          string
+	}{
+		{
+			name:  "OK",
+			input: loginUserReq{Username: user.Username, Password: password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					ResetFailedLogins(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CreateLogin(gomock.Any(), gomock.Eq(db.CreateLoginParams{
+						Username:  user.Username,
+						ClientIp:  "192.0.2.1",
+						UserAgent: "test-agent",
+					})).
+					Times(1).
+					Return(db.Login{}, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateSessionParams) (db.Session, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, "192.0.2.1", arg.ClientIp)
+						require.Equal(t, "test-agent", arg.UserAgent)
+						require.NotZero(t, arg.ID)
+						return db.Session{ID: arg.ID}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name:  "ResetFailedLogins",
+			input: loginUserReq{Username: user.Username, Password: password},
+			buildStubs: func(store *mockdb.MockStore) {
+				failedUser := user
+				failedUser.FailedLoginAttempts = 3
+				failedUser.LockedUntil = time.Now().Add(-time.Minute)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(failedUser, nil)
+				store.EXPECT().
+					ResetFailedLogins(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(nil)
+				store.EXPECT().
+					CreateLogin(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Login{}, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name:  "RehashBcrypt",
+			input: loginUserReq{Username: user.Username, Password: password},
+			buildStubs: func(store *mockdb.MockStore) {
+				bcryptHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+				require.NoError(t, err)
+				bcryptUser := user
+				bcryptUser.HashedPassword = string(bcryptHash)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(bcryptUser, nil)
+				store.EXPECT().
+					RehashUserPassword(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RehashUserPasswordParams) error {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, bcryptUser.HashedPassword, arg.OldHash)
+						require.NoError(t, util.CheckPassword(password, arg.NewHash))
+						require.False(t, util.NeedsRehash(arg.NewHash))
+						return nil
+					})
+				store.EXPECT().
+					CreateLogin(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Login{}, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name:  "RehashFails",
+			input: loginUserReq{Username: user.Username, Password: password},
+			buildStubs: func(store *mockdb.MockStore) {
+				bcryptHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+				require.NoError(t, err)
+				bcryptUser := user
+				bcryptUser.HashedPassword = string(bcryptHash)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(bcryptUser, nil)
+				store.EXPECT().
+					RehashUserPassword(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sql.ErrConnDone)
+				store.EXPECT().
+					CreateLogin(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Login{}, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name:  "IncorrectPassword",
+			input: loginUserReq{Username: user.Username, Password: "incorrect"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					RecordFailedLogin(gomock.Any(), gomock.Eq(db.RecordFailedLoginParams{
+						Username:          user.Username,
+						MaxAttempts:       3,
+						LockoutSeconds:    60,
+						MaxLockoutSeconds: 3600,
+					})).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateLogin(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+				requireErrorCode(t, w.Body, CodeInvalidCredentials)
+			},
+		},
+		{
+			name:  "RecordFailedLoginError",
+			input: loginUserReq{Username: user.Username, Password: "incorrect"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					RecordFailedLogin(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+		{
+			name:  "UserLocked",
+			input: loginUserReq{Username: user.Username, Password: password},
+			buildStubs: func(store *mockdb.MockStore) {
+				lockedUser := user
+				lockedUser.FailedLoginAttempts = 3
+				lockedUser.LockedUntil = time.Now().Add(90 * time.Second)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(lockedUser, nil)
+				store.EXPECT().
+					RecordFailedLogin(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CreateLogin(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusLocked, w.Code)
+				requireErrorCode(t, w.Body, CodeUserLocked)
+				require.Equal(t, "90", w.Header().Get("Retry-After"))
+			},
+		},
+		{
+			name:  "UserNotFound",
+			input: loginUserReq{Username: user.Username, Password: password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+		{
+			name:  "InvalidScope",
+			input: loginUserReq{Username: user.Username, Password: password, Scopes: []string{"users:write"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name:  "InvalidAudience",
+			input: loginUserReq{Username: user.Username, Password: password, Audience: "ftp"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			jsonVal, err := json.Marshal(tc.input)
+			require.NoError(t, err)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.config.LoginMaxAttempts = 3
+			server.config.LoginLockout = time.Minute
+			server.config.LoginMaxLockout = time.Hour
+			w := httptest.NewRecorder()
+
+			req := httptest.NewRequest(http.MethodPost, "/users/login", bytes.NewBuffer(jsonVal))
+			req.Header.Set("User-Agent", "test-agent")
+
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, w)
+		})
+	}
+}
+
+func TestLoginUserScopes(t *testing.T) {
+	user, password := randomUser()
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+	user.HashedPassword = hashedPassword
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+	store.EXPECT().CreateLogin(gomock.Any(), gomock.Any()).Times(1).Return(db.Login{}, nil)
+	store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{}, nil)
+
+	server := newTestServer(t, store)
+	body, err := json.Marshal(loginUserReq{
+		Username: user.Username,
+		Password: password,
+		Scopes:   []string{token.ScopeAccountsRead},
+		Audience: token.AudienceHTTP,
+	})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/users/login", bytes.NewBuffer(body))
+	server.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var res loginUserRes
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	payload, err := server.tokenMaker.VerifyToken(res.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, []string{token.ScopeAccountsRead}, payload.Scopes)
+	require.Equal(t, token.AudienceHTTP, payload.Audience)
+	require.False(t, payload.IsThirdParty())
+}
+
+func TestListLogins(t *testing.T) {
+	user, _ := randomUser()
+	logins := []db.Login{
+		{ID: 2, Username: user.Username, ClientIp: "192.0.2.1", UserAgent: "curl/8.0"},
+		{ID: 1, Username: user.Username, ClientIp: "198.51.100.7", UserAgent: "Mozilla/5.0"},
+	}
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		query         string
+		name          string
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListLogins(gomock.Any(), gomock.Eq(db.ListLoginsParams{Username: user.Username, Limit: 20})).
+					Times(1).
+					Return(logins, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+
+				var res listRes[loginRes]
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				require.Len(t, res.Data, 2)
+				require.Equal(t, "192.0.2.1", res.Data[0].ClientIP)
+				require.Equal(t, "Mozilla/5.0", res.Data[1].UserAgent)
+			},
+		},
+		{
+			name:  "Limit",
+			query: "?limit=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListLogins(gomock.Any(), gomock.Eq(db.ListLoginsParams{Username: user.Username, Limit: 5})).
+					Times(1).
+					Return(logins[:1], nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name:  "InvalidLimit",
+			query: "?limit=100",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListLogins(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+				requireErrorCode(t, w.Body, CodeInvalidArgument)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListLogins(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Login{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+
+			server := newTestServer(t, store)
+			w := httptest.NewRecorder()
+
+			req := httptest.NewRequest(http.MethodGet, "/users/me/logins"+tc.query, nil)
+			addAuthorization(t, server.tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, w)
+		})
+	}
+}
+
 func randomUser() (db.User, string) {
 	return db.User{
 		Username: util.RandomOwner(),
@@ -166,3 +624,238 @@ func randomUser() (db.User, string) {
 		Email:    util.RandomEmail(),
 	}, util.RandomString(6)
 }
+
+func TestGetUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user, _ := randomUser()
+	user.EmailVerified = true
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(user, nil)
+
+	server := newTestServer(t, store)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/users/me", nil)
+	addAuthorization(t, server.tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+	server.router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var res userRes
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Equal(t, newUserRes(user), res)
+}
+
+func TestUpdateUser(t *testing.T) {
+	user, password := randomUser()
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+	user.HashedPassword = hashedPassword
+	user.EmailVerified = true
+
+	newName := util.RandomString(8)
+	newEmail := util.RandomEmail()
+
+	testCases := []struct {
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		body          string
+		name          string
+	}{
+		{
+			name: "FullName",
+			body: fmt.Sprintf(`{"full_name": %q}`, newName),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Eq(db.UpdateUserTxParams{
+						Username:  user.Username,
+						FullName:  sql.NullString{String: newName, Valid: true},
+						ClientIp:  "192.0.2.1",
+						UserAgent: "test-agent",
+					})).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						updated := user
+						updated.FullName = newName
+						return db.UpdateUserTxResult{
+							User:         updated,
+							AuditEntries: []db.UserAuditLog{{Field: "full_name"}},
+						}, nil
+					})
+				store.EXPECT().
+					CreateEmailVerification(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+
+				var res userRes
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				require.Equal(t, newName, res.FullName)
+				require.Equal(t, user.Email, res.Email)
+				require.True(t, res.EmailVerified)
+			},
+		},
+		{
+			name: "Email",
+			body: fmt.Sprintf(`{"email": %q, "current_password": %q}`, newEmail, password),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Eq(db.UpdateUserTxParams{
+						Username:  user.Username,
+						Email:     sql.NullString{String: newEmail, Valid: true},
+						ClientIp:  "192.0.2.1",
+						UserAgent: "test-agent",
+					})).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						updated := user
+						updated.Email = newEmail
+						updated.EmailVerified = false
+						return db.UpdateUserTxResult{
+							User:         updated,
+							AuditEntries: []db.UserAuditLog{{Field: "email"}},
+						}, nil
+					})
+				store.EXPECT().
+					CreateEmailVerification(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateEmailVerificationParams) (db.EmailVerification, error) {
+						require.Equal(t, newEmail, arg.Email)
+						return db.EmailVerification{}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+
+				var res userRes
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				require.Equal(t, newEmail, res.Email)
+				require.False(t, res.EmailVerified)
+			},
+		},
+		{
+			name: "EmailWithoutPassword",
+			body: fmt.Sprintf(`{"email": %q}`, newEmail),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RecordFailedLogin(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+				requireErrorCode(t, w.Body, CodeInvalidCredentials)
+			},
+		},
+		{
+			name: "SameEmailWithoutPassword",
+			body: fmt.Sprintf(`{"email": %q}`, user.Email),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateUserTxResult{User: user}, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			name: "EmailTaken",
+			body: fmt.Sprintf(`{"email": %q, "current_password": %q}`, newEmail, password),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateUserTxResult{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, w.Code)
+				requireErrorCode(t, w.Body, CodeAlreadyExists)
+			},
+		},
+		{
+			name: "NothingToUpdate",
+			body: `{}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+				requireErrorCode(t, w.Body, CodeInvalidArgument)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: `{"email": "not-an-email"}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name: "EmptyFullName",
+			body: `{"full_name": ""}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: fmt.Sprintf(`{"full_name": %q}`, newName),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateUserTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUser(gomock.Any(), gomock.Eq(user.Username)).
+				AnyTimes().
+				Return(user, nil)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPatch, "/users/me", strings.NewReader(tc.body))
+			req.Header.Set("User-Agent", "test-agent")
+			addAuthorization(t, server.tokenMaker, req, authTypeBearer, user.Username, time.Minute)
+
+			server.router.ServeHTTP(w, req)
+			tc.checkResponse(t, w)
+		})
+	}
+}
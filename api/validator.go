@@ -1,7 +1,10 @@
 package api
 
 import (
+	"reflect"
+	"simplebank/token"
 	"simplebank/util"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -13,3 +16,22 @@ var validCurrency validator.Func = func(fl validator.FieldLevel) bool {
 	}
 	return false
 }
+
+var validScope validator.Func = func(fl validator.FieldLevel) bool {
+	if scope, ok := fl.Field().Interface().(string); ok {
+		return token.IsValidScope(scope)
+	}
+	return false
+}
+
+// requestFieldName names fields in validation errors the way clients send them,
+// by their json, form or uri tag instead of the Go field name
+func requestFieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form", "uri"} {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
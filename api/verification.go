@@ -0,0 +1,56 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"simplebank/verification"
+
+	"github.com/gin-gonic/gin"
+)
+
+type verifyEmailReq struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// verifyEmail confirms the address a verification link was sent to, no
+// access token is needed to follow the link
+func (server *Server) verifyEmail(c *gin.Context) {
+	var req verifyEmailReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user, err := server.verifier.Verify(c, req.Token)
+	if err != nil {
+		if errors.Is(err, verification.ErrInvalidToken) {
+			abortWithError(c, http.StatusBadRequest, err)
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newUserRes(user))
+}
+
+// resendVerification emails a new verification link, for users who lost the
+// first one or let it expire
+func (server *Server) resendVerification(c *gin.Context) {
+	user, valid := server.authUser(c)
+	if !valid {
+		return
+	}
+
+	err := server.verifier.Send(c, user)
+	if err != nil {
+		if errors.Is(err, verification.ErrAlreadyVerified) {
+			abortWithError(c, http.StatusConflict, err)
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
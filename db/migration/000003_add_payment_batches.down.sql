@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "payment_batches";
\ No newline at end of file
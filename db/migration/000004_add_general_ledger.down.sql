@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS "journal_lines";
+
+DROP TABLE IF EXISTS "journal_entries";
+
+DROP TABLE IF EXISTS "ledger_accounts";
+
+DROP FUNCTION IF EXISTS reject_journal_change();
+
+DROP FUNCTION IF EXISTS check_journal_entry_balanced();
+
+DROP TYPE IF EXISTS "ledger_account_type";
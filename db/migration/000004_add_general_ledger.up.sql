@@ -0,0 +1,156 @@
+CREATE TYPE "ledger_account_type" AS ENUM (
+  'asset',
+  'liability',
+  'income',
+  'expense'
+);
+
+CREATE TABLE "ledger_accounts" (
+  "id" bigserial PRIMARY KEY,
+  "code" varchar UNIQUE NOT NULL,
+  "name" varchar NOT NULL,
+  "type" ledger_account_type NOT NULL,
+  "currency" varchar NOT NULL,
+  "account_id" bigint UNIQUE,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "journal_entries" (
+  "id" bigserial PRIMARY KEY,
+  "description" varchar NOT NULL,
+  "transfer_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "journal_lines" (
+  "id" bigserial PRIMARY KEY,
+  "journal_entry_id" bigint NOT NULL,
+  "ledger_account_id" bigint NOT NULL,
+  "debit" bigint NOT NULL DEFAULT 0,
+  "credit" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "journal_entries" ("transfer_id");
+
+CREATE INDEX ON "journal_lines" ("journal_entry_id");
+
+CREATE INDEX ON "journal_lines" ("ledger_account_id");
+
+COMMENT ON COLUMN "ledger_accounts"."account_id" IS 'customer account this ledger account is the deposit liability of';
+
+COMMENT ON COLUMN "journal_lines"."debit" IS 'exactly one of debit and credit is positive';
+
+ALTER TABLE "ledger_accounts" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "journal_entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "journal_lines" ADD FOREIGN KEY ("journal_entry_id") REFERENCES "journal_entries" ("id");
+
+ALTER TABLE "journal_lines" ADD FOREIGN KEY ("ledger_account_id") REFERENCES "ledger_accounts" ("id");
+
+ALTER TABLE "journal_lines" ADD CONSTRAINT "debit_or_credit" CHECK (
+  "debit" >= 0 AND "credit" >= 0 AND ("debit" = 0) <> ("credit" = 0)
+);
+
+-- A journal entry must have at least two lines and its debits must equal its credits
+-- in every currency. The check runs at commit so the lines can be inserted one by one.
+CREATE FUNCTION check_journal_entry_balanced() RETURNS trigger AS $$
+DECLARE
+  entry_id bigint;
+BEGIN
+  IF TG_TABLE_NAME = 'journal_entries' THEN
+    entry_id := NEW.id;
+  ELSE
+    entry_id := NEW.journal_entry_id;
+  END IF;
+
+  IF (SELECT count(*) FROM journal_lines WHERE journal_entry_id = entry_id) < 2 THEN
+    RAISE EXCEPTION 'journal entry % must have at least two lines', entry_id
+      USING ERRCODE = 'check_violation';
+  END IF;
+
+  IF EXISTS (
+    SELECT 1
+    FROM journal_lines l
+    JOIN ledger_accounts a ON a.id = l.ledger_account_id
+    WHERE l.journal_entry_id = entry_id
+    GROUP BY a.currency
+    HAVING sum(l.debit) <> sum(l.credit)
+  ) THEN
+    RAISE EXCEPTION 'journal entry % is not balanced', entry_id
+      USING ERRCODE = 'check_violation';
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER "journal_entries_balanced"
+  AFTER INSERT ON "journal_entries"
+  DEFERRABLE INITIALLY DEFERRED
+  FOR EACH ROW EXECUTE PROCEDURE check_journal_entry_balanced();
+
+CREATE CONSTRAINT TRIGGER "journal_lines_balanced"
+  AFTER INSERT ON "journal_lines"
+  DEFERRABLE INITIALLY DEFERRED
+  FOR EACH ROW EXECUTE PROCEDURE check_journal_entry_balanced();
+
+-- Posted journal entries are never changed, corrections are posted as new entries
+CREATE FUNCTION reject_journal_change() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION '% is append-only', TG_TABLE_NAME
+    USING ERRCODE = 'restrict_violation';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "journal_entries_append_only"
+  BEFORE UPDATE OR DELETE ON "journal_entries"
+  FOR EACH ROW EXECUTE PROCEDURE reject_journal_change();
+
+CREATE TRIGGER "journal_lines_append_only"
+  BEFORE UPDATE OR DELETE ON "journal_lines"
+  FOR EACH ROW EXECUTE PROCEDURE reject_journal_change();
+
+-- Chart of accounts
+INSERT INTO "ledger_accounts" ("code", "name", "type", "currency") VALUES
+  ('1000-USD', 'Cash USD', 'asset', 'USD'),
+  ('1000-EUR', 'Cash EUR', 'asset', 'EUR'),
+  ('1000-TWD', 'Cash TWD', 'asset', 'TWD'),
+  ('4000-USD', 'Fee income USD', 'income', 'USD'),
+  ('4000-EUR', 'Fee income EUR', 'income', 'EUR'),
+  ('4000-TWD', 'Fee income TWD', 'income', 'TWD'),
+  ('5000-USD', 'Operating expenses USD', 'expense', 'USD'),
+  ('5000-EUR', 'Operating expenses EUR', 'expense', 'EUR'),
+  ('5000-TWD', 'Operating expenses TWD', 'expense', 'TWD');
+
+-- Every existing customer account gets its deposit liability account,
+-- its current balance is posted as an opening entry against cash
+INSERT INTO "ledger_accounts" ("code", "name", "type", "currency", "account_id")
+SELECT '2100-' || "id", 'Customer deposits ' || "id", 'liability', "currency", "id"
+FROM "accounts";
+
+DO $$
+DECLARE
+  account record;
+  entry_id bigint;
+BEGIN
+  FOR account IN SELECT "id", "balance", "currency" FROM "accounts" WHERE "balance" <> 0 ORDER BY "id" LOOP
+    INSERT INTO "ledger_accounts" ("code", "name", "type", "currency")
+    VALUES ('1000-' || account.currency, 'Cash ' || account.currency, 'asset', account.currency)
+    ON CONFLICT ("code") DO NOTHING;
+
+    INSERT INTO "journal_entries" ("description")
+    VALUES ('Opening balance of account ' || account.id)
+    RETURNING "id" INTO entry_id;
+
+    INSERT INTO "journal_lines" ("journal_entry_id", "ledger_account_id", "debit", "credit")
+    SELECT entry_id, "id", greatest(account.balance, 0), greatest(-account.balance, 0)
+    FROM "ledger_accounts" WHERE "code" = '1000-' || account.currency;
+
+    INSERT INTO "journal_lines" ("journal_entry_id", "ledger_account_id", "debit", "credit")
+    SELECT entry_id, "id", greatest(-account.balance, 0), greatest(account.balance, 0)
+    FROM "ledger_accounts" WHERE "account_id" = account.id;
+  END LOOP;
+END;
+$$;
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS "journal_entries_open_day" ON "journal_entries";
+
+DROP FUNCTION IF EXISTS reject_closed_day_posting();
+
+DROP TABLE IF EXISTS "ledger_balances";
+
+DROP TABLE IF EXISTS "daily_closes";
+
+ALTER TABLE IF EXISTS "journal_entries" DROP COLUMN IF EXISTS "booking_date";
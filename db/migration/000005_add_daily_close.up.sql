@@ -0,0 +1,60 @@
+ALTER TABLE "journal_entries" ADD COLUMN "booking_date" date NOT NULL DEFAULT ((now() AT TIME ZONE 'UTC')::date);
+
+-- existing entries are booked on the day they were created
+ALTER TABLE "journal_entries" DISABLE TRIGGER "journal_entries_append_only";
+UPDATE "journal_entries" SET "booking_date" = ("created_at" AT TIME ZONE 'UTC')::date;
+ALTER TABLE "journal_entries" ENABLE TRIGGER "journal_entries_append_only";
+
+CREATE INDEX ON "journal_entries" ("booking_date");
+
+CREATE TABLE "daily_closes" (
+  "business_date" date PRIMARY KEY,
+  "previous_date" date,
+  "signature" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "ledger_balances" (
+  "business_date" date NOT NULL,
+  "ledger_account_id" bigint NOT NULL,
+  "opening" bigint NOT NULL,
+  "debits" bigint NOT NULL,
+  "credits" bigint NOT NULL,
+  "closing" bigint NOT NULL,
+  PRIMARY KEY ("business_date", "ledger_account_id")
+);
+
+COMMENT ON COLUMN "daily_closes"."signature" IS 'HMAC-SHA256 of the trial balance of the day';
+
+COMMENT ON COLUMN "ledger_balances"."closing" IS 'debit balance, negative for a credit balance';
+
+ALTER TABLE "daily_closes" ADD FOREIGN KEY ("previous_date") REFERENCES "daily_closes" ("business_date");
+
+ALTER TABLE "ledger_balances" ADD FOREIGN KEY ("business_date") REFERENCES "daily_closes" ("business_date") DEFERRABLE INITIALLY DEFERRED;
+
+ALTER TABLE "ledger_balances" ADD FOREIGN KEY ("ledger_account_id") REFERENCES "ledger_accounts" ("id");
+
+-- Nothing can be booked into a day that has been closed
+CREATE FUNCTION reject_closed_day_posting() RETURNS trigger AS $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM daily_closes WHERE business_date >= NEW.booking_date) THEN
+    RAISE EXCEPTION 'business date % is closed', NEW.booking_date
+      USING ERRCODE = 'check_violation', CONSTRAINT = 'journal_entries_open_day';
+  END IF;
+
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "journal_entries_open_day"
+  BEFORE INSERT ON "journal_entries"
+  FOR EACH ROW EXECUTE PROCEDURE reject_closed_day_posting();
+
+-- Closes and their balances are final
+CREATE TRIGGER "daily_closes_append_only"
+  BEFORE UPDATE OR DELETE ON "daily_closes"
+  FOR EACH ROW EXECUTE PROCEDURE reject_journal_change();
+
+CREATE TRIGGER "ledger_balances_append_only"
+  BEFORE UPDATE OR DELETE ON "ledger_balances"
+  FOR EACH ROW EXECUTE PROCEDURE reject_journal_change();
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "logins";
+
+ALTER TABLE "users"
+  DROP COLUMN IF EXISTS "locked_until",
+  DROP COLUMN IF EXISTS "failed_login_attempts";
@@ -0,0 +1,16 @@
+ALTER TABLE "users"
+  ADD COLUMN "failed_login_attempts" integer NOT NULL DEFAULT 0,
+  ADD COLUMN "locked_until" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z';
+
+-- successful sign-ins, users review them to spot logins they didn't make
+CREATE TABLE "logins" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "client_ip" varchar NOT NULL,
+  "user_agent" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "logins" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "logins" ("username", "id");
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS "recovery_codes";
+
+ALTER TABLE "users"
+  DROP COLUMN IF EXISTS "totp_last_step",
+  DROP COLUMN IF EXISTS "totp_enabled",
+  DROP COLUMN IF EXISTS "totp_secret";
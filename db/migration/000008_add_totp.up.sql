@@ -0,0 +1,16 @@
+ALTER TABLE "users"
+  -- encrypted with TOTP_ENCRYPTION_KEY, set from enrollment on but only
+  -- required at login once confirmed
+  ADD COLUMN "totp_secret" varchar NOT NULL DEFAULT '',
+  ADD COLUMN "totp_enabled" boolean NOT NULL DEFAULT false,
+  -- the last time step a code was accepted for, codes of it or before are refused
+  ADD COLUMN "totp_last_step" bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE "recovery_codes" (
+  "username" varchar NOT NULL,
+  "code_hash" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("username", "code_hash")
+);
+
+ALTER TABLE "recovery_codes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
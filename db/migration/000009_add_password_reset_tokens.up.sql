@@ -0,0 +1,11 @@
+-- single-use tokens of the password reset emails, only their SHA-256 is kept
+CREATE TABLE "password_reset_tokens" (
+  "token_hash" varchar PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "password_reset_tokens" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "password_reset_tokens" ("username");
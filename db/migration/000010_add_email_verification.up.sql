@@ -0,0 +1,18 @@
+ALTER TABLE "users" ADD COLUMN "email_verified" boolean NOT NULL DEFAULT false;
+
+-- users from before verification existed keep using their accounts
+UPDATE "users" SET "email_verified" = true;
+
+-- single-use tokens of the verification emails, only their SHA-256 is kept.
+-- The address is kept too, a link stops working once the user changed it.
+CREATE TABLE "email_verifications" (
+  "token_hash" varchar PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "email" varchar NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "email_verifications" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "email_verifications" ("username");
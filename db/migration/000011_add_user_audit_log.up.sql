@@ -0,0 +1,15 @@
+-- one row per changed profile field, kept for support and fraud reviews
+CREATE TABLE "user_audit_log" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "field" varchar NOT NULL,
+  "old_value" varchar NOT NULL,
+  "new_value" varchar NOT NULL,
+  "client_ip" varchar NOT NULL,
+  "user_agent" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "user_audit_log" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "user_audit_log" ("username", "id");
@@ -0,0 +1,19 @@
+-- what a user lets a third-party app read, open banking style: which accounts,
+-- which permissions and until when. The latest consent that is neither
+-- expired nor revoked applies to every token of the client for the user.
+CREATE TABLE "consents" (
+  "id" bigserial PRIMARY KEY,
+  "client_id" varchar NOT NULL,
+  "username" varchar NOT NULL,
+  "account_ids" bigint[] NOT NULL,
+  "permissions" varchar[] NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "revoked_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "consents" ADD FOREIGN KEY ("client_id") REFERENCES "oauth_clients" ("id");
+
+ALTER TABLE "consents" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "consents" ("username", "client_id");
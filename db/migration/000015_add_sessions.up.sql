@@ -0,0 +1,16 @@
+-- the device a login signed in from, one session per access token of a login
+-- with the id of the token. Revoking a session revokes its token.
+CREATE TABLE "sessions" (
+  "id" uuid PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "client_ip" varchar NOT NULL,
+  "user_agent" varchar NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "last_seen_at" timestamptz NOT NULL DEFAULT (now()),
+  "revoked_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "sessions" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "sessions" ("username", "expires_at");
@@ -0,0 +1,31 @@
+package migration
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestVersion(t *testing.T) {
+	version, err := LatestVersion(FS)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, version, int64(5))
+
+	version, err = LatestVersion(fstest.MapFS{
+		"000001_init_schema.up.sql":   {},
+		"000001_init_schema.down.sql": {},
+		"000012_add_index.up.sql":     {},
+		"000012_add_index.down.sql":   {},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(12), version)
+}
+
+func TestLatestVersionInvalid(t *testing.T) {
+	_, err := LatestVersion(fstest.MapFS{})
+	require.EqualError(t, err, "no up migrations found")
+
+	_, err = LatestVersion(fstest.MapFS{"init.up.sql": {}})
+	require.EqualError(t, err, `invalid migration file name "init.up.sql"`)
+}
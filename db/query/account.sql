@@ -17,11 +17,20 @@ WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE;
 
 -- name: ListAccounts :many
+-- account_ids narrows the list down to the accounts of a consent, NULL lists
+-- them all
 SELECT * FROM accounts
-WHERE owner = $1
+WHERE owner = sqlc.arg(owner) AND id > sqlc.arg(after_id)
+  AND (sqlc.narg(account_ids)::bigint[] IS NULL OR id = ANY(sqlc.narg(account_ids)::bigint[]))
 ORDER BY id
-LIMIT $2
-OFFSET $3;
+LIMIT sqlc.arg('limit');
+
+-- name: ListAccountsBefore :many
+SELECT * FROM accounts
+WHERE owner = sqlc.arg(owner) AND id < sqlc.arg(before_id)
+  AND (sqlc.narg(account_ids)::bigint[] IS NULL OR id = ANY(sqlc.narg(account_ids)::bigint[]))
+ORDER BY id DESC
+LIMIT sqlc.arg('limit');
 
 -- name: UpdateAccount :one
 UPDATE accounts 
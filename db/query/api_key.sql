@@ -0,0 +1,33 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+  username,
+  name,
+  prefix,
+  key_hash,
+  scopes,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetAPIKeyByHash :one
+SELECT * FROM api_keys
+WHERE key_hash = $1 LIMIT 1;
+
+-- name: ListAPIKeys :many
+SELECT * FROM api_keys
+WHERE username = $1 AND revoked_at = '0001-01-01 00:00:00Z'
+ORDER BY id DESC;
+
+-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET revoked_at = now()
+WHERE id = $1 AND username = $2 AND revoked_at = '0001-01-01 00:00:00Z'
+RETURNING *;
+
+-- name: TouchAPIKey :exec
+-- last_used_at is only kept to the minute, a busy key doesn't write on every
+-- request
+UPDATE api_keys
+SET last_used_at = now()
+WHERE id = $1 AND last_used_at < now() - interval '1 minute';
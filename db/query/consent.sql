@@ -0,0 +1,28 @@
+-- name: CreateConsent :one
+INSERT INTO consents (
+  client_id,
+  username,
+  account_ids,
+  permissions,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetActiveConsent :one
+SELECT * FROM consents
+WHERE username = $1 AND client_id = $2
+  AND revoked_at = '0001-01-01 00:00:00Z' AND expires_at > now()
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: ListConsents :many
+SELECT * FROM consents
+WHERE username = $1 AND revoked_at = '0001-01-01 00:00:00Z'
+ORDER BY id DESC;
+
+-- name: RevokeConsent :one
+UPDATE consents
+SET revoked_at = now()
+WHERE id = $1 AND username = $2 AND revoked_at = '0001-01-01 00:00:00Z'
+RETURNING *;
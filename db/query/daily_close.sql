@@ -0,0 +1,69 @@
+-- name: GetLatestDailyClose :one
+SELECT * FROM daily_closes
+ORDER BY business_date DESC
+LIMIT 1;
+
+-- name: GetDailyClose :one
+SELECT * FROM daily_closes
+WHERE business_date = $1 LIMIT 1;
+
+-- name: CreateDailyClose :one
+INSERT INTO daily_closes (
+  business_date,
+  previous_date,
+  signature
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: RollLedgerBalances :many
+INSERT INTO ledger_balances (
+  business_date,
+  ledger_account_id,
+  opening,
+  debits,
+  credits,
+  closing
+)
+SELECT
+  sqlc.arg(business_date)::date,
+  a.id,
+  COALESCE(p.closing, 0),
+  COALESCE(m.debits, 0),
+  COALESCE(m.credits, 0),
+  COALESCE(p.closing, 0) + COALESCE(m.debits, 0) - COALESCE(m.credits, 0)
+FROM ledger_accounts a
+LEFT JOIN ledger_balances p
+  ON p.ledger_account_id = a.id AND p.business_date = sqlc.narg(previous_date)::date
+LEFT JOIN (
+  SELECT
+    l.ledger_account_id,
+    sum(l.debit)::bigint AS debits,
+    sum(l.credit)::bigint AS credits
+  FROM journal_lines l
+  JOIN journal_entries e ON e.id = l.journal_entry_id
+  WHERE
+    (sqlc.narg(previous_date)::date IS NULL OR e.booking_date > sqlc.narg(previous_date)::date) AND
+    e.booking_date <= sqlc.arg(business_date)::date
+  GROUP BY l.ledger_account_id
+) m ON m.ledger_account_id = a.id
+ORDER BY a.id
+RETURNING *;
+
+-- name: ListLedgerBalances :many
+SELECT * FROM ledger_balances
+WHERE business_date = $1
+ORDER BY ledger_account_id;
+
+-- name: GetTrialBalance :many
+SELECT
+  a.currency,
+  sum(b.debits)::bigint AS debits,
+  sum(b.credits)::bigint AS credits,
+  sum(greatest(b.closing, 0))::bigint AS debit_balances,
+  sum(greatest(-b.closing, 0))::bigint AS credit_balances
+FROM ledger_balances b
+JOIN ledger_accounts a ON a.id = b.ledger_account_id
+WHERE b.business_date = $1
+GROUP BY a.currency
+ORDER BY a.currency;
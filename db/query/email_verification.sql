@@ -0,0 +1,25 @@
+-- name: CreateEmailVerification :one
+INSERT INTO email_verifications (
+  token_hash,
+  username,
+  email,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: UseEmailVerification :one
+DELETE FROM email_verifications
+WHERE token_hash = $1 AND expires_at > now()
+RETURNING *;
+
+-- name: DeleteEmailVerifications :exec
+DELETE FROM email_verifications
+WHERE username = $1;
+
+-- name: VerifyUserEmail :one
+-- Only verifies the address the email was sent to
+UPDATE users
+SET email_verified = true
+WHERE username = $1 AND email = $2
+RETURNING *;
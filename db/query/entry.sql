@@ -12,7 +12,30 @@ WHERE id = $1 LIMIT 1;
 
 -- name: ListEntries :many
 SELECT * FROM entries
-WHERE account_id = $1
+WHERE account_id = sqlc.arg(account_id) AND id > sqlc.arg(after_id)
 ORDER BY id
-LIMIT $2
-OFFSET $3;
\ No newline at end of file
+LIMIT sqlc.arg('limit');
+
+-- name: ListEntriesBefore :many
+SELECT * FROM entries
+WHERE account_id = sqlc.arg(account_id) AND id < sqlc.arg(before_id)
+ORDER BY id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListEntriesInPeriod :many
+SELECT * FROM entries
+WHERE
+  account_id = sqlc.arg(account_id) AND
+  created_at >= sqlc.arg(from_time) AND
+  created_at < sqlc.arg(to_time)
+ORDER BY id;
+
+-- name: GetBalanceAt :one
+-- the balance of the account at from_time, its balance less the entries booked
+-- since, both read in the one snapshot of the statement
+SELECT (a.balance - COALESCE((
+  SELECT SUM(e.amount) FROM entries e
+  WHERE e.account_id = a.id AND e.created_at >= sqlc.arg(from_time)
+), 0))::bigint AS balance
+FROM accounts a
+WHERE a.id = sqlc.arg(account_id);
@@ -0,0 +1,67 @@
+-- name: CreateLedgerAccount :one
+INSERT INTO ledger_accounts (
+  code,
+  name,
+  type,
+  currency,
+  account_id
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetLedgerAccount :one
+SELECT * FROM ledger_accounts
+WHERE id = $1 LIMIT 1;
+
+-- name: GetLedgerAccountByCode :one
+SELECT * FROM ledger_accounts
+WHERE code = $1 LIMIT 1;
+
+-- name: GetLedgerAccountByAccountID :one
+SELECT * FROM ledger_accounts
+WHERE account_id = $1 LIMIT 1;
+
+-- name: ListLedgerAccounts :many
+SELECT * FROM ledger_accounts
+ORDER BY code;
+
+-- name: CreateJournalEntry :one
+INSERT INTO journal_entries (
+  description,
+  transfer_id,
+  booking_date
+) VALUES (
+  sqlc.arg(description),
+  sqlc.arg(transfer_id),
+  COALESCE(sqlc.narg(booking_date)::date, (now() AT TIME ZONE 'UTC')::date)
+) RETURNING *;
+
+-- name: GetJournalEntry :one
+SELECT * FROM journal_entries
+WHERE id = $1 LIMIT 1;
+
+-- name: GetJournalEntryByTransfer :one
+SELECT * FROM journal_entries
+WHERE transfer_id = $1 LIMIT 1;
+
+-- name: CreateJournalLine :one
+INSERT INTO journal_lines (
+  journal_entry_id,
+  ledger_account_id,
+  debit,
+  credit
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListJournalLines :many
+SELECT * FROM journal_lines
+WHERE journal_entry_id = $1
+ORDER BY id;
+
+-- name: GetLedgerAccountTotals :one
+SELECT
+  COALESCE(sum(debit), 0)::bigint AS debits,
+  COALESCE(sum(credit), 0)::bigint AS credits
+FROM journal_lines
+WHERE ledger_account_id = $1;
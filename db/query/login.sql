@@ -0,0 +1,14 @@
+-- name: CreateLogin :one
+INSERT INTO logins (
+  username,
+  client_ip,
+  user_agent
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: ListLogins :many
+SELECT * FROM logins
+WHERE username = $1
+ORDER BY id DESC
+LIMIT $2;
@@ -0,0 +1,7 @@
+-- name: CreatePaymentBatch :one
+INSERT INTO payment_batches (
+  owner,
+  message_id
+) VALUES (
+  $1, $2
+) RETURNING *;
\ No newline at end of file
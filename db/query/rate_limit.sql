@@ -0,0 +1,25 @@
+-- name: TakeRateLimitToken :one
+-- refills the bucket for the time since its last update and takes cost
+-- tokens, returns no row when fewer are left
+INSERT INTO rate_limit_buckets AS b (
+  key,
+  tokens,
+  updated_at
+) VALUES (
+  sqlc.arg(key), sqlc.arg(burst)::float8 - sqlc.arg(cost)::float8, now()
+)
+ON CONFLICT (key) DO UPDATE SET
+  tokens = LEAST(sqlc.arg(burst)::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * sqlc.arg(rate)::float8) - sqlc.arg(cost)::float8,
+  updated_at = now()
+WHERE LEAST(sqlc.arg(burst)::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * sqlc.arg(rate)::float8) >= sqlc.arg(cost)::float8
+RETURNING tokens;
+
+-- name: GetRateLimitTokens :one
+-- returns the tokens of the bucket refilled up to now, without taking any
+SELECT LEAST(sqlc.arg(burst)::float8, tokens + EXTRACT(EPOCH FROM now() - updated_at)::float8 * sqlc.arg(rate)::float8)::float8 AS tokens
+FROM rate_limit_buckets
+WHERE key = sqlc.arg(key);
+
+-- name: DeleteRateLimitBucketsBefore :exec
+DELETE FROM rate_limit_buckets
+WHERE updated_at < sqlc.arg(updated_before);
@@ -0,0 +1,42 @@
+-- name: CreateSession :one
+-- created_at is the issue time of the token, like password_changed_at it
+-- comes from the clock of the app
+INSERT INTO sessions (
+  id,
+  username,
+  client_ip,
+  user_agent,
+  expires_at,
+  created_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetSession :one
+SELECT * FROM sessions
+WHERE id = $1 LIMIT 1;
+
+-- name: ListSessions :many
+-- sessions a token can still be used in: not revoked, not expired and not
+-- opened before the last password change, the check the token of the session
+-- gets on every request
+SELECT sessions.* FROM sessions
+JOIN users ON users.username = sessions.username
+WHERE sessions.username = $1
+  AND sessions.revoked_at = '0001-01-01 00:00:00Z'
+  AND sessions.expires_at > now()
+  AND sessions.created_at >= users.password_changed_at
+ORDER BY sessions.last_seen_at DESC;
+
+-- name: RevokeSession :one
+UPDATE sessions
+SET revoked_at = now()
+WHERE id = $1 AND username = $2 AND revoked_at = '0001-01-01 00:00:00Z'
+RETURNING *;
+
+-- name: TouchSession :exec
+-- last_seen_at is only kept to the minute, a busy session doesn't write on
+-- every request
+UPDATE sessions
+SET last_seen_at = now()
+WHERE id = $1 AND last_seen_at < now() - interval '1 minute';
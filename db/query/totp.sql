@@ -0,0 +1,38 @@
+-- name: SetTOTPSecret :one
+UPDATE users
+SET totp_secret = $2
+WHERE username = $1 AND NOT totp_enabled
+RETURNING *;
+
+-- name: EnableTOTP :one
+UPDATE users
+SET totp_enabled = true, totp_last_step = $2
+WHERE username = $1
+RETURNING *;
+
+-- name: UseTOTPStep :execrows
+-- Accepts the step of a code only once, concurrent requests with the same code
+-- can't both succeed
+UPDATE users
+SET totp_last_step = $2
+WHERE username = $1 AND totp_last_step < $2;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (
+  username,
+  code_hash
+) VALUES (
+  $1, $2
+);
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE username = $1;
+
+-- name: UseRecoveryCode :execrows
+DELETE FROM recovery_codes
+WHERE username = $1 AND code_hash = $2;
+
+-- name: CountRecoveryCodes :one
+SELECT count(*) FROM recovery_codes
+WHERE username = $1;
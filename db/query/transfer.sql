@@ -14,8 +14,26 @@ WHERE id = $1 LIMIT 1;
 -- name: ListTransfers :many
 SELECT * FROM transfers
 WHERE
-  to_account_id = $1 OR
-  from_account_id = $2
+  (to_account_id = sqlc.arg(to_account_id) OR
+  from_account_id = sqlc.arg(from_account_id)) AND
+  id > sqlc.arg(after_id)
 ORDER BY id
-LIMIT $3
-OFFSET $4;
\ No newline at end of file
+LIMIT sqlc.arg('limit');
+
+-- name: ListTransfersBefore :many
+SELECT * FROM transfers
+WHERE
+  (to_account_id = sqlc.arg(to_account_id) OR
+  from_account_id = sqlc.arg(from_account_id)) AND
+  id < sqlc.arg(before_id)
+ORDER BY id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListTransfersInPeriod :many
+SELECT * FROM transfers
+WHERE
+  (to_account_id = sqlc.arg(account_id) OR
+  from_account_id = sqlc.arg(account_id)) AND
+  created_at >= sqlc.arg(from_time) AND
+  created_at < sqlc.arg(to_time)
+ORDER BY id;
@@ -10,4 +10,60 @@ INSERT INTO users (
 
 -- name: GetUser :one
 SELECT * FROM users
-WHERE username = $1 LIMIT 1;
\ No newline at end of file
+WHERE username = $1 LIMIT 1;
+
+-- name: RecordFailedLogin :one
+-- Counts a wrong password. From max_attempts failures on the user is locked,
+-- for lockout_seconds doubled with each further failure, up to max_lockout_seconds.
+-- A max_attempts of 0 never locks.
+UPDATE users
+SET
+  failed_login_attempts = failed_login_attempts + 1,
+  locked_until = CASE
+    WHEN sqlc.arg(max_attempts)::int > 0 AND failed_login_attempts + 1 >= sqlc.arg(max_attempts)::int
+    THEN now() + least(
+      sqlc.arg(lockout_seconds)::float8 * power(2, least(failed_login_attempts + 1 - sqlc.arg(max_attempts)::int, 30)),
+      sqlc.arg(max_lockout_seconds)::float8
+    ) * interval '1 second'
+    ELSE locked_until
+  END
+WHERE username = sqlc.arg(username)
+RETURNING *;
+
+-- name: ResetFailedLogins :exec
+UPDATE users
+SET failed_login_attempts = 0, locked_until = '0001-01-01 00:00:00Z'
+WHERE username = $1;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 LIMIT 1;
+
+-- name: UpdateUserPassword :one
+UPDATE users
+SET hashed_password = $2, password_changed_at = $3
+WHERE username = $1
+RETURNING *;
+
+-- name: RehashUserPassword :exec
+-- Stores a new hash of the same password, the tokens of the user stay valid.
+-- A password changed meanwhile is left alone.
+UPDATE users
+SET hashed_password = sqlc.arg(new_hash)
+WHERE username = sqlc.arg(username) AND hashed_password = sqlc.arg(old_hash);
+
+-- name: GetUserForUpdate :one
+SELECT * FROM users
+WHERE username = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: UpdateUser :one
+-- Sets the fields that are not null. A new email address has to be verified
+-- again.
+UPDATE users
+SET
+  full_name = coalesce(sqlc.narg(full_name), full_name),
+  email = coalesce(sqlc.narg(email), email),
+  email_verified = email_verified AND coalesce(sqlc.narg(email) = email, true)
+WHERE username = sqlc.arg(username)
+RETURNING *;
@@ -0,0 +1,17 @@
+-- name: CreateUserAuditEntry :one
+INSERT INTO user_audit_log (
+  username,
+  field,
+  old_value,
+  new_value,
+  client_ip,
+  user_agent
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: ListUserAuditEntries :many
+SELECT * FROM user_audit_log
+WHERE username = $1
+ORDER BY id DESC
+LIMIT $2;
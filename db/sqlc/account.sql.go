@@ -7,6 +7,8 @@ package db
 
 import (
 	"context"
+
+	"github.com/lib/pq"
 )
 
 const addAccountBalancd = `-- name: AddAccountBalancd :one
@@ -112,20 +114,77 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 
 const listAccounts = `-- name: ListAccounts :many
 SELECT id, owner, balance, currency, created_at FROM accounts
-WHERE owner = $1
+WHERE owner = $1 AND id > $2
+  AND ($3::bigint[] IS NULL OR id = ANY($3::bigint[]))
 ORDER BY id
-LIMIT $2
-OFFSET $3
+LIMIT $4
 `
 
 type ListAccountsParams struct {
-	Owner  string `json:"owner"`
-	Limit  int32  `json:"limit"`
-	Offset int32  `json:"offset"`
+	Owner      string  `json:"owner"`
+	AfterID    int64   `json:"after_id"`
+	AccountIds []int64 `json:"account_ids"`
+	Limit      int32   `json:"limit"`
 }
 
+// account_ids narrows the list down to the accounts of a consent, NULL lists
+// them all
 func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
-	rows, err := q.db.QueryContext(ctx, listAccounts, arg.Owner, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listAccounts,
+		arg.Owner,
+		arg.AfterID,
+		pq.Array(arg.AccountIds),
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsBefore = `-- name: ListAccountsBefore :many
+SELECT id, owner, balance, currency, created_at FROM accounts
+WHERE owner = $1 AND id < $2
+  AND ($3::bigint[] IS NULL OR id = ANY($3::bigint[]))
+ORDER BY id DESC
+LIMIT $4
+`
+
+type ListAccountsBeforeParams struct {
+	Owner      string  `json:"owner"`
+	BeforeID   int64   `json:"before_id"`
+	AccountIds []int64 `json:"account_ids"`
+	Limit      int32   `json:"limit"`
+}
+
+func (q *Queries) ListAccountsBefore(ctx context.Context, arg ListAccountsBeforeParams) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsBefore,
+		arg.Owner,
+		arg.BeforeID,
+		pq.Array(arg.AccountIds),
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
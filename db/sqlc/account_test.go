@@ -10,11 +10,15 @@ import (
 )
 
 func createRandomAccount(t *testing.T) Account {
+	return createRandomAccountInCurrency(t, util.RandomCurrency())
+}
+
+func createRandomAccountInCurrency(t *testing.T, currency string) Account {
 	user := createRandomUser(t)
 	arg := CreateAccountParams{
 		Owner:    user.Username,
 		Balance:  util.RandomBalance(),
-		Currency: util.RandomCurrency(),
+		Currency: currency,
 	}
 
 	account, err := testQueries.CreateAccount(context.Background(), arg)
@@ -57,9 +61,9 @@ func TestListAccounts(t *testing.T) {
 	}
 
 	arg := ListAccountsParams{
-		Owner:  lastAccount.Owner,
-		Limit:  5,
-		Offset: 0,
+		Owner:   lastAccount.Owner,
+		AfterID: 0,
+		Limit:   5,
 	}
 
 	accounts, err := testQueries.ListAccounts(context.Background(), arg)
@@ -72,6 +76,34 @@ func TestListAccounts(t *testing.T) {
 	}
 }
 
+func TestListAccountsByIDs(t *testing.T) {
+	account1 := createRandomAccountInCurrency(t, util.USD)
+	account2, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    account1.Owner,
+		Currency: util.EUR,
+	})
+	require.NoError(t, err)
+
+	accounts, err := testQueries.ListAccounts(context.Background(), ListAccountsParams{
+		Owner:      account1.Owner,
+		AccountIds: []int64{account2.ID},
+		Limit:      5,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account2.ID, accounts[0].ID)
+
+	accounts, err = testQueries.ListAccountsBefore(context.Background(), ListAccountsBeforeParams{
+		Owner:      account1.Owner,
+		BeforeID:   account2.ID + 1,
+		AccountIds: []int64{account1.ID},
+		Limit:      5,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account1.ID, accounts[0].ID)
+}
+
 func TestUpdateAccount(t *testing.T) {
 	account1 := createRandomAccount(t)
 	arg := UpdateAccountParams{
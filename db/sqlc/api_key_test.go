@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAPIKey(t *testing.T, user User) ApiKey {
+	arg := CreateAPIKeyParams{
+		Username:  user.Username,
+		Name:      util.RandomOwner(),
+		Prefix:    "sbk_" + util.RandomString(8),
+		KeyHash:   util.RandomString(64),
+		Scopes:    []string{"accounts:read", "transfers:write"},
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	apiKey, err := testQueries.CreateAPIKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, apiKey.Username)
+	require.Equal(t, arg.Name, apiKey.Name)
+	require.Equal(t, arg.Prefix, apiKey.Prefix)
+	require.Equal(t, arg.KeyHash, apiKey.KeyHash)
+	require.Equal(t, arg.Scopes, apiKey.Scopes)
+	require.WithinDuration(t, arg.ExpiresAt, apiKey.ExpiresAt, time.Second)
+	require.True(t, apiKey.LastUsedAt.IsZero())
+	require.True(t, apiKey.RevokedAt.IsZero())
+	return apiKey
+}
+
+func TestGetAPIKeyByHash(t *testing.T) {
+	apiKey1 := createRandomAPIKey(t, createRandomUser(t))
+
+	apiKey2, err := testQueries.GetAPIKeyByHash(context.Background(), apiKey1.KeyHash)
+	require.NoError(t, err)
+	require.Equal(t, apiKey1.ID, apiKey2.ID)
+	require.Equal(t, apiKey1.Scopes, apiKey2.Scopes)
+
+	_, err = testQueries.GetAPIKeyByHash(context.Background(), util.RandomString(64))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	user := createRandomUser(t)
+	apiKey1 := createRandomAPIKey(t, user)
+	apiKey2 := createRandomAPIKey(t, user)
+
+	// only the owner can revoke a key
+	_, err := testQueries.RevokeAPIKey(context.Background(), RevokeAPIKeyParams{
+		ID:       apiKey1.ID,
+		Username: createRandomUser(t).Username,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	revoked, err := testQueries.RevokeAPIKey(context.Background(), RevokeAPIKeyParams{
+		ID:       apiKey1.ID,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), revoked.RevokedAt, time.Second)
+
+	_, err = testQueries.RevokeAPIKey(context.Background(), RevokeAPIKeyParams{
+		ID:       apiKey1.ID,
+		Username: user.Username,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	apiKeys, err := testQueries.ListAPIKeys(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, apiKeys, 1)
+	require.Equal(t, apiKey2.ID, apiKeys[0].ID)
+}
+
+func TestTouchAPIKey(t *testing.T) {
+	apiKey := createRandomAPIKey(t, createRandomUser(t))
+
+	require.NoError(t, testQueries.TouchAPIKey(context.Background(), apiKey.ID))
+	touched, err := testQueries.GetAPIKeyByHash(context.Background(), apiKey.KeyHash)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), touched.LastUsedAt, time.Second)
+
+	// within the minute the time isn't written again
+	require.NoError(t, testQueries.TouchAPIKey(context.Background(), apiKey.ID))
+	again, err := testQueries.GetAPIKeyByHash(context.Background(), apiKey.KeyHash)
+	require.NoError(t, err)
+	require.Equal(t, touched.LastUsedAt, again.LastUsedAt)
+}
@@ -0,0 +1,150 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.16.0
+// source: consent.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const createConsent = `-- name: CreateConsent :one
+INSERT INTO consents (
+  client_id,
+  username,
+  account_ids,
+  permissions,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING id, client_id, username, account_ids, permissions, expires_at, revoked_at, created_at
+`
+
+type CreateConsentParams struct {
+	ClientID    string    `json:"client_id"`
+	Username    string    `json:"username"`
+	AccountIds  []int64   `json:"account_ids"`
+	Permissions []string  `json:"permissions"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateConsent(ctx context.Context, arg CreateConsentParams) (Consent, error) {
+	row := q.db.QueryRowContext(ctx, createConsent,
+		arg.ClientID,
+		arg.Username,
+		pq.Array(arg.AccountIds),
+		pq.Array(arg.Permissions),
+		arg.ExpiresAt,
+	)
+	var i Consent
+	err := row.Scan(
+		&i.ID,
+		&i.ClientID,
+		&i.Username,
+		pq.Array(&i.AccountIds),
+		pq.Array(&i.Permissions),
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getActiveConsent = `-- name: GetActiveConsent :one
+SELECT id, client_id, username, account_ids, permissions, expires_at, revoked_at, created_at FROM consents
+WHERE username = $1 AND client_id = $2
+  AND revoked_at = '0001-01-01 00:00:00Z' AND expires_at > now()
+ORDER BY id DESC
+LIMIT 1
+`
+
+type GetActiveConsentParams struct {
+	Username string `json:"username"`
+	ClientID string `json:"client_id"`
+}
+
+func (q *Queries) GetActiveConsent(ctx context.Context, arg GetActiveConsentParams) (Consent, error) {
+	row := q.db.QueryRowContext(ctx, getActiveConsent, arg.Username, arg.ClientID)
+	var i Consent
+	err := row.Scan(
+		&i.ID,
+		&i.ClientID,
+		&i.Username,
+		pq.Array(&i.AccountIds),
+		pq.Array(&i.Permissions),
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listConsents = `-- name: ListConsents :many
+SELECT id, client_id, username, account_ids, permissions, expires_at, revoked_at, created_at FROM consents
+WHERE username = $1 AND revoked_at = '0001-01-01 00:00:00Z'
+ORDER BY id DESC
+`
+
+func (q *Queries) ListConsents(ctx context.Context, username string) ([]Consent, error) {
+	rows, err := q.db.QueryContext(ctx, listConsents, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Consent{}
+	for rows.Next() {
+		var i Consent
+		if err := rows.Scan(
+			&i.ID,
+			&i.ClientID,
+			&i.Username,
+			pq.Array(&i.AccountIds),
+			pq.Array(&i.Permissions),
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeConsent = `-- name: RevokeConsent :one
+UPDATE consents
+SET revoked_at = now()
+WHERE id = $1 AND username = $2 AND revoked_at = '0001-01-01 00:00:00Z'
+RETURNING id, client_id, username, account_ids, permissions, expires_at, revoked_at, created_at
+`
+
+type RevokeConsentParams struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+func (q *Queries) RevokeConsent(ctx context.Context, arg RevokeConsentParams) (Consent, error) {
+	row := q.db.QueryRowContext(ctx, revokeConsent, arg.ID, arg.Username)
+	var i Consent
+	err := row.Scan(
+		&i.ID,
+		&i.ClientID,
+		&i.Username,
+		pq.Array(&i.AccountIds),
+		pq.Array(&i.Permissions),
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomConsent(t *testing.T, client OauthClient, username string, expiresAt time.Time) Consent {
+	arg := CreateConsentParams{
+		ClientID:    client.ID,
+		Username:    username,
+		AccountIds:  []int64{1, 2},
+		Permissions: []string{"balances", "transactions"},
+		ExpiresAt:   expiresAt,
+	}
+
+	consent, err := testQueries.CreateConsent(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ClientID, consent.ClientID)
+	require.Equal(t, arg.Username, consent.Username)
+	require.Equal(t, arg.AccountIds, consent.AccountIds)
+	require.Equal(t, arg.Permissions, consent.Permissions)
+	require.WithinDuration(t, arg.ExpiresAt, consent.ExpiresAt, time.Second)
+	require.True(t, consent.RevokedAt.IsZero())
+	return consent
+}
+
+func TestGetActiveConsent(t *testing.T) {
+	user := createRandomUser(t)
+	client := createRandomOAuthClient(t, createRandomUser(t))
+	arg := GetActiveConsentParams{Username: user.Username, ClientID: client.ID}
+
+	_, err := testQueries.GetActiveConsent(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// the latest consent supersedes the earlier ones
+	createRandomConsent(t, client, user.Username, time.Now().Add(time.Hour))
+	consent2 := createRandomConsent(t, client, user.Username, time.Now().Add(time.Hour))
+
+	active, err := testQueries.GetActiveConsent(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, consent2.ID, active.ID)
+
+	// expired consents don't count
+	createRandomConsent(t, client, user.Username, time.Now().Add(-time.Minute))
+	active, err = testQueries.GetActiveConsent(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, consent2.ID, active.ID)
+}
+
+func TestRevokeConsent(t *testing.T) {
+	user := createRandomUser(t)
+	client := createRandomOAuthClient(t, createRandomUser(t))
+	consent := createRandomConsent(t, client, user.Username, time.Now().Add(time.Hour))
+
+	// only the user who consented can revoke it
+	_, err := testQueries.RevokeConsent(context.Background(), RevokeConsentParams{
+		ID:       consent.ID,
+		Username: createRandomUser(t).Username,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	revoked, err := testQueries.RevokeConsent(context.Background(), RevokeConsentParams{
+		ID:       consent.ID,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.False(t, revoked.RevokedAt.IsZero())
+
+	_, err = testQueries.GetActiveConsent(context.Background(), GetActiveConsentParams{
+		Username: user.Username,
+		ClientID: client.ID,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	consents, err := testQueries.ListConsents(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Empty(t, consents)
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	// ErrDayClosed is returned for postings or closes into a business date that is already closed
+	ErrDayClosed = errors.New("business date is already closed")
+	// ErrTrialBalanceMismatch is returned when debits don't equal credits at close
+	ErrTrialBalanceMismatch = errors.New("trial balance debits don't equal credits")
+)
+
+// TrialBalance is the state of the ledger at the end of a business date
+type TrialBalance struct {
+	BusinessDate time.Time            `json:"business_date"`
+	PreviousDate sql.NullTime         `json:"previous_date"`
+	Accounts     []LedgerBalance      `json:"accounts"`
+	Currencies   []GetTrialBalanceRow `json:"currencies"`
+}
+
+// CloseDayTxParams contain the input parameters of the daily close transaction
+type CloseDayTxParams struct {
+	BusinessDate time.Time
+	// Sign returns the signature stored with the close record
+	Sign func(trialBalance TrialBalance) (string, error)
+}
+
+// CloseDayTxResult is the result of the daily close transaction
+type CloseDayTxResult struct {
+	DailyClose   DailyClose   `json:"daily_close"`
+	TrialBalance TrialBalance `json:"trial_balance"`
+}
+
+// CloseDayTx freezes the journal up to arg.BusinessDate, rolls the ledger balances of the
+// previous close forward with the postings booked since, checks the trial balance and stores
+// the signed close record. Any day between the previous close and arg.BusinessDate is closed with it.
+func (store *SQLStore) CloseDayTx(ctx context.Context, arg CloseDayTxParams) (CloseDayTxResult, error) {
+	var result CloseDayTxResult
+	businessDate := truncateDate(arg.BusinessDate)
+
+	ctx, span := startTxSpan(ctx, "CloseDayTx", attribute.String("ledger.business_date", businessDate.Format(time.DateOnly)))
+	defer span.End()
+
+	err := store.execTx(ctx, func(ctx context.Context, q *Queries) error {
+		// wait for postings in flight and block new ones until the close commits
+		if _, err := q.db.ExecContext(ctx, "LOCK TABLE journal_entries IN SHARE MODE"); err != nil {
+			return err
+		}
+
+		var previousDate sql.NullTime
+		latest, err := q.GetLatestDailyClose(ctx)
+		switch {
+		case err == nil:
+			if !latest.BusinessDate.Before(businessDate) {
+				return fmt.Errorf("%w: %s", ErrDayClosed, businessDate.Format(time.DateOnly))
+			}
+			previousDate = sql.NullTime{Time: latest.BusinessDate, Valid: true}
+		case !errors.Is(err, sql.ErrNoRows):
+			return err
+		}
+
+		result.TrialBalance = TrialBalance{BusinessDate: businessDate, PreviousDate: previousDate}
+		result.TrialBalance.Accounts, err = q.RollLedgerBalances(ctx, RollLedgerBalancesParams{
+			BusinessDate: businessDate,
+			PreviousDate: previousDate,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.TrialBalance.Currencies, err = q.GetTrialBalance(ctx, businessDate)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range result.TrialBalance.Currencies {
+			if row.Debits != row.Credits || row.DebitBalances != row.CreditBalances {
+				return fmt.Errorf("%w: %s debits %d, credits %d, debit balances %d, credit balances %d",
+					ErrTrialBalanceMismatch, row.Currency, row.Debits, row.Credits, row.DebitBalances, row.CreditBalances)
+			}
+		}
+
+		signature, err := arg.Sign(result.TrialBalance)
+		if err != nil {
+			return err
+		}
+
+		result.DailyClose, err = q.CreateDailyClose(ctx, CreateDailyCloseParams{
+			BusinessDate: businessDate,
+			PreviousDate: previousDate,
+			Signature:    signature,
+		})
+		return err
+	})
+
+	recordError(span, err)
+	return result, err
+}
+
+// checkBookingDate rejects postings backdated into a closed business date.
+// The database enforces the same rule, this gives callers a typed error.
+func checkBookingDate(ctx context.Context, q *Queries, bookingDate time.Time) error {
+	latest, err := q.GetLatestDailyClose(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	if !latest.BusinessDate.Before(truncateDate(bookingDate)) {
+		return fmt.Errorf("%w: %s", ErrDayClosed, bookingDate.Format(time.DateOnly))
+	}
+	return nil
+}
+
+// truncateDate returns the UTC calendar date of t
+func truncateDate(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,232 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.16.0
+// source: daily_close.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const createDailyClose = `-- name: CreateDailyClose :one
+INSERT INTO daily_closes (
+  business_date,
+  previous_date,
+  signature
+) VALUES (
+  $1, $2, $3
+) RETURNING business_date, previous_date, signature, created_at
+`
+
+type CreateDailyCloseParams struct {
+	BusinessDate time.Time    `json:"business_date"`
+	PreviousDate sql.NullTime `json:"previous_date"`
+	Signature    string       `json:"signature"`
+}
+
+func (q *Queries) CreateDailyClose(ctx context.Context, arg CreateDailyCloseParams) (DailyClose, error) {
+	row := q.db.QueryRowContext(ctx, createDailyClose, arg.BusinessDate, arg.PreviousDate, arg.Signature)
+	var i DailyClose
+	err := row.Scan(
+		&i.BusinessDate,
+		&i.PreviousDate,
+		&i.Signature,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getDailyClose = `-- name: GetDailyClose :one
+SELECT business_date, previous_date, signature, created_at FROM daily_closes
+WHERE business_date = $1 LIMIT 1
+`
+
+func (q *Queries) GetDailyClose(ctx context.Context, businessDate time.Time) (DailyClose, error) {
+	row := q.db.QueryRowContext(ctx, getDailyClose, businessDate)
+	var i DailyClose
+	err := row.Scan(
+		&i.BusinessDate,
+		&i.PreviousDate,
+		&i.Signature,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLatestDailyClose = `-- name: GetLatestDailyClose :one
+SELECT business_date, previous_date, signature, created_at FROM daily_closes
+ORDER BY business_date DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestDailyClose(ctx context.Context) (DailyClose, error) {
+	row := q.db.QueryRowContext(ctx, getLatestDailyClose)
+	var i DailyClose
+	err := row.Scan(
+		&i.BusinessDate,
+		&i.PreviousDate,
+		&i.Signature,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getTrialBalance = `-- name: GetTrialBalance :many
+SELECT
+  a.currency,
+  sum(b.debits)::bigint AS debits,
+  sum(b.credits)::bigint AS credits,
+  sum(greatest(b.closing, 0))::bigint AS debit_balances,
+  sum(greatest(-b.closing, 0))::bigint AS credit_balances
+FROM ledger_balances b
+JOIN ledger_accounts a ON a.id = b.ledger_account_id
+WHERE b.business_date = $1
+GROUP BY a.currency
+ORDER BY a.currency
+`
+
+type GetTrialBalanceRow struct {
+	Currency       string `json:"currency"`
+	Debits         int64  `json:"debits"`
+	Credits        int64  `json:"credits"`
+	DebitBalances  int64  `json:"debit_balances"`
+	CreditBalances int64  `json:"credit_balances"`
+}
+
+func (q *Queries) GetTrialBalance(ctx context.Context, businessDate time.Time) ([]GetTrialBalanceRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTrialBalance, businessDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetTrialBalanceRow{}
+	for rows.Next() {
+		var i GetTrialBalanceRow
+		if err := rows.Scan(
+			&i.Currency,
+			&i.Debits,
+			&i.Credits,
+			&i.DebitBalances,
+			&i.CreditBalances,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLedgerBalances = `-- name: ListLedgerBalances :many
+SELECT business_date, ledger_account_id, opening, debits, credits, closing FROM ledger_balances
+WHERE business_date = $1
+ORDER BY ledger_account_id
+`
+
+func (q *Queries) ListLedgerBalances(ctx context.Context, businessDate time.Time) ([]LedgerBalance, error) {
+	rows, err := q.db.QueryContext(ctx, listLedgerBalances, businessDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []LedgerBalance{}
+	for rows.Next() {
+		var i LedgerBalance
+		if err := rows.Scan(
+			&i.BusinessDate,
+			&i.LedgerAccountID,
+			&i.Opening,
+			&i.Debits,
+			&i.Credits,
+			&i.Closing,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const rollLedgerBalances = `-- name: RollLedgerBalances :many
+INSERT INTO ledger_balances (
+  business_date,
+  ledger_account_id,
+  opening,
+  debits,
+  credits,
+  closing
+)
+SELECT
+  $1::date,
+  a.id,
+  COALESCE(p.closing, 0),
+  COALESCE(m.debits, 0),
+  COALESCE(m.credits, 0),
+  COALESCE(p.closing, 0) + COALESCE(m.debits, 0) - COALESCE(m.credits, 0)
+FROM ledger_accounts a
+LEFT JOIN ledger_balances p
+  ON p.ledger_account_id = a.id AND p.business_date = $2::date
+LEFT JOIN (
+  SELECT
+    l.ledger_account_id,
+    sum(l.debit)::bigint AS debits,
+    sum(l.credit)::bigint AS credits
+  FROM journal_lines l
+  JOIN journal_entries e ON e.id = l.journal_entry_id
+  WHERE
+    ($2::date IS NULL OR e.booking_date > $2::date) AND
+    e.booking_date <= $1::date
+  GROUP BY l.ledger_account_id
+) m ON m.ledger_account_id = a.id
+ORDER BY a.id
+RETURNING business_date, ledger_account_id, opening, debits, credits, closing
+`
+
+type RollLedgerBalancesParams struct {
+	BusinessDate time.Time    `json:"business_date"`
+	PreviousDate sql.NullTime `json:"previous_date"`
+}
+
+func (q *Queries) RollLedgerBalances(ctx context.Context, arg RollLedgerBalancesParams) ([]LedgerBalance, error) {
+	rows, err := q.db.QueryContext(ctx, rollLedgerBalances, arg.BusinessDate, arg.PreviousDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []LedgerBalance{}
+	for rows.Next() {
+		var i LedgerBalance
+		if err := rows.Scan(
+			&i.BusinessDate,
+			&i.LedgerAccountID,
+			&i.Opening,
+			&i.Debits,
+			&i.Credits,
+			&i.Closing,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testSign(trialBalance TrialBalance) (string, error) {
+	return "signature-" + trialBalance.BusinessDate.Format(time.DateOnly), nil
+}
+
+func TestCloseDayTx(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testDB)
+	yesterday := truncateDate(time.Now()).AddDate(0, 0, -1)
+
+	// the test database keeps its closes between runs, yesterday may be closed already
+	latest, err := store.GetLatestDailyClose(ctx)
+	if err != nil {
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	}
+
+	if err != nil || latest.BusinessDate.Before(yesterday) {
+		expense := createRandomLedgerAccount(t, LedgerAccountTypeExpense, util.USD)
+		cash := createRandomLedgerAccount(t, LedgerAccountTypeAsset, util.USD)
+		_, err := store.PostJournalTx(ctx, PostJournalTxParams{
+			Description: "Backdated expense",
+			BookingDate: yesterday,
+			Lines: []JournalLineParams{
+				{LedgerAccountID: expense.ID, Debit: 100},
+				{LedgerAccountID: cash.ID, Credit: 100},
+			},
+		})
+		require.NoError(t, err)
+
+		result, err := store.CloseDayTx(ctx, CloseDayTxParams{BusinessDate: yesterday, Sign: testSign})
+		require.NoError(t, err)
+		require.True(t, yesterday.Equal(result.DailyClose.BusinessDate))
+		require.Equal(t, "signature-"+yesterday.Format(time.DateOnly), result.DailyClose.Signature)
+		require.NotEmpty(t, result.TrialBalance.Currencies)
+
+		for _, row := range result.TrialBalance.Currencies {
+			require.Equal(t, row.Debits, row.Credits)
+			require.Equal(t, row.DebitBalances, row.CreditBalances)
+		}
+
+		var found bool
+		for _, balance := range result.TrialBalance.Accounts {
+			if balance.LedgerAccountID == expense.ID {
+				found = true
+				require.Zero(t, balance.Opening)
+				require.Equal(t, int64(100), balance.Debits)
+				require.Equal(t, int64(100), balance.Closing)
+			}
+		}
+		require.True(t, found)
+
+		balances, err := store.ListLedgerBalances(ctx, yesterday)
+		require.NoError(t, err)
+		require.Equal(t, result.TrialBalance.Accounts, balances)
+	}
+
+	// the day can't be closed twice
+	_, err = store.CloseDayTx(ctx, CloseDayTxParams{BusinessDate: yesterday, Sign: testSign})
+	require.ErrorIs(t, err, ErrDayClosed)
+
+	expense := createRandomLedgerAccount(t, LedgerAccountTypeExpense, util.EUR)
+	cash := createRandomLedgerAccount(t, LedgerAccountTypeAsset, util.EUR)
+	arg := PostJournalTxParams{
+		Description: "Backdated into a closed day",
+		BookingDate: yesterday,
+		Lines: []JournalLineParams{
+			{LedgerAccountID: expense.ID, Debit: 100},
+			{LedgerAccountID: cash.ID, Credit: 100},
+		},
+	}
+
+	// backdated postings are rejected by the store
+	_, err = store.PostJournalTx(ctx, arg)
+	require.ErrorIs(t, err, ErrDayClosed)
+
+	// and by the database
+	_, err = testQueries.CreateJournalEntry(ctx, CreateJournalEntryParams{
+		Description: arg.Description,
+		BookingDate: sql.NullTime{Time: yesterday, Valid: true},
+	})
+	requireCheckViolation(t, err)
+
+	// postings of today are still accepted
+	arg.BookingDate = time.Time{}
+	_, err = store.PostJournalTx(ctx, arg)
+	require.NoError(t, err)
+}
+
+func TestCloseDayTxSignError(t *testing.T) {
+	errSign := errors.New("cannot sign")
+	tomorrow := truncateDate(time.Now()).AddDate(0, 0, 1)
+
+	_, err := NewStore(testDB).CloseDayTx(context.Background(), CloseDayTxParams{
+		BusinessDate: tomorrow,
+		Sign: func(trialBalance TrialBalance) (string, error) {
+			return "", errSign
+		},
+	})
+	require.ErrorIs(t, err, errSign)
+
+	_, err = testQueries.GetDailyClose(context.Background(), tomorrow)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,34 @@
+package db
+
+import "context"
+
+// VerifyEmailTx marks the address a verification token was sent to as
+// verified and uses up every outstanding token of the user. Unknown or
+// expired tokens, and tokens sent to an address the user no longer has,
+// return sql.ErrNoRows.
+func (store *SQLStore) VerifyEmailTx(ctx context.Context, tokenHash string) (User, error) {
+	var user User
+
+	ctx, span := startTxSpan(ctx, "VerifyEmailTx")
+	defer span.End()
+
+	err := store.execTx(ctx, func(ctx context.Context, q *Queries) error {
+		verification, err := q.UseEmailVerification(ctx, tokenHash)
+		if err != nil {
+			return err
+		}
+
+		user, err = q.VerifyUserEmail(ctx, VerifyUserEmailParams{
+			Username: verification.Username,
+			Email:    verification.Email,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.DeleteEmailVerifications(ctx, user.Username)
+	})
+
+	recordError(span, err)
+	return user, err
+}
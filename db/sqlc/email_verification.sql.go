@@ -0,0 +1,109 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.16.0
+// source: email_verification.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createEmailVerification = `-- name: CreateEmailVerification :one
+INSERT INTO email_verifications (
+  token_hash,
+  username,
+  email,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING token_hash, username, email, expires_at, created_at
+`
+
+type CreateEmailVerificationParams struct {
+	TokenHash string    `json:"token_hash"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateEmailVerification(ctx context.Context, arg CreateEmailVerificationParams) (EmailVerification, error) {
+	row := q.db.QueryRowContext(ctx, createEmailVerification,
+		arg.TokenHash,
+		arg.Username,
+		arg.Email,
+		arg.ExpiresAt,
+	)
+	var i EmailVerification
+	err := row.Scan(
+		&i.TokenHash,
+		&i.Username,
+		&i.Email,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteEmailVerifications = `-- name: DeleteEmailVerifications :exec
+DELETE FROM email_verifications
+WHERE username = $1
+`
+
+func (q *Queries) DeleteEmailVerifications(ctx context.Context, username string) error {
+	_, err := q.db.ExecContext(ctx, deleteEmailVerifications, username)
+	return err
+}
+
+const useEmailVerification = `-- name: UseEmailVerification :one
+DELETE FROM email_verifications
+WHERE token_hash = $1 AND expires_at > now()
+RETURNING token_hash, username, email, expires_at, created_at
+`
+
+func (q *Queries) UseEmailVerification(ctx context.Context, tokenHash string) (EmailVerification, error) {
+	row := q.db.QueryRowContext(ctx, useEmailVerification, tokenHash)
+	var i EmailVerification
+	err := row.Scan(
+		&i.TokenHash,
+		&i.Username,
+		&i.Email,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const verifyUserEmail = `-- name: VerifyUserEmail :one
+UPDATE users
+SET email_verified = true
+WHERE username = $1 AND email = $2
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, failed_login_attempts, locked_until, totp_secret, totp_enabled, totp_last_step, email_verified
+`
+
+type VerifyUserEmailParams struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// Only verifies the address the email was sent to
+func (q *Queries) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, verifyUserEmail, arg.Username, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.TotpLastStep,
+		&i.EmailVerified,
+	)
+	return i, err
+}
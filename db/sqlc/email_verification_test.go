@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomEmailVerification(t *testing.T, user User, expiresAt time.Time) EmailVerification {
+	verification, err := testQueries.CreateEmailVerification(context.Background(), CreateEmailVerificationParams{
+		TokenHash: util.RandomString(64),
+		Username:  user.Username,
+		Email:     user.Email,
+		ExpiresAt: expiresAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Email, verification.Email)
+	return verification
+}
+
+func TestVerifyEmailTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	require.False(t, user.EmailVerified)
+
+	verification := createRandomEmailVerification(t, user, time.Now().Add(time.Hour))
+	other := createRandomEmailVerification(t, user, time.Now().Add(time.Hour))
+
+	verified, err := store.VerifyEmailTx(context.Background(), verification.TokenHash)
+	require.NoError(t, err)
+	require.True(t, verified.EmailVerified)
+
+	// every link of the user is used up
+	for _, tokenHash := range []string{verification.TokenHash, other.TokenHash} {
+		_, err = store.VerifyEmailTx(context.Background(), tokenHash)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	}
+}
+
+func TestVerifyEmailTxExpired(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	verification := createRandomEmailVerification(t, user, time.Now().Add(-time.Second))
+
+	_, err := store.VerifyEmailTx(context.Background(), verification.TokenHash)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestVerifyEmailTxChangedEmail(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	verification := createRandomEmailVerification(t, user, time.Now().Add(time.Hour))
+
+	_, err := testDB.Exec("UPDATE users SET email = $1 WHERE username = $2", util.RandomEmail(), user.Username)
+	require.NoError(t, err)
+
+	_, err = store.VerifyEmailTx(context.Background(), verification.TokenHash)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	got, err := testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.False(t, got.EmailVerified)
+}
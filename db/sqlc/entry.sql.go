@@ -7,6 +7,7 @@ package db
 
 import (
 	"context"
+	"time"
 )
 
 const createEntry = `-- name: CreateEntry :one
@@ -35,6 +36,29 @@ func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry
 	return i, err
 }
 
+const getBalanceAt = `-- name: GetBalanceAt :one
+SELECT (a.balance - COALESCE((
+  SELECT SUM(e.amount) FROM entries e
+  WHERE e.account_id = a.id AND e.created_at >= $1
+), 0))::bigint AS balance
+FROM accounts a
+WHERE a.id = $2
+`
+
+type GetBalanceAtParams struct {
+	FromTime  time.Time `json:"from_time"`
+	AccountID int64     `json:"account_id"`
+}
+
+// the balance of the account at from_time, its balance less the entries booked
+// since, both read in the one snapshot of the statement
+func (q *Queries) GetBalanceAt(ctx context.Context, arg GetBalanceAtParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getBalanceAt, arg.FromTime, arg.AccountID)
+	var balance int64
+	err := row.Scan(&balance)
+	return balance, err
+}
+
 const getEntry = `-- name: GetEntry :one
 SELECT id, account_id, amount, created_at FROM entries
 WHERE id = $1 LIMIT 1
@@ -54,20 +78,103 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 
 const listEntries = `-- name: ListEntries :many
 SELECT id, account_id, amount, created_at FROM entries
-WHERE account_id = $1
+WHERE account_id = $1 AND id > $2
 ORDER BY id
-LIMIT $2
-OFFSET $3
+LIMIT $3
 `
 
 type ListEntriesParams struct {
 	AccountID int64 `json:"account_id"`
+	AfterID   int64 `json:"after_id"`
 	Limit     int32 `json:"limit"`
-	Offset    int32 `json:"offset"`
 }
 
 func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
-	rows, err := q.db.QueryContext(ctx, listEntries, arg.AccountID, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listEntries, arg.AccountID, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEntriesBefore = `-- name: ListEntriesBefore :many
+SELECT id, account_id, amount, created_at FROM entries
+WHERE account_id = $1 AND id < $2
+ORDER BY id DESC
+LIMIT $3
+`
+
+type ListEntriesBeforeParams struct {
+	AccountID int64 `json:"account_id"`
+	BeforeID  int64 `json:"before_id"`
+	Limit     int32 `json:"limit"`
+}
+
+func (q *Queries) ListEntriesBefore(ctx context.Context, arg ListEntriesBeforeParams) ([]Entry, error) {
+	rows, err := q.db.QueryContext(ctx, listEntriesBefore, arg.AccountID, arg.BeforeID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEntriesInPeriod = `-- name: ListEntriesInPeriod :many
+SELECT id, account_id, amount, created_at FROM entries
+WHERE
+  account_id = $1 AND
+  created_at >= $2 AND
+  created_at < $3
+ORDER BY id
+`
+
+type ListEntriesInPeriodParams struct {
+	AccountID int64     `json:"account_id"`
+	FromTime  time.Time `json:"from_time"`
+	ToTime    time.Time `json:"to_time"`
+}
+
+func (q *Queries) ListEntriesInPeriod(ctx context.Context, arg ListEntriesInPeriodParams) ([]Entry, error) {
+	rows, err := q.db.QueryContext(ctx, listEntriesInPeriod, arg.AccountID, arg.FromTime, arg.ToTime)
 	if err != nil {
 		return nil, err
 	}
@@ -2,8 +2,10 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"simplebank/util"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -50,22 +52,98 @@ func TestGetEntry(t *testing.T) {
 func TestListEntries(t *testing.T) {
 	account := createRandomAccount(t)
 
+	var entries []Entry
 	for i := 0; i < 10; i++ {
-		createRandomEntry(t, account)
+		entries = append(entries, createRandomEntry(t, account))
 	}
 
 	arg := ListEntriesParams{
 		AccountID: account.ID,
+		AfterID:   entries[4].ID,
 		Limit:     5,
-		Offset:    5,
 	}
 
-	entries, err := testQueries.ListEntries(context.Background(), arg)
+	page, err := testQueries.ListEntries(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, page, 5)
+	require.Equal(t, entries[5:], page)
+}
+
+func TestListEntriesBefore(t *testing.T) {
+	account := createRandomAccount(t)
+
+	var entries []Entry
+	for i := 0; i < 10; i++ {
+		entries = append(entries, createRandomEntry(t, account))
+	}
+
+	arg := ListEntriesBeforeParams{
+		AccountID: account.ID,
+		BeforeID:  entries[5].ID,
+		Limit:     5,
+	}
+
+	page, err := testQueries.ListEntriesBefore(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, page, 5)
+
+	// rows come back newest first
+	for i, entry := range page {
+		require.Equal(t, entries[4-i], entry)
+	}
+}
+
+func TestListEntriesInPeriod(t *testing.T) {
+	account := createRandomAccount(t)
+
+	for i := 0; i < 5; i++ {
+		createRandomEntry(t, account)
+	}
+
+	arg := ListEntriesInPeriodParams{
+		AccountID: account.ID,
+		FromTime:  time.Now().Add(-time.Minute),
+		ToTime:    time.Now().Add(time.Minute),
+	}
+
+	entries, err := testQueries.ListEntriesInPeriod(context.Background(), arg)
 	require.NoError(t, err)
 	require.Len(t, entries, 5)
 
-	for _, account := range entries {
-		require.NotEmpty(t, account)
-		require.Equal(t, arg.AccountID, account.AccountID)
+	arg.FromTime = arg.ToTime
+	arg.ToTime = arg.ToTime.Add(time.Minute)
+	entries, err = testQueries.ListEntriesInPeriod(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestGetBalanceAt(t *testing.T) {
+	account := createRandomAccount(t)
+
+	var total int64
+	for i := 0; i < 5; i++ {
+		total += createRandomEntry(t, account).Amount
 	}
+
+	// the entries are all booked since, the balance before them is the
+	// current one less their sum
+	balance, err := testQueries.GetBalanceAt(context.Background(), GetBalanceAtParams{
+		AccountID: account.ID,
+		FromTime:  time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.Balance-total, balance)
+
+	balance, err = testQueries.GetBalanceAt(context.Background(), GetBalanceAtParams{
+		AccountID: account.ID,
+		FromTime:  time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, balance)
+
+	_, err = testQueries.GetBalanceAt(context.Background(), GetBalanceAtParams{
+		AccountID: account.ID + 1_000_000,
+		FromTime:  time.Now(),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
 }
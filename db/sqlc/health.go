@@ -0,0 +1,17 @@
+package db
+
+import (
+	"context"
+)
+
+// Ping checks the database is reachable
+func (store *SQLStore) Ping(ctx context.Context) error {
+	return store.db.PingContext(ctx)
+}
+
+// SchemaVersion returns the migration version golang-migrate recorded in the
+// schema_migrations table, dirty is set when a migration failed halfway
+func (store *SQLStore) SchemaVersion(ctx context.Context) (version int64, dirty bool, err error) {
+	err = store.db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	return
+}
@@ -0,0 +1,26 @@
+package db
+
+import (
+	"context"
+	"simplebank/db/migration"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPing(t *testing.T) {
+	store := NewStore(testDB)
+	require.NoError(t, store.Ping(context.Background()))
+}
+
+func TestSchemaVersion(t *testing.T) {
+	store := NewStore(testDB)
+
+	version, dirty, err := store.SchemaVersion(context.Background())
+	require.NoError(t, err)
+	require.False(t, dirty)
+
+	latest, err := migration.LatestVersion(migration.FS)
+	require.NoError(t, err)
+	require.Equal(t, latest, version)
+}
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrUnbalancedJournal is returned for journal entries whose debits don't equal their credits
+var ErrUnbalancedJournal = errors.New("journal entry is not balanced")
+
+// JournalLineParams is one debit or credit of a journal entry, exactly one of Debit and Credit is positive
+type JournalLineParams struct {
+	LedgerAccountID int64 `json:"ledger_account_id"`
+	Debit           int64 `json:"debit"`
+	Credit          int64 `json:"credit"`
+}
+
+// PostJournalTxParams contain the input parameters of the journal posting transaction
+type PostJournalTxParams struct {
+	Description string        `json:"description"`
+	TransferID  sql.NullInt64 `json:"transfer_id"`
+	// BookingDate backdates the entry, it defaults to the current UTC date
+	BookingDate time.Time           `json:"booking_date"`
+	Lines       []JournalLineParams `json:"lines"`
+}
+
+// PostJournalTxResult is the result of the journal posting transaction
+type PostJournalTxResult struct {
+	JournalEntry JournalEntry  `json:"journal_entry"`
+	Lines        []JournalLine `json:"lines"`
+}
+
+// PostJournalTx posts a balanced journal entry to the general ledger
+func (store *SQLStore) PostJournalTx(ctx context.Context, arg PostJournalTxParams) (PostJournalTxResult, error) {
+	ctx, span := startTxSpan(ctx, "PostJournalTx", attribute.Int("journal.lines", len(arg.Lines)))
+	defer span.End()
+
+	var result PostJournalTxResult
+
+	err := store.execTx(ctx, func(ctx context.Context, q *Queries) error {
+		var err error
+		result, err = postJournal(ctx, q, arg)
+		return err
+	})
+
+	recordError(span, err)
+	return result, err
+}
+
+// postJournal inserts a journal entry and its lines inside an existing transaction.
+// The database checks the entry is balanced per currency when the transaction commits,
+// the checks here only catch obvious mistakes before a round trip.
+func postJournal(ctx context.Context, q *Queries, arg PostJournalTxParams) (PostJournalTxResult, error) {
+	var result PostJournalTxResult
+
+	if err := checkJournalLines(arg.Lines); err != nil {
+		return result, err
+	}
+
+	var bookingDate sql.NullTime
+	if !arg.BookingDate.IsZero() {
+		if err := checkBookingDate(ctx, q, arg.BookingDate); err != nil {
+			return result, err
+		}
+		bookingDate = sql.NullTime{Time: truncateDate(arg.BookingDate), Valid: true}
+	}
+
+	var err error
+	result.JournalEntry, err = q.CreateJournalEntry(ctx, CreateJournalEntryParams{
+		Description: arg.Description,
+		TransferID:  arg.TransferID,
+		BookingDate: bookingDate,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Lines = make([]JournalLine, 0, len(arg.Lines))
+	for _, line := range arg.Lines {
+		journalLine, err := q.CreateJournalLine(ctx, CreateJournalLineParams{
+			JournalEntryID:  result.JournalEntry.ID,
+			LedgerAccountID: line.LedgerAccountID,
+			Debit:           line.Debit,
+			Credit:          line.Credit,
+		})
+		if err != nil {
+			return result, err
+		}
+		result.Lines = append(result.Lines, journalLine)
+	}
+
+	return result, nil
+}
+
+func checkJournalLines(lines []JournalLineParams) error {
+	if len(lines) < 2 {
+		return fmt.Errorf("%w: needs at least two lines", ErrUnbalancedJournal)
+	}
+
+	var debits, credits int64
+	for _, line := range lines {
+		if line.Debit < 0 || line.Credit < 0 || (line.Debit == 0) == (line.Credit == 0) {
+			return fmt.Errorf("%w: every line must either debit or credit a positive amount", ErrUnbalancedJournal)
+		}
+		debits += line.Debit
+		credits += line.Credit
+	}
+
+	if debits != credits {
+		return fmt.Errorf("%w: debits %d, credits %d", ErrUnbalancedJournal, debits, credits)
+	}
+	return nil
+}
+
+// customerLedgerAccount returns the deposit liability ledger account of a customer account,
+// creating it on the first posting
+func customerLedgerAccount(ctx context.Context, q *Queries, account Account) (LedgerAccount, error) {
+	ledgerAccount, err := q.GetLedgerAccountByAccountID(ctx, sql.NullInt64{Int64: account.ID, Valid: true})
+	if err == nil || !errors.Is(err, sql.ErrNoRows) {
+		return ledgerAccount, err
+	}
+
+	return q.CreateLedgerAccount(ctx, CreateLedgerAccountParams{
+		Code:      fmt.Sprintf("2100-%d", account.ID),
+		Name:      fmt.Sprintf("Customer deposits %d", account.ID),
+		Type:      LedgerAccountTypeLiability,
+		Currency:  account.Currency,
+		AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+	})
+}
@@ -0,0 +1,308 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.16.0
+// source: ledger.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const createJournalEntry = `-- name: CreateJournalEntry :one
+INSERT INTO journal_entries (
+  description,
+  transfer_id,
+  booking_date
+) VALUES (
+  $1,
+  $2,
+  COALESCE($3::date, (now() AT TIME ZONE 'UTC')::date)
+) RETURNING id, description, transfer_id, created_at, booking_date
+`
+
+type CreateJournalEntryParams struct {
+	Description string        `json:"description"`
+	TransferID  sql.NullInt64 `json:"transfer_id"`
+	BookingDate sql.NullTime  `json:"booking_date"`
+}
+
+func (q *Queries) CreateJournalEntry(ctx context.Context, arg CreateJournalEntryParams) (JournalEntry, error) {
+	row := q.db.QueryRowContext(ctx, createJournalEntry, arg.Description, arg.TransferID, arg.BookingDate)
+	var i JournalEntry
+	err := row.Scan(
+		&i.ID,
+		&i.Description,
+		&i.TransferID,
+		&i.CreatedAt,
+		&i.BookingDate,
+	)
+	return i, err
+}
+
+const createJournalLine = `-- name: CreateJournalLine :one
+INSERT INTO journal_lines (
+  journal_entry_id,
+  ledger_account_id,
+  debit,
+  credit
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING id, journal_entry_id, ledger_account_id, debit, credit, created_at
+`
+
+type CreateJournalLineParams struct {
+	JournalEntryID  int64 `json:"journal_entry_id"`
+	LedgerAccountID int64 `json:"ledger_account_id"`
+	Debit           int64 `json:"debit"`
+	Credit          int64 `json:"credit"`
+}
+
+func (q *Queries) CreateJournalLine(ctx context.Context, arg CreateJournalLineParams) (JournalLine, error) {
+	row := q.db.QueryRowContext(ctx, createJournalLine,
+		arg.JournalEntryID,
+		arg.LedgerAccountID,
+		arg.Debit,
+		arg.Credit,
+	)
+	var i JournalLine
+	err := row.Scan(
+		&i.ID,
+		&i.JournalEntryID,
+		&i.LedgerAccountID,
+		&i.Debit,
+		&i.Credit,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createLedgerAccount = `-- name: CreateLedgerAccount :one
+INSERT INTO ledger_accounts (
+  code,
+  name,
+  type,
+  currency,
+  account_id
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING id, code, name, type, currency, account_id, created_at
+`
+
+type CreateLedgerAccountParams struct {
+	Code      string            `json:"code"`
+	Name      string            `json:"name"`
+	Type      LedgerAccountType `json:"type"`
+	Currency  string            `json:"currency"`
+	AccountID sql.NullInt64     `json:"account_id"`
+}
+
+func (q *Queries) CreateLedgerAccount(ctx context.Context, arg CreateLedgerAccountParams) (LedgerAccount, error) {
+	row := q.db.QueryRowContext(ctx, createLedgerAccount,
+		arg.Code,
+		arg.Name,
+		arg.Type,
+		arg.Currency,
+		arg.AccountID,
+	)
+	var i LedgerAccount
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Name,
+		&i.Type,
+		&i.Currency,
+		&i.AccountID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getJournalEntry = `-- name: GetJournalEntry :one
+SELECT id, description, transfer_id, created_at, booking_date FROM journal_entries
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetJournalEntry(ctx context.Context, id int64) (JournalEntry, error) {
+	row := q.db.QueryRowContext(ctx, getJournalEntry, id)
+	var i JournalEntry
+	err := row.Scan(
+		&i.ID,
+		&i.Description,
+		&i.TransferID,
+		&i.CreatedAt,
+		&i.BookingDate,
+	)
+	return i, err
+}
+
+const getJournalEntryByTransfer = `-- name: GetJournalEntryByTransfer :one
+SELECT id, description, transfer_id, created_at, booking_date FROM journal_entries
+WHERE transfer_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetJournalEntryByTransfer(ctx context.Context, transferID sql.NullInt64) (JournalEntry, error) {
+	row := q.db.QueryRowContext(ctx, getJournalEntryByTransfer, transferID)
+	var i JournalEntry
+	err := row.Scan(
+		&i.ID,
+		&i.Description,
+		&i.TransferID,
+		&i.CreatedAt,
+		&i.BookingDate,
+	)
+	return i, err
+}
+
+const getLedgerAccount = `-- name: GetLedgerAccount :one
+SELECT id, code, name, type, currency, account_id, created_at FROM ledger_accounts
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetLedgerAccount(ctx context.Context, id int64) (LedgerAccount, error) {
+	row := q.db.QueryRowContext(ctx, getLedgerAccount, id)
+	var i LedgerAccount
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Name,
+		&i.Type,
+		&i.Currency,
+		&i.AccountID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLedgerAccountByAccountID = `-- name: GetLedgerAccountByAccountID :one
+SELECT id, code, name, type, currency, account_id, created_at FROM ledger_accounts
+WHERE account_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetLedgerAccountByAccountID(ctx context.Context, accountID sql.NullInt64) (LedgerAccount, error) {
+	row := q.db.QueryRowContext(ctx, getLedgerAccountByAccountID, accountID)
+	var i LedgerAccount
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Name,
+		&i.Type,
+		&i.Currency,
+		&i.AccountID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLedgerAccountByCode = `-- name: GetLedgerAccountByCode :one
+SELECT id, code, name, type, currency, account_id, created_at FROM ledger_accounts
+WHERE code = $1 LIMIT 1
+`
+
+func (q *Queries) GetLedgerAccountByCode(ctx context.Context, code string) (LedgerAccount, error) {
+	row := q.db.QueryRowContext(ctx, getLedgerAccountByCode, code)
+	var i LedgerAccount
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Name,
+		&i.Type,
+		&i.Currency,
+		&i.AccountID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLedgerAccountTotals = `-- name: GetLedgerAccountTotals :one
+SELECT
+  COALESCE(sum(debit), 0)::bigint AS debits,
+  COALESCE(sum(credit), 0)::bigint AS credits
+FROM journal_lines
+WHERE ledger_account_id = $1
+`
+
+type GetLedgerAccountTotalsRow struct {
+	Debits  int64 `json:"debits"`
+	Credits int64 `json:"credits"`
+}
+
+func (q *Queries) GetLedgerAccountTotals(ctx context.Context, ledgerAccountID int64) (GetLedgerAccountTotalsRow, error) {
+	row := q.db.QueryRowContext(ctx, getLedgerAccountTotals, ledgerAccountID)
+	var i GetLedgerAccountTotalsRow
+	err := row.Scan(&i.Debits, &i.Credits)
+	return i, err
+}
+
+const listJournalLines = `-- name: ListJournalLines :many
+SELECT id, journal_entry_id, ledger_account_id, debit, credit, created_at FROM journal_lines
+WHERE journal_entry_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListJournalLines(ctx context.Context, journalEntryID int64) ([]JournalLine, error) {
+	rows, err := q.db.QueryContext(ctx, listJournalLines, journalEntryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []JournalLine{}
+	for rows.Next() {
+		var i JournalLine
+		if err := rows.Scan(
+			&i.ID,
+			&i.JournalEntryID,
+			&i.LedgerAccountID,
+			&i.Debit,
+			&i.Credit,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLedgerAccounts = `-- name: ListLedgerAccounts :many
+SELECT id, code, name, type, currency, account_id, created_at FROM ledger_accounts
+ORDER BY code
+`
+
+func (q *Queries) ListLedgerAccounts(ctx context.Context) ([]LedgerAccount, error) {
+	rows, err := q.db.QueryContext(ctx, listLedgerAccounts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []LedgerAccount{}
+	for rows.Next() {
+		var i LedgerAccount
+		if err := rows.Scan(
+			&i.ID,
+			&i.Code,
+			&i.Name,
+			&i.Type,
+			&i.Currency,
+			&i.AccountID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,189 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"simplebank/util"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomLedgerAccount(t *testing.T, accountType LedgerAccountType, currency string) LedgerAccount {
+	arg := CreateLedgerAccountParams{
+		Code:     util.RandomString(10),
+		Name:     util.RandomOwner(),
+		Type:     accountType,
+		Currency: currency,
+	}
+
+	ledgerAccount, err := testQueries.CreateLedgerAccount(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, ledgerAccount.ID)
+	require.Equal(t, arg.Code, ledgerAccount.Code)
+	require.Equal(t, arg.Type, ledgerAccount.Type)
+	require.Equal(t, arg.Currency, ledgerAccount.Currency)
+	require.False(t, ledgerAccount.AccountID.Valid)
+
+	return ledgerAccount
+}
+
+func TestChartOfAccounts(t *testing.T) {
+	for _, currency := range []string{util.USD, util.EUR, util.TWD} {
+		cash, err := testQueries.GetLedgerAccountByCode(context.Background(), "1000-"+currency)
+		require.NoError(t, err)
+		require.Equal(t, LedgerAccountTypeAsset, cash.Type)
+		require.Equal(t, currency, cash.Currency)
+	}
+}
+
+func TestPostJournalTx(t *testing.T) {
+	store := NewStore(testDB)
+	expense := createRandomLedgerAccount(t, LedgerAccountTypeExpense, util.USD)
+	cash := createRandomLedgerAccount(t, LedgerAccountTypeAsset, util.USD)
+
+	result, err := store.PostJournalTx(context.Background(), PostJournalTxParams{
+		Description: "Office rent",
+		Lines: []JournalLineParams{
+			{LedgerAccountID: expense.ID, Debit: 1000},
+			{LedgerAccountID: cash.ID, Credit: 1000},
+		},
+	})
+	require.NoError(t, err)
+	require.NotZero(t, result.JournalEntry.ID)
+	require.Equal(t, "Office rent", result.JournalEntry.Description)
+	require.False(t, result.JournalEntry.TransferID.Valid)
+	require.Len(t, result.Lines, 2)
+
+	totals, err := store.GetLedgerAccountTotals(context.Background(), expense.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), totals.Debits)
+	require.Zero(t, totals.Credits)
+}
+
+func TestPostJournalTxUnbalanced(t *testing.T) {
+	store := NewStore(testDB)
+	expense := createRandomLedgerAccount(t, LedgerAccountTypeExpense, util.USD)
+	cash := createRandomLedgerAccount(t, LedgerAccountTypeAsset, util.USD)
+
+	testCases := []struct {
+		name  string
+		lines []JournalLineParams
+	}{
+		{
+			name:  "SingleLine",
+			lines: []JournalLineParams{{LedgerAccountID: cash.ID, Debit: 10}},
+		},
+		{
+			name: "DebitsNotCredits",
+			lines: []JournalLineParams{
+				{LedgerAccountID: expense.ID, Debit: 10},
+				{LedgerAccountID: cash.ID, Credit: 9},
+			},
+		},
+		{
+			name: "DebitAndCredit",
+			lines: []JournalLineParams{
+				{LedgerAccountID: expense.ID, Debit: 10, Credit: 10},
+				{LedgerAccountID: cash.ID, Credit: 0},
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := store.PostJournalTx(context.Background(), PostJournalTxParams{
+				Description: tc.name,
+				Lines:       tc.lines,
+			})
+			require.ErrorIs(t, err, ErrUnbalancedJournal)
+		})
+	}
+}
+
+func TestJournalBalancedConstraint(t *testing.T) {
+	store := NewStore(testDB).(*SQLStore)
+	usd := createRandomLedgerAccount(t, LedgerAccountTypeAsset, util.USD)
+	eur := createRandomLedgerAccount(t, LedgerAccountTypeLiability, util.EUR)
+
+	// skip the checks of postJournal, the database must reject the entry on its own
+	err := store.execTx(context.Background(), func(ctx context.Context, q *Queries) error {
+		entry, err := q.CreateJournalEntry(ctx, CreateJournalEntryParams{Description: "one-sided"})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateJournalLine(ctx, CreateJournalLineParams{
+			JournalEntryID:  entry.ID,
+			LedgerAccountID: usd.ID,
+			Debit:           10,
+		})
+		return err
+	})
+	requireCheckViolation(t, err)
+
+	// debits equal credits in total but not per currency
+	err = store.execTx(context.Background(), func(ctx context.Context, q *Queries) error {
+		_, err := postJournal(ctx, q, PostJournalTxParams{
+			Description: "cross-currency",
+			Lines: []JournalLineParams{
+				{LedgerAccountID: usd.ID, Debit: 10},
+				{LedgerAccountID: eur.ID, Credit: 10},
+			},
+		})
+		return err
+	})
+	requireCheckViolation(t, err)
+
+	totals, err := store.GetLedgerAccountTotals(context.Background(), usd.ID)
+	require.NoError(t, err)
+	require.Zero(t, totals.Debits)
+}
+
+func TestJournalAppendOnly(t *testing.T) {
+	store := NewStore(testDB)
+	expense := createRandomLedgerAccount(t, LedgerAccountTypeExpense, util.TWD)
+	cash := createRandomLedgerAccount(t, LedgerAccountTypeAsset, util.TWD)
+
+	result, err := store.PostJournalTx(context.Background(), PostJournalTxParams{
+		Description: "Stationery",
+		Lines: []JournalLineParams{
+			{LedgerAccountID: expense.ID, Debit: 50},
+			{LedgerAccountID: cash.ID, Credit: 50},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = testDB.ExecContext(context.Background(), "UPDATE journal_lines SET debit = 60 WHERE id = $1", result.Lines[0].ID)
+	require.Error(t, err)
+
+	_, err = testDB.ExecContext(context.Background(), "DELETE FROM journal_entries WHERE id = $1", result.JournalEntry.ID)
+	require.Error(t, err)
+}
+
+func TestGetJournalEntryByTransfer(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccountInCurrency(t, util.EUR)
+	account2 := createRandomAccountInCurrency(t, util.EUR)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	entry, err := store.GetJournalEntryByTransfer(context.Background(), sql.NullInt64{Int64: result.Transfer.ID, Valid: true})
+	require.NoError(t, err)
+	require.Equal(t, result.JournalEntry.ID, entry.ID)
+}
+
+func requireCheckViolation(t *testing.T, err error) {
+	require.Error(t, err)
+	pqErr, ok := err.(*pq.Error)
+	require.True(t, ok, "expected a pq error, got %v", err)
+	require.Equal(t, "check_violation", pqErr.Code.Name())
+}
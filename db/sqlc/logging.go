@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"simplebank/logging"
+	"strings"
+	"time"
+)
+
+// queries taking longer than this are logged as slow
+const slowQueryThreshold = 200 * time.Millisecond
+
+// loggingDB logs slow queries with the logger of the context, so they carry the
+// request id of the request that ran them
+type loggingDB struct {
+	DBTX
+}
+
+func (db loggingDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	result, err := db.DBTX.ExecContext(ctx, query, args...)
+	logQuery(ctx, query, start, err)
+	return result, err
+}
+
+func (db loggingDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := db.DBTX.QueryContext(ctx, query, args...)
+	logQuery(ctx, query, start, err)
+	return rows, err
+}
+
+func (db loggingDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := db.DBTX.QueryRowContext(ctx, query, args...)
+	logQuery(ctx, query, start, row.Err())
+	return row
+}
+
+func logQuery(ctx context.Context, query string, start time.Time, err error) {
+	duration := time.Since(start)
+	if duration < slowQueryThreshold {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("query", queryName(query)),
+		slog.Duration("duration", duration),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	logging.FromContext(ctx).LogAttrs(ctx, slog.LevelWarn, "slow query", attrs...)
+}
+
+// queryName returns the sqlc name of a generated query ("-- name: GetAccount :one"),
+// or the first line of a hand written one
+func queryName(query string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(query), "\n")
+	if name, ok := strings.CutPrefix(line, "-- name: "); ok {
+		name, _, _ = strings.Cut(name, " ")
+		return name
+	}
+	return line
+}
@@ -0,0 +1,13 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryName(t *testing.T) {
+	require.Equal(t, "GetAccount", queryName(getAccount))
+	require.Equal(t, "TransferTx", queryName("-- name: TransferTx :exec\nSELECT 1"))
+	require.Equal(t, "LOCK TABLE journal_entries IN SHARE MODE", queryName("LOCK TABLE journal_entries IN SHARE MODE"))
+}
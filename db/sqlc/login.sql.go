@@ -0,0 +1,80 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.16.0
+// source: login.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createLogin = `-- name: CreateLogin :one
+INSERT INTO logins (
+  username,
+  client_ip,
+  user_agent
+) VALUES (
+  $1, $2, $3
+) RETURNING id, username, client_ip, user_agent, created_at
+`
+
+type CreateLoginParams struct {
+	Username  string `json:"username"`
+	ClientIp  string `json:"client_ip"`
+	UserAgent string `json:"user_agent"`
+}
+
+func (q *Queries) CreateLogin(ctx context.Context, arg CreateLoginParams) (Login, error) {
+	row := q.db.QueryRowContext(ctx, createLogin, arg.Username, arg.ClientIp, arg.UserAgent)
+	var i Login
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.ClientIp,
+		&i.UserAgent,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listLogins = `-- name: ListLogins :many
+SELECT id, username, client_ip, user_agent, created_at FROM logins
+WHERE username = $1
+ORDER BY id DESC
+LIMIT $2
+`
+
+type ListLoginsParams struct {
+	Username string `json:"username"`
+	Limit    int32  `json:"limit"`
+}
+
+func (q *Queries) ListLogins(ctx context.Context, arg ListLoginsParams) ([]Login, error) {
+	rows, err := q.db.QueryContext(ctx, listLogins, arg.Username, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Login{}
+	for rows.Next() {
+		var i Login
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.ClientIp,
+			&i.UserAgent,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
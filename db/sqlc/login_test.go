@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListLogins(t *testing.T) {
+	user := createRandomUser(t)
+
+	var created []Login
+	for _, ip := range []string{"192.0.2.1", "198.51.100.7", "203.0.113.9"} {
+		login, err := testQueries.CreateLogin(context.Background(), CreateLoginParams{
+			Username:  user.Username,
+			ClientIp:  ip,
+			UserAgent: "test-agent",
+		})
+		require.NoError(t, err)
+		require.NotZero(t, login.ID)
+		require.NotZero(t, login.CreatedAt)
+		created = append(created, login)
+	}
+
+	logins, err := testQueries.ListLogins(context.Background(), ListLoginsParams{
+		Username: user.Username,
+		Limit:    2,
+	})
+	require.NoError(t, err)
+	require.Len(t, logins, 2)
+	// newest first
+	require.Equal(t, created[2].ID, logins[0].ID)
+	require.Equal(t, created[1].ID, logins[1].ID)
+	require.Equal(t, "198.51.100.7", logins[1].ClientIp)
+}
@@ -5,9 +5,58 @@
 package db
 
 import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
 	"time"
+
+	"github.com/google/uuid"
+)
+
+type LedgerAccountType string
+
+const (
+	LedgerAccountTypeAsset     LedgerAccountType = "asset"
+	LedgerAccountTypeLiability LedgerAccountType = "liability"
+	LedgerAccountTypeIncome    LedgerAccountType = "income"
+	LedgerAccountTypeExpense   LedgerAccountType = "expense"
 )
 
+func (e *LedgerAccountType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LedgerAccountType(s)
+	case string:
+		*e = LedgerAccountType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LedgerAccountType: %T", src)
+	}
+	return nil
+}
+
+type NullLedgerAccountType struct {
+	LedgerAccountType LedgerAccountType
+	Valid             bool // Valid is true if LedgerAccountType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLedgerAccountType) Scan(value interface{}) error {
+	if value == nil {
+		ns.LedgerAccountType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LedgerAccountType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLedgerAccountType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return ns.LedgerAccountType, nil
+}
+
 type Account struct {
 	ID        int64     `json:"id"`
 	Owner     string    `json:"owner"`
@@ -16,6 +65,46 @@ type Account struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type ApiKey struct {
+	ID         int64     `json:"id"`
+	Username   string    `json:"username"`
+	Name       string    `json:"name"`
+	Prefix     string    `json:"prefix"`
+	KeyHash    string    `json:"key_hash"`
+	Scopes     []string  `json:"scopes"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	RevokedAt  time.Time `json:"revoked_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type Consent struct {
+	ID          int64     `json:"id"`
+	ClientID    string    `json:"client_id"`
+	Username    string    `json:"username"`
+	AccountIds  []int64   `json:"account_ids"`
+	Permissions []string  `json:"permissions"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	RevokedAt   time.Time `json:"revoked_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type DailyClose struct {
+	BusinessDate time.Time    `json:"business_date"`
+	PreviousDate sql.NullTime `json:"previous_date"`
+	// HMAC-SHA256 of the trial balance of the day
+	Signature string    `json:"signature"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type EmailVerification struct {
+	TokenHash string    `json:"token_hash"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Entry struct {
 	ID        int64 `json:"id"`
 	AccountID int64 `json:"account_id"`
@@ -24,6 +113,111 @@ type Entry struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type JournalEntry struct {
+	ID          int64         `json:"id"`
+	Description string        `json:"description"`
+	TransferID  sql.NullInt64 `json:"transfer_id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	BookingDate time.Time     `json:"booking_date"`
+}
+
+type JournalLine struct {
+	ID              int64 `json:"id"`
+	JournalEntryID  int64 `json:"journal_entry_id"`
+	LedgerAccountID int64 `json:"ledger_account_id"`
+	// exactly one of debit and credit is positive
+	Debit     int64     `json:"debit"`
+	Credit    int64     `json:"credit"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LedgerAccount struct {
+	ID       int64             `json:"id"`
+	Code     string            `json:"code"`
+	Name     string            `json:"name"`
+	Type     LedgerAccountType `json:"type"`
+	Currency string            `json:"currency"`
+	// customer account this ledger account is the deposit liability of
+	AccountID sql.NullInt64 `json:"account_id"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type LedgerBalance struct {
+	BusinessDate    time.Time `json:"business_date"`
+	LedgerAccountID int64     `json:"ledger_account_id"`
+	Opening         int64     `json:"opening"`
+	Debits          int64     `json:"debits"`
+	Credits         int64     `json:"credits"`
+	// debit balance, negative for a credit balance
+	Closing int64 `json:"closing"`
+}
+
+type Login struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	ClientIp  string    `json:"client_ip"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OauthAuthorizationCode struct {
+	CodeHash      string    `json:"code_hash"`
+	ClientID      string    `json:"client_id"`
+	Username      string    `json:"username"`
+	RedirectUri   string    `json:"redirect_uri"`
+	Scopes        []string  `json:"scopes"`
+	CodeChallenge string    `json:"code_challenge"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type OauthClient struct {
+	ID           string    `json:"id"`
+	Owner        string    `json:"owner"`
+	Name         string    `json:"name"`
+	SecretHash   string    `json:"secret_hash"`
+	RedirectUris []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type PasswordResetToken struct {
+	TokenHash string    `json:"token_hash"`
+	Username  string    `json:"username"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PaymentBatch struct {
+	ID        int64     `json:"id"`
+	Owner     string    `json:"owner"`
+	MessageID string    `json:"message_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RateLimitBucket struct {
+	Key       string    `json:"key"`
+	Tokens    float64   `json:"tokens"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type RecoveryCode struct {
+	Username  string    `json:"username"`
+	CodeHash  string    `json:"code_hash"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	Username   string    `json:"username"`
+	ClientIp   string    `json:"client_ip"`
+	UserAgent  string    `json:"user_agent"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	RevokedAt  time.Time `json:"revoked_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
 type Transfer struct {
 	ID            int64 `json:"id"`
 	FromAccountID int64 `json:"from_account_id"`
@@ -34,10 +228,27 @@ type Transfer struct {
 }
 
 type User struct {
-	Username          string    `json:"username"`
-	HashedPassword    string    `json:"hashed_password"`
-	FullName          string    `json:"full_name"`
-	Email             string    `json:"email"`
-	PasswordChangedAt time.Time `json:"password_changed_at"`
-	CreatedAt         time.Time `json:"created_at"`
+	Username            string    `json:"username"`
+	HashedPassword      string    `json:"hashed_password"`
+	FullName            string    `json:"full_name"`
+	Email               string    `json:"email"`
+	PasswordChangedAt   time.Time `json:"password_changed_at"`
+	CreatedAt           time.Time `json:"created_at"`
+	FailedLoginAttempts int32     `json:"failed_login_attempts"`
+	LockedUntil         time.Time `json:"locked_until"`
+	TotpSecret          string    `json:"totp_secret"`
+	TotpEnabled         bool      `json:"totp_enabled"`
+	TotpLastStep        int64     `json:"totp_last_step"`
+	EmailVerified       bool      `json:"email_verified"`
+}
+
+type UserAuditLog struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	Field     string    `json:"field"`
+	OldValue  string    `json:"old_value"`
+	NewValue  string    `json:"new_value"`
+	ClientIp  string    `json:"client_ip"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
 }
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomOAuthClient(t *testing.T, owner User) OauthClient {
+	arg := CreateOAuthClientParams{
+		ID:           uuid.NewString(),
+		Owner:        owner.Username,
+		Name:         util.RandomOwner(),
+		SecretHash:   util.RandomString(64),
+		RedirectUris: []string{"https://app.example.com/callback"},
+		Scopes:       []string{"accounts:read"},
+	}
+
+	client, err := testQueries.CreateOAuthClient(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, client.ID)
+	require.Equal(t, arg.Owner, client.Owner)
+	require.Equal(t, arg.SecretHash, client.SecretHash)
+	require.Equal(t, arg.RedirectUris, client.RedirectUris)
+	require.Equal(t, arg.Scopes, client.Scopes)
+	require.NotZero(t, client.CreatedAt)
+	return client
+}
+
+func createRandomOAuthAuthorizationCode(t *testing.T, client OauthClient, expiresAt time.Time) OauthAuthorizationCode {
+	arg := CreateOAuthAuthorizationCodeParams{
+		CodeHash:      util.RandomString(64),
+		ClientID:      client.ID,
+		Username:      createRandomUser(t).Username,
+		RedirectUri:   client.RedirectUris[0],
+		Scopes:        client.Scopes,
+		CodeChallenge: util.RandomString(43),
+		ExpiresAt:     expiresAt,
+	}
+
+	code, err := testQueries.CreateOAuthAuthorizationCode(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.CodeHash, code.CodeHash)
+	require.Equal(t, arg.Username, code.Username)
+	require.Equal(t, arg.CodeChallenge, code.CodeChallenge)
+	return code
+}
+
+func TestGetOAuthClient(t *testing.T) {
+	client1 := createRandomOAuthClient(t, createRandomUser(t))
+
+	client2, err := testQueries.GetOAuthClient(context.Background(), client1.ID)
+	require.NoError(t, err)
+	require.Equal(t, client1.Name, client2.Name)
+	require.Equal(t, client1.RedirectUris, client2.RedirectUris)
+
+	_, err = testQueries.GetOAuthClient(context.Background(), uuid.NewString())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestListOAuthClients(t *testing.T) {
+	owner := createRandomUser(t)
+	client1 := createRandomOAuthClient(t, owner)
+	client2 := createRandomOAuthClient(t, owner)
+	createRandomOAuthClient(t, createRandomUser(t))
+
+	clients, err := testQueries.ListOAuthClients(context.Background(), owner.Username)
+	require.NoError(t, err)
+	require.Len(t, clients, 2)
+	require.ElementsMatch(t, []string{client1.ID, client2.ID}, []string{clients[0].ID, clients[1].ID})
+}
+
+func TestUseOAuthAuthorizationCode(t *testing.T) {
+	client := createRandomOAuthClient(t, createRandomUser(t))
+	code1 := createRandomOAuthAuthorizationCode(t, client, time.Now().Add(time.Minute))
+
+	code2, err := testQueries.UseOAuthAuthorizationCode(context.Background(), code1.CodeHash)
+	require.NoError(t, err)
+	require.Equal(t, code1.Username, code2.Username)
+	require.Equal(t, code1.Scopes, code2.Scopes)
+
+	// a code only works once
+	_, err = testQueries.UseOAuthAuthorizationCode(context.Background(), code1.CodeHash)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	expired := createRandomOAuthAuthorizationCode(t, client, time.Now().Add(-time.Minute))
+	_, err = testQueries.UseOAuthAuthorizationCode(context.Background(), expired.CodeHash)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// ResetPasswordTxParams contain the input parameters of the password reset transaction
+type ResetPasswordTxParams struct {
+	TokenHash         string
+	HashedPassword    string
+	PasswordChangedAt time.Time
+}
+
+// ResetPasswordTx sets the password of the user a reset token was issued to.
+// The token and every other outstanding one of the user are used up, and the
+// failed logins are forgiven. Unknown or expired tokens return sql.ErrNoRows.
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (User, error) {
+	var user User
+
+	ctx, span := startTxSpan(ctx, "ResetPasswordTx")
+	defer span.End()
+
+	err := store.execTx(ctx, func(ctx context.Context, q *Queries) error {
+		resetToken, err := q.UsePasswordResetToken(ctx, arg.TokenHash)
+		if err != nil {
+			return err
+		}
+
+		user, err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			Username:          resetToken.Username,
+			HashedPassword:    arg.HashedPassword,
+			PasswordChangedAt: arg.PasswordChangedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := q.DeletePasswordResetTokens(ctx, user.Username); err != nil {
+			return err
+		}
+
+		return q.ResetFailedLogins(ctx, user.Username)
+	})
+
+	recordError(span, err)
+	return user, err
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPasswordResetToken(t *testing.T, username string, expiresAt time.Time) PasswordResetToken {
+	resetToken, err := testQueries.CreatePasswordResetToken(context.Background(), CreatePasswordResetTokenParams{
+		TokenHash: util.RandomString(64),
+		Username:  username,
+		ExpiresAt: expiresAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, username, resetToken.Username)
+	require.WithinDuration(t, expiresAt, resetToken.ExpiresAt, time.Second)
+	return resetToken
+}
+
+func TestResetPasswordTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	_, err := testQueries.RecordFailedLogin(context.Background(), RecordFailedLoginParams{
+		Username:          user.Username,
+		MaxAttempts:       5,
+		LockoutSeconds:    60,
+		MaxLockoutSeconds: 3600,
+	})
+	require.NoError(t, err)
+
+	resetToken := createRandomPasswordResetToken(t, user.Username, time.Now().Add(time.Minute))
+	other := createRandomPasswordResetToken(t, user.Username, time.Now().Add(time.Minute))
+
+	changedAt := time.Now()
+	updated, err := store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash:         resetToken.TokenHash,
+		HashedPassword:    "new-hash",
+		PasswordChangedAt: changedAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "new-hash", updated.HashedPassword)
+	require.WithinDuration(t, changedAt, updated.PasswordChangedAt, time.Second)
+	require.Zero(t, updated.FailedLoginAttempts)
+
+	user, err = testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Zero(t, user.FailedLoginAttempts)
+
+	// the token is single-use and the other ones of the user are gone too
+	for _, tokenHash := range []string{resetToken.TokenHash, other.TokenHash} {
+		_, err = store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+			TokenHash:         tokenHash,
+			HashedPassword:    "other-hash",
+			PasswordChangedAt: time.Now(),
+		})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	}
+}
+
+func TestResetPasswordTxExpired(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	resetToken := createRandomPasswordResetToken(t, user.Username, time.Now().Add(-time.Second))
+
+	_, err := store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash:         resetToken.TokenHash,
+		HashedPassword:    "new-hash",
+		PasswordChangedAt: time.Now(),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	got, err := testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.HashedPassword, got.HashedPassword)
+}
+
+func TestGetUserByEmail(t *testing.T) {
+	user := createRandomUser(t)
+
+	got, err := testQueries.GetUserByEmail(context.Background(), user.Email)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, got.Username)
+
+	_, err = testQueries.GetUserByEmail(context.Background(), util.RandomEmail())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,36 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.16.0
+// source: payment_batch.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createPaymentBatch = `-- name: CreatePaymentBatch :one
+INSERT INTO payment_batches (
+  owner,
+  message_id
+) VALUES (
+  $1, $2
+) RETURNING id, owner, message_id, created_at
+`
+
+type CreatePaymentBatchParams struct {
+	Owner     string `json:"owner"`
+	MessageID string `json:"message_id"`
+}
+
+func (q *Queries) CreatePaymentBatch(ctx context.Context, arg CreatePaymentBatchParams) (PaymentBatch, error) {
+	row := q.db.QueryRowContext(ctx, createPaymentBatch, arg.Owner, arg.MessageID)
+	var i PaymentBatch
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.MessageID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"context"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePaymentBatch(t *testing.T) {
+	user := createRandomUser(t)
+	arg := CreatePaymentBatchParams{
+		Owner:     user.Username,
+		MessageID: util.RandomString(12),
+	}
+
+	batch, err := testQueries.CreatePaymentBatch(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, batch.ID)
+	require.Equal(t, arg.Owner, batch.Owner)
+	require.Equal(t, arg.MessageID, batch.MessageID)
+	require.WithinDuration(t, time.Now(), batch.CreatedAt, time.Second)
+
+	_, err = testQueries.CreatePaymentBatch(context.Background(), arg)
+	require.Error(t, err)
+	pqErr, ok := err.(*pq.Error)
+	require.True(t, ok)
+	require.Equal(t, "unique_violation", pqErr.Code.Name())
+}
@@ -6,24 +6,125 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
 	AddAccountBalancd(ctx context.Context, arg AddAccountBalancdParams) (Account, error)
+	CountRecoveryCodes(ctx context.Context, username string) (int64, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateConsent(ctx context.Context, arg CreateConsentParams) (Consent, error)
+	CreateDailyClose(ctx context.Context, arg CreateDailyCloseParams) (DailyClose, error)
+	CreateEmailVerification(ctx context.Context, arg CreateEmailVerificationParams) (EmailVerification, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateJournalEntry(ctx context.Context, arg CreateJournalEntryParams) (JournalEntry, error)
+	CreateJournalLine(ctx context.Context, arg CreateJournalLineParams) (JournalLine, error)
+	CreateLedgerAccount(ctx context.Context, arg CreateLedgerAccountParams) (LedgerAccount, error)
+	CreateLogin(ctx context.Context, arg CreateLoginParams) (Login, error)
+	CreateOAuthAuthorizationCode(ctx context.Context, arg CreateOAuthAuthorizationCodeParams) (OauthAuthorizationCode, error)
+	CreateOAuthClient(ctx context.Context, arg CreateOAuthClientParams) (OauthClient, error)
+	CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error)
+	CreatePaymentBatch(ctx context.Context, arg CreatePaymentBatchParams) (PaymentBatch, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error
+	// created_at is the issue time of the token, like password_changed_at it
+	// comes from the clock of the app
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateUserAuditEntry(ctx context.Context, arg CreateUserAuditEntryParams) (UserAuditLog, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteEmailVerifications(ctx context.Context, username string) error
+	DeletePasswordResetTokens(ctx context.Context, username string) error
+	DeleteRateLimitBucketsBefore(ctx context.Context, updatedBefore time.Time) error
+	DeleteRecoveryCodes(ctx context.Context, username string) error
+	EnableTOTP(ctx context.Context, arg EnableTOTPParams) (User, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (ApiKey, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetActiveConsent(ctx context.Context, arg GetActiveConsentParams) (Consent, error)
+	// the balance of the account at from_time, its balance less the entries booked
+	// since, both read in the one snapshot of the statement
+	GetBalanceAt(ctx context.Context, arg GetBalanceAtParams) (int64, error)
+	GetDailyClose(ctx context.Context, businessDate time.Time) (DailyClose, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetJournalEntry(ctx context.Context, id int64) (JournalEntry, error)
+	GetJournalEntryByTransfer(ctx context.Context, transferID sql.NullInt64) (JournalEntry, error)
+	GetLatestDailyClose(ctx context.Context) (DailyClose, error)
+	GetLedgerAccount(ctx context.Context, id int64) (LedgerAccount, error)
+	GetLedgerAccountByAccountID(ctx context.Context, accountID sql.NullInt64) (LedgerAccount, error)
+	GetLedgerAccountByCode(ctx context.Context, code string) (LedgerAccount, error)
+	GetLedgerAccountTotals(ctx context.Context, ledgerAccountID int64) (GetLedgerAccountTotalsRow, error)
+	GetOAuthClient(ctx context.Context, id string) (OauthClient, error)
+	// returns the tokens of the bucket refilled up to now, without taking any
+	GetRateLimitTokens(ctx context.Context, arg GetRateLimitTokensParams) (float64, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTrialBalance(ctx context.Context, businessDate time.Time) ([]GetTrialBalanceRow, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetUserForUpdate(ctx context.Context, username string) (User, error)
+	ListAPIKeys(ctx context.Context, username string) ([]ApiKey, error)
+	// account_ids narrows the list down to the accounts of a consent, NULL lists
+	// them all
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListAccountsBefore(ctx context.Context, arg ListAccountsBeforeParams) ([]Account, error)
+	ListConsents(ctx context.Context, username string) ([]Consent, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListEntriesBefore(ctx context.Context, arg ListEntriesBeforeParams) ([]Entry, error)
+	ListEntriesInPeriod(ctx context.Context, arg ListEntriesInPeriodParams) ([]Entry, error)
+	ListJournalLines(ctx context.Context, journalEntryID int64) ([]JournalLine, error)
+	ListLedgerAccounts(ctx context.Context) ([]LedgerAccount, error)
+	ListLedgerBalances(ctx context.Context, businessDate time.Time) ([]LedgerBalance, error)
+	ListLogins(ctx context.Context, arg ListLoginsParams) ([]Login, error)
+	ListOAuthClients(ctx context.Context, owner string) ([]OauthClient, error)
+	// sessions a token can still be used in: not revoked, not expired and not
+	// opened before the last password change, the check the token of the session
+	// gets on every request
+	ListSessions(ctx context.Context, username string) ([]Session, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListTransfersBefore(ctx context.Context, arg ListTransfersBeforeParams) ([]Transfer, error)
+	ListTransfersInPeriod(ctx context.Context, arg ListTransfersInPeriodParams) ([]Transfer, error)
+	ListUserAuditEntries(ctx context.Context, arg ListUserAuditEntriesParams) ([]UserAuditLog, error)
+	// Counts a wrong password. From max_attempts failures on the user is locked,
+	// for lockout_seconds doubled with each further failure, up to max_lockout_seconds.
+	// A max_attempts of 0 never locks.
+	RecordFailedLogin(ctx context.Context, arg RecordFailedLoginParams) (User, error)
+	// Stores a new hash of the same password, the tokens of the user stay valid.
+	// A password changed meanwhile is left alone.
+	RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) error
+	ResetFailedLogins(ctx context.Context, username string) error
+	RevokeAPIKey(ctx context.Context, arg RevokeAPIKeyParams) (ApiKey, error)
+	RevokeConsent(ctx context.Context, arg RevokeConsentParams) (Consent, error)
+	RevokeSession(ctx context.Context, arg RevokeSessionParams) (Session, error)
+	RollLedgerBalances(ctx context.Context, arg RollLedgerBalancesParams) ([]LedgerBalance, error)
+	SetTOTPSecret(ctx context.Context, arg SetTOTPSecretParams) (User, error)
+	// refills the bucket for the time since its last update and takes cost
+	// tokens, returns no row when fewer are left
+	TakeRateLimitToken(ctx context.Context, arg TakeRateLimitTokenParams) (float64, error)
+	// last_used_at is only kept to the minute, a busy key doesn't write on every
+	// request
+	TouchAPIKey(ctx context.Context, id int64) error
+	// last_seen_at is only kept to the minute, a busy session doesn't write on
+	// every request
+	TouchSession(ctx context.Context, id uuid.UUID) error
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	// Sets the fields that are not null. A new email address has to be verified
+	// again.
+	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error)
+	UseEmailVerification(ctx context.Context, tokenHash string) (EmailVerification, error)
+	UseOAuthAuthorizationCode(ctx context.Context, codeHash string) (OauthAuthorizationCode, error)
+	UsePasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error)
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error)
+	// Accepts the step of a code only once, concurrent requests with the same code
+	// can't both succeed
+	UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error)
+	// Only verifies the address the email was sent to
+	VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
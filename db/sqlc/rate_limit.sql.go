@@ -0,0 +1,77 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.16.0
+// source: rate_limit.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const deleteRateLimitBucketsBefore = `-- name: DeleteRateLimitBucketsBefore :exec
+DELETE FROM rate_limit_buckets
+WHERE updated_at < $1
+`
+
+func (q *Queries) DeleteRateLimitBucketsBefore(ctx context.Context, updatedBefore time.Time) error {
+	_, err := q.db.ExecContext(ctx, deleteRateLimitBucketsBefore, updatedBefore)
+	return err
+}
+
+const getRateLimitTokens = `-- name: GetRateLimitTokens :one
+SELECT LEAST($1::float8, tokens + EXTRACT(EPOCH FROM now() - updated_at)::float8 * $2::float8)::float8 AS tokens
+FROM rate_limit_buckets
+WHERE key = $3
+`
+
+type GetRateLimitTokensParams struct {
+	Burst float64 `json:"burst"`
+	Rate  float64 `json:"rate"`
+	Key   string  `json:"key"`
+}
+
+// returns the tokens of the bucket refilled up to now, without taking any
+func (q *Queries) GetRateLimitTokens(ctx context.Context, arg GetRateLimitTokensParams) (float64, error) {
+	row := q.db.QueryRowContext(ctx, getRateLimitTokens, arg.Burst, arg.Rate, arg.Key)
+	var tokens float64
+	err := row.Scan(&tokens)
+	return tokens, err
+}
+
+const takeRateLimitToken = `-- name: TakeRateLimitToken :one
+INSERT INTO rate_limit_buckets AS b (
+  key,
+  tokens,
+  updated_at
+) VALUES (
+  $1, $2::float8 - $3::float8, now()
+)
+ON CONFLICT (key) DO UPDATE SET
+  tokens = LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * $4::float8) - $3::float8,
+  updated_at = now()
+WHERE LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * $4::float8) >= $3::float8
+RETURNING tokens
+`
+
+type TakeRateLimitTokenParams struct {
+	Key   string  `json:"key"`
+	Burst float64 `json:"burst"`
+	Cost  float64 `json:"cost"`
+	Rate  float64 `json:"rate"`
+}
+
+// refills the bucket for the time since its last update and takes cost
+// tokens, returns no row when fewer are left
+func (q *Queries) TakeRateLimitToken(ctx context.Context, arg TakeRateLimitTokenParams) (float64, error) {
+	row := q.db.QueryRowContext(ctx, takeRateLimitToken,
+		arg.Key,
+		arg.Burst,
+		arg.Cost,
+		arg.Rate,
+	)
+	var tokens float64
+	err := row.Scan(&tokens)
+	return tokens, err
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTakeRateLimitToken(t *testing.T) {
+	arg := TakeRateLimitTokenParams{
+		Key:   "test:" + util.RandomString(12),
+		Burst: 2,
+		// slow enough not to refill a whole token during the test
+		Rate: 0.001,
+		Cost: 1,
+	}
+
+	tokens, err := testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.InDelta(t, 1, tokens, 0.01)
+
+	tokens, err = testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.InDelta(t, 0, tokens, 0.01)
+
+	_, err = testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	tokens, err = testQueries.GetRateLimitTokens(context.Background(), GetRateLimitTokensParams{
+		Key:   arg.Key,
+		Burst: arg.Burst,
+		Rate:  arg.Rate,
+	})
+	require.NoError(t, err)
+	require.InDelta(t, 0, tokens, 0.01)
+}
+
+func TestTakeRateLimitTokens(t *testing.T) {
+	arg := TakeRateLimitTokenParams{
+		Key:   "test:" + util.RandomString(12),
+		Burst: 5,
+		Rate:  0.001,
+		Cost:  3,
+	}
+
+	tokens, err := testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.InDelta(t, 2, tokens, 0.01)
+
+	// fewer tokens than the cost are left, none are taken
+	_, err = testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	arg.Cost = 2
+	tokens, err = testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.InDelta(t, 0, tokens, 0.01)
+}
+
+func TestDeleteRateLimitBucketsBefore(t *testing.T) {
+	arg := TakeRateLimitTokenParams{Key: "test:" + util.RandomString(12), Burst: 5, Rate: 1, Cost: 1}
+	_, err := testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+
+	err = testQueries.DeleteRateLimitBucketsBefore(context.Background(), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+
+	_, err = testQueries.GetRateLimitTokens(context.Background(), GetRateLimitTokensParams{Key: arg.Key, Burst: arg.Burst, Rate: arg.Rate})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
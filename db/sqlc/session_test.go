@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomSession(t *testing.T, user User, duration time.Duration) Session {
+	arg := CreateSessionParams{
+		ID:        uuid.New(),
+		Username:  user.Username,
+		ClientIp:  "192.0.2.1",
+		UserAgent: util.RandomString(12),
+		ExpiresAt: time.Now().Add(duration),
+		CreatedAt: time.Now(),
+	}
+
+	session, err := testQueries.CreateSession(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, session.ID)
+	require.Equal(t, arg.Username, session.Username)
+	require.Equal(t, arg.ClientIp, session.ClientIp)
+	require.Equal(t, arg.UserAgent, session.UserAgent)
+	require.WithinDuration(t, arg.ExpiresAt, session.ExpiresAt, time.Second)
+	require.WithinDuration(t, arg.CreatedAt, session.CreatedAt, time.Second)
+	require.WithinDuration(t, time.Now(), session.LastSeenAt, time.Second)
+	require.True(t, session.RevokedAt.IsZero())
+	return session
+}
+
+func TestGetSession(t *testing.T) {
+	session1 := createRandomSession(t, createRandomUser(t), time.Hour)
+
+	session2, err := testQueries.GetSession(context.Background(), session1.ID)
+	require.NoError(t, err)
+	require.Equal(t, session1.ID, session2.ID)
+	require.Equal(t, session1.UserAgent, session2.UserAgent)
+
+	_, err = testQueries.GetSession(context.Background(), uuid.New())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestListSessions(t *testing.T) {
+	user := createRandomUser(t)
+	createRandomSession(t, user, -time.Minute)
+	changedBefore := createRandomSession(t, user, time.Hour)
+
+	_, err := testQueries.UpdateUserPassword(context.Background(), UpdateUserPasswordParams{
+		Username:          user.Username,
+		HashedPassword:    user.HashedPassword,
+		PasswordChangedAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	session1 := createRandomSession(t, user, time.Hour)
+	session2 := createRandomSession(t, user, time.Hour)
+	revoked := createRandomSession(t, user, time.Hour)
+	_, err = testQueries.RevokeSession(context.Background(), RevokeSessionParams{
+		ID:       revoked.ID,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+
+	// expired, revoked and older than the password change are all left out
+	sessions, err := testQueries.ListSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	for _, session := range sessions {
+		require.Contains(t, []uuid.UUID{session1.ID, session2.ID}, session.ID)
+		require.NotEqual(t, changedBefore.ID, session.ID)
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	user := createRandomUser(t)
+	session := createRandomSession(t, user, time.Hour)
+
+	// only the owner can revoke a session
+	_, err := testQueries.RevokeSession(context.Background(), RevokeSessionParams{
+		ID:       session.ID,
+		Username: createRandomUser(t).Username,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	revoked, err := testQueries.RevokeSession(context.Background(), RevokeSessionParams{
+		ID:       session.ID,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), revoked.RevokedAt, time.Second)
+
+	_, err = testQueries.RevokeSession(context.Background(), RevokeSessionParams{
+		ID:       session.ID,
+		Username: user.Username,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestTouchSession(t *testing.T) {
+	session := createRandomSession(t, createRandomUser(t), time.Hour)
+
+	// a session opened within the minute isn't written again
+	require.NoError(t, testQueries.TouchSession(context.Background(), session.ID))
+	touched, err := testQueries.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.Equal(t, session.LastSeenAt, touched.LastSeenAt)
+}
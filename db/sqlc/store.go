@@ -4,12 +4,23 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"simplebank/logging"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Store provides all functions to execute db queries and transactions
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	PostJournalTx(ctx context.Context, arg PostJournalTxParams) (PostJournalTxResult, error)
+	CloseDayTx(ctx context.Context, arg CloseDayTxParams) (CloseDayTxResult, error)
+	EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) (User, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (User, error)
+	VerifyEmailTx(ctx context.Context, tokenHash string) (User, error)
+	UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error)
+	Ping(ctx context.Context) error
+	SchemaVersion(ctx context.Context) (version int64, dirty bool, err error)
 }
 
 // SQLStore provides all functions to execute SQL queries and transactions
@@ -22,26 +33,39 @@ type SQLStore struct {
 func NewStore(db *sql.DB) Store {
 	return &SQLStore{
 		db:      db,
-		Queries: New(db),
+		Queries: New(tracingDB{loggingDB{db}}),
 	}
 }
 
-func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
+// execTx runs fn in a database transaction. fn gets the context of the
+// transaction span, the queries it makes with it are traced as its children
+func (store *SQLStore) execTx(ctx context.Context, fn func(context.Context, *Queries) error) (err error) {
+	ctx, span := startTxSpan(ctx, "execTx")
+	defer func() {
+		recordError(span, err)
+		span.End()
+	}()
+
 	tx, err := store.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	q := New(tx)
-	err = fn(q)
+	q := New(tracingDB{loggingDB{tx}})
+	err = fn(ctx, q)
 	if err != nil {
 		if rbErr := tx.Rollback(); rbErr != nil {
-			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+			err = fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
 		}
+		logging.FromContext(ctx).WarnContext(ctx, "transaction failed", "error", err)
 		return err
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "transaction commit failed", "error", err)
+		return err
+	}
+	return nil
 }
 
 // TransferTxParams contain the input parameters of the transfer transaction
@@ -53,18 +77,27 @@ type TransferTxParams struct {
 
 // TransferTxResult is the result of the transfer transaction
 type TransferTxResult struct {
-	Transfer    Transfer `json:"transfer"`
-	FromAccount Account  `json:"from_account"`
-	ToAccount   Account  `json:"to_account"`
-	FromEntry   Entry    `json:"from_entry"`
-	ToEntry     Entry    `json:"to_entry"`
+	Transfer     Transfer     `json:"transfer"`
+	FromAccount  Account      `json:"from_account"`
+	ToAccount    Account      `json:"to_account"`
+	FromEntry    Entry        `json:"from_entry"`
+	ToEntry      Entry        `json:"to_entry"`
+	JournalEntry JournalEntry `json:"journal_entry"`
 }
 
 // TransferTx performs a money transfer from one account to the other
+// and posts it to the general ledger
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
+	ctx, span := startTxSpan(ctx, "TransferTx",
+		attribute.Int64("transfer.from_account_id", arg.FromAccountID),
+		attribute.Int64("transfer.to_account_id", arg.ToAccountID),
+		attribute.Int64("transfer.amount", arg.Amount),
+	)
+	defer span.End()
+
 	var result TransferTxResult
 
-	err := store.execTx(ctx, func(q *Queries) error {
+	err := store.execTx(ctx, func(ctx context.Context, q *Queries) error {
 		var err error
 
 		// create a transfer record
@@ -95,10 +128,34 @@ func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (Tr
 		} else {
 			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
 		}
+		if err != nil {
+			return err
+		}
+
+		// post the transfer to the ledger, both accounts are locked by addMoney at this point
+		fromLedger, err := customerLedgerAccount(ctx, q, result.FromAccount)
+		if err != nil {
+			return err
+		}
+		toLedger, err := customerLedgerAccount(ctx, q, result.ToAccount)
+		if err != nil {
+			return err
+		}
+
+		journal, err := postJournal(ctx, q, PostJournalTxParams{
+			Description: fmt.Sprintf("Transfer %d", result.Transfer.ID),
+			TransferID:  sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+			Lines: []JournalLineParams{
+				{LedgerAccountID: fromLedger.ID, Debit: arg.Amount},
+				{LedgerAccountID: toLedger.ID, Credit: arg.Amount},
+			},
+		})
+		result.JournalEntry = journal.JournalEntry
 
 		return err
 	})
 
+	recordError(span, err)
 	return result, err
 }
 
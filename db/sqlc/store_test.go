@@ -2,6 +2,8 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"simplebank/util"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -11,8 +13,9 @@ func TestTransferTx(t *testing.T) {
 	ctx := context.Background()
 	store := NewStore(testDB)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	// both sides of a transfer are posted to the ledger, which must balance per currency
+	account1 := createRandomAccountInCurrency(t, util.USD)
+	account2 := createRandomAccountInCurrency(t, util.USD)
 
 	// run a concurrent transfer transactions
 	n := 5
@@ -76,6 +79,22 @@ func TestTransferTx(t *testing.T) {
 		_, err = store.GetEntry(ctx, toEntry.ID)
 		require.NoError(t, err)
 
+		// check journal entry
+		journalEntry := result.JournalEntry
+		require.NotZero(t, journalEntry.ID)
+		require.Equal(t, transfer.ID, journalEntry.TransferID.Int64)
+
+		lines, err := store.ListJournalLines(ctx, journalEntry.ID)
+		require.NoError(t, err)
+		require.Len(t, lines, 2)
+		require.Equal(t, amount, lines[0].Debit)
+		require.Equal(t, amount, lines[1].Credit)
+
+		fromLedger, err := store.GetLedgerAccountByAccountID(ctx, sql.NullInt64{Int64: account1.ID, Valid: true})
+		require.NoError(t, err)
+		require.Equal(t, fromLedger.ID, lines[0].LedgerAccountID)
+		require.Equal(t, LedgerAccountTypeLiability, fromLedger.Type)
+
 		// check accounts
 		fromAccount := result.FromAccount
 		require.NotEmpty(t, fromAccount)
@@ -107,14 +126,24 @@ func TestTransferTx(t *testing.T) {
 
 	require.Equal(t, account1.Balance-int64(n)*amount, updatedAccount1.Balance)
 	require.Equal(t, account2.Balance+int64(n)*amount, updatedAccount2.Balance)
+
+	// check the ledger saw the same movements
+	toLedger, err := store.GetLedgerAccountByAccountID(ctx, sql.NullInt64{Int64: account2.ID, Valid: true})
+	require.NoError(t, err)
+
+	totals, err := store.GetLedgerAccountTotals(ctx, toLedger.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(n)*amount, totals.Credits)
+	require.Zero(t, totals.Debits)
 }
 
 func TestTransferTxDeadLock(t *testing.T) {
 	ctx := context.Background()
 	store := NewStore(testDB)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	// both sides of a transfer are posted to the ledger, which must balance per currency
+	account1 := createRandomAccountInCurrency(t, util.USD)
+	account2 := createRandomAccountInCurrency(t, util.USD)
 
 	// run a concurrent transfer transactions
 	n := 10
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+)
+
+// EnableTOTPTxParams contain the input parameters of the TOTP enrollment transaction
+type EnableTOTPTxParams struct {
+	Username string
+	// Step of the code that confirmed the enrollment, it can't be used again
+	Step int64
+	// RecoveryCodeHashes replace the recovery codes the user had
+	RecoveryCodeHashes []string
+}
+
+// EnableTOTPTx turns the second factor on for a user together with a new set
+// of recovery codes
+func (store *SQLStore) EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) (User, error) {
+	var user User
+
+	ctx, span := startTxSpan(ctx, "EnableTOTPTx")
+	defer span.End()
+
+	err := store.execTx(ctx, func(ctx context.Context, q *Queries) error {
+		var err error
+		user, err = q.EnableTOTP(ctx, EnableTOTPParams{
+			Username:     arg.Username,
+			TotpLastStep: arg.Step,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := q.DeleteRecoveryCodes(ctx, arg.Username); err != nil {
+			return err
+		}
+
+		for _, hash := range arg.RecoveryCodeHashes {
+			err := q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+				Username: arg.Username,
+				CodeHash: hash,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	recordError(span, err)
+	return user, err
+}
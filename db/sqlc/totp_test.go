@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnableTOTPTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	require.False(t, user.TotpEnabled)
+
+	enrolled, err := testQueries.SetTOTPSecret(context.Background(), SetTOTPSecretParams{
+		Username:   user.Username,
+		TotpSecret: "sealed-secret",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "sealed-secret", enrolled.TotpSecret)
+	require.False(t, enrolled.TotpEnabled)
+
+	enabled, err := store.EnableTOTPTx(context.Background(), EnableTOTPTxParams{
+		Username:           user.Username,
+		Step:               100,
+		RecoveryCodeHashes: []string{"hash-1", "hash-2"},
+	})
+	require.NoError(t, err)
+	require.True(t, enabled.TotpEnabled)
+	require.Equal(t, int64(100), enabled.TotpLastStep)
+
+	count, err := testQueries.CountRecoveryCodes(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	// the secret can't be replaced once enabled
+	_, err = testQueries.SetTOTPSecret(context.Background(), SetTOTPSecretParams{
+		Username:   user.Username,
+		TotpSecret: "other-secret",
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// enabling again replaces the recovery codes
+	_, err = store.EnableTOTPTx(context.Background(), EnableTOTPTxParams{
+		Username:           user.Username,
+		Step:               101,
+		RecoveryCodeHashes: []string{"hash-3"},
+	})
+	require.NoError(t, err)
+
+	rows, err := testQueries.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{Username: user.Username, CodeHash: "hash-1"})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = testQueries.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{Username: user.Username, CodeHash: "hash-3"})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	rows, err = testQueries.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{Username: user.Username, CodeHash: "hash-3"})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
+
+func TestUseTOTPStep(t *testing.T) {
+	user := createRandomUser(t)
+
+	rows, err := testQueries.UseTOTPStep(context.Background(), UseTOTPStepParams{Username: user.Username, TotpLastStep: 10})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	// the same step and earlier ones are refused
+	for _, step := range []int64{10, 9} {
+		rows, err = testQueries.UseTOTPStep(context.Background(), UseTOTPStepParams{Username: user.Username, TotpLastStep: step})
+		require.NoError(t, err)
+		require.Zero(t, rows)
+	}
+
+	rows, err = testQueries.UseTOTPStep(context.Background(), UseTOTPStepParams{Username: user.Username, TotpLastStep: 11})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+}
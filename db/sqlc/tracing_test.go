@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// errDB fails every query with err
+type errDB struct {
+	DBTX
+	err error
+}
+
+func (db errDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, db.err
+}
+
+func (db errDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, db.err
+}
+
+func TestTracingDB(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	ctx, parent := tracer.Start(context.Background(), "TransferTx")
+	_, err := tracingDB{errDB{err: sql.ErrConnDone}}.ExecContext(ctx, updateAccount, 1, 1)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	_, err = tracingDB{errDB{err: sql.ErrNoRows}}.QueryContext(ctx, listAccounts, "owner", 0, 5)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	require.Equal(t, "UpdateAccount", spans[0].Name())
+	require.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+
+	require.Equal(t, "ListAccounts", spans[1].Name())
+	require.Equal(t, codes.Unset, spans[1].Status().Code)
+}
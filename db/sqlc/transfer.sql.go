@@ -7,6 +7,7 @@ package db
 
 import (
 	"context"
+	"time"
 )
 
 const createTransfer = `-- name: CreateTransfer :one
@@ -59,26 +60,26 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 const listTransfers = `-- name: ListTransfers :many
 SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
 WHERE
-  to_account_id = $1 OR
-  from_account_id = $2
+  (to_account_id = $1 OR
+  from_account_id = $2) AND
+  id > $3
 ORDER BY id
-LIMIT $3
-OFFSET $4
+LIMIT $4
 `
 
 type ListTransfersParams struct {
 	ToAccountID   int64 `json:"to_account_id"`
 	FromAccountID int64 `json:"from_account_id"`
+	AfterID       int64 `json:"after_id"`
 	Limit         int32 `json:"limit"`
-	Offset        int32 `json:"offset"`
 }
 
 func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
 	rows, err := q.db.QueryContext(ctx, listTransfers,
 		arg.ToAccountID,
 		arg.FromAccountID,
+		arg.AfterID,
 		arg.Limit,
-		arg.Offset,
 	)
 	if err != nil {
 		return nil, err
@@ -106,3 +107,99 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 	}
 	return items, nil
 }
+
+const listTransfersBefore = `-- name: ListTransfersBefore :many
+SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+WHERE
+  (to_account_id = $1 OR
+  from_account_id = $2) AND
+  id < $3
+ORDER BY id DESC
+LIMIT $4
+`
+
+type ListTransfersBeforeParams struct {
+	ToAccountID   int64 `json:"to_account_id"`
+	FromAccountID int64 `json:"from_account_id"`
+	BeforeID      int64 `json:"before_id"`
+	Limit         int32 `json:"limit"`
+}
+
+func (q *Queries) ListTransfersBefore(ctx context.Context, arg ListTransfersBeforeParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listTransfersBefore,
+		arg.ToAccountID,
+		arg.FromAccountID,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransfersInPeriod = `-- name: ListTransfersInPeriod :many
+SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+WHERE
+  (to_account_id = $1 OR
+  from_account_id = $1) AND
+  created_at >= $2 AND
+  created_at < $3
+ORDER BY id
+`
+
+type ListTransfersInPeriodParams struct {
+	AccountID int64     `json:"account_id"`
+	FromTime  time.Time `json:"from_time"`
+	ToTime    time.Time `json:"to_time"`
+}
+
+func (q *Queries) ListTransfersInPeriod(ctx context.Context, arg ListTransfersInPeriodParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listTransfersInPeriod, arg.AccountID, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
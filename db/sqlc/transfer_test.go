@@ -4,6 +4,7 @@ import (
 	"context"
 	"simplebank/util"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -63,7 +64,6 @@ func TestListTransfers(t *testing.T) {
 		ToAccountID:   account1.ID,
 		FromAccountID: account1.ID,
 		Limit:         5,
-		Offset:        5,
 	}
 
 	transfers, err := testQueries.ListTransfers(context.Background(), arg)
@@ -73,5 +73,44 @@ func TestListTransfers(t *testing.T) {
 	for _, transfer := range transfers {
 		require.NotEmpty(t, transfer)
 		require.True(t, transfer.FromAccountID == account1.ID || transfer.ToAccountID == account1.ID)
+		require.Greater(t, transfer.ID, arg.AfterID)
+		arg.AfterID = transfer.ID
 	}
+
+	transfers, err = testQueries.ListTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, 5)
+
+	before := ListTransfersBeforeParams{
+		ToAccountID:   account1.ID,
+		FromAccountID: account1.ID,
+		BeforeID:      transfers[0].ID,
+		Limit:         5,
+	}
+
+	previous, err := testQueries.ListTransfersBefore(context.Background(), before)
+	require.NoError(t, err)
+	require.Len(t, previous, 5)
+
+	for _, transfer := range previous {
+		require.Less(t, transfer.ID, before.BeforeID)
+		before.BeforeID = transfer.ID
+	}
+}
+
+func TestListTransfersInPeriod(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	createRandomTransfer(t, account1, account2)
+	createRandomTransfer(t, account2, account1)
+
+	arg := ListTransfersInPeriodParams{
+		AccountID: account1.ID,
+		FromTime:  time.Now().Add(-time.Minute),
+		ToTime:    time.Now().Add(time.Minute),
+	}
+
+	transfers, err := testQueries.ListTransfersInPeriod(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
 }
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// UpdateUserTxParams contain the input parameters of the profile update
+// transaction, null fields are left as they are
+type UpdateUserTxParams struct {
+	Username  string
+	FullName  sql.NullString
+	Email     sql.NullString
+	ClientIp  string
+	UserAgent string
+}
+
+// UpdateUserTxResult is the result of the profile update transaction
+type UpdateUserTxResult struct {
+	User User
+	// one audit entry per field whose value changed
+	AuditEntries []UserAuditLog
+}
+
+// EmailChanged tells whether the update gave the user a new email address
+func (result UpdateUserTxResult) EmailChanged() bool {
+	for _, entry := range result.AuditEntries {
+		if entry.Field == "email" {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateUserTx updates the profile of a user and writes an audit entry for
+// every field that changed. A new email address is unverified. An address
+// taken by another user fails with a unique_violation.
+func (store *SQLStore) UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error) {
+	var result UpdateUserTxResult
+
+	ctx, span := startTxSpan(ctx, "UpdateUserTx")
+	defer span.End()
+
+	err := store.execTx(ctx, func(ctx context.Context, q *Queries) error {
+		before, err := q.GetUserForUpdate(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.UpdateUser(ctx, UpdateUserParams{
+			Username: arg.Username,
+			FullName: arg.FullName,
+			Email:    arg.Email,
+		})
+		if err != nil {
+			return err
+		}
+
+		changes := []struct {
+			field    string
+			old, new string
+		}{
+			{"full_name", before.FullName, result.User.FullName},
+			{"email", before.Email, result.User.Email},
+		}
+		for _, change := range changes {
+			if change.old == change.new {
+				continue
+			}
+
+			entry, err := q.CreateUserAuditEntry(ctx, CreateUserAuditEntryParams{
+				Username:  arg.Username,
+				Field:     change.field,
+				OldValue:  change.old,
+				NewValue:  change.new,
+				ClientIp:  arg.ClientIp,
+				UserAgent: arg.UserAgent,
+			})
+			if err != nil {
+				return err
+			}
+			result.AuditEntries = append(result.AuditEntries, entry)
+		}
+		return nil
+	})
+
+	recordError(span, err)
+	return result, err
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"simplebank/util"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateUserTxFullName(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	_, err := testDB.Exec("UPDATE users SET email_verified = true WHERE username = $1", user.Username)
+	require.NoError(t, err)
+
+	fullName := util.RandomOwner()
+	result, err := store.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		Username:  user.Username,
+		FullName:  sql.NullString{String: fullName, Valid: true},
+		ClientIp:  "192.0.2.1",
+		UserAgent: "test-agent",
+	})
+	require.NoError(t, err)
+	require.Equal(t, fullName, result.User.FullName)
+	require.Equal(t, user.Email, result.User.Email)
+	require.True(t, result.User.EmailVerified)
+	require.False(t, result.EmailChanged())
+
+	require.Len(t, result.AuditEntries, 1)
+	entry := result.AuditEntries[0]
+	require.Equal(t, "full_name", entry.Field)
+	require.Equal(t, user.FullName, entry.OldValue)
+	require.Equal(t, fullName, entry.NewValue)
+	require.Equal(t, "192.0.2.1", entry.ClientIp)
+	require.Equal(t, "test-agent", entry.UserAgent)
+}
+
+func TestUpdateUserTxEmail(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	_, err := testDB.Exec("UPDATE users SET email_verified = true WHERE username = $1", user.Username)
+	require.NoError(t, err)
+
+	email := util.RandomEmail()
+	result, err := store.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		Username: user.Username,
+		FullName: sql.NullString{String: user.FullName, Valid: true},
+		Email:    sql.NullString{String: email, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, email, result.User.Email)
+	require.False(t, result.User.EmailVerified)
+	require.True(t, result.EmailChanged())
+
+	// the unchanged full name is not audited
+	require.Len(t, result.AuditEntries, 1)
+	require.Equal(t, "email", result.AuditEntries[0].Field)
+
+	entries, err := store.ListUserAuditEntries(context.Background(), ListUserAuditEntriesParams{
+		Username: user.Username,
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, result.AuditEntries[0].ID, entries[0].ID)
+	require.Equal(t, user.Email, entries[0].OldValue)
+	require.Equal(t, email, entries[0].NewValue)
+}
+
+func TestUpdateUserTxSameEmail(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	_, err := testDB.Exec("UPDATE users SET email_verified = true WHERE username = $1", user.Username)
+	require.NoError(t, err)
+
+	result, err := store.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		Username: user.Username,
+		Email:    sql.NullString{String: user.Email, Valid: true},
+	})
+	require.NoError(t, err)
+	require.True(t, result.User.EmailVerified)
+	require.Empty(t, result.AuditEntries)
+}
+
+func TestUpdateUserTxEmailTaken(t *testing.T) {
+	store := NewStore(testDB)
+	user1 := createRandomUser(t)
+	user2 := createRandomUser(t)
+
+	_, err := store.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		Username: user1.Username,
+		Email:    sql.NullString{String: user2.Email, Valid: true},
+	})
+	var pqErr *pq.Error
+	require.ErrorAs(t, err, &pqErr)
+	require.Equal(t, "unique_violation", pqErr.Code.Name())
+
+	entries, err := store.ListUserAuditEntries(context.Background(), ListUserAuditEntriesParams{
+		Username: user1.Username,
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
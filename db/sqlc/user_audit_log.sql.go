@@ -0,0 +1,99 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.16.0
+// source: user_audit_log.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createUserAuditEntry = `-- name: CreateUserAuditEntry :one
+INSERT INTO user_audit_log (
+  username,
+  field,
+  old_value,
+  new_value,
+  client_ip,
+  user_agent
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING id, username, field, old_value, new_value, client_ip, user_agent, created_at
+`
+
+type CreateUserAuditEntryParams struct {
+	Username  string `json:"username"`
+	Field     string `json:"field"`
+	OldValue  string `json:"old_value"`
+	NewValue  string `json:"new_value"`
+	ClientIp  string `json:"client_ip"`
+	UserAgent string `json:"user_agent"`
+}
+
+func (q *Queries) CreateUserAuditEntry(ctx context.Context, arg CreateUserAuditEntryParams) (UserAuditLog, error) {
+	row := q.db.QueryRowContext(ctx, createUserAuditEntry,
+		arg.Username,
+		arg.Field,
+		arg.OldValue,
+		arg.NewValue,
+		arg.ClientIp,
+		arg.UserAgent,
+	)
+	var i UserAuditLog
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Field,
+		&i.OldValue,
+		&i.NewValue,
+		&i.ClientIp,
+		&i.UserAgent,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listUserAuditEntries = `-- name: ListUserAuditEntries :many
+SELECT id, username, field, old_value, new_value, client_ip, user_agent, created_at FROM user_audit_log
+WHERE username = $1
+ORDER BY id DESC
+LIMIT $2
+`
+
+type ListUserAuditEntriesParams struct {
+	Username string `json:"username"`
+	Limit    int32  `json:"limit"`
+}
+
+func (q *Queries) ListUserAuditEntries(ctx context.Context, arg ListUserAuditEntriesParams) ([]UserAuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, listUserAuditEntries, arg.Username, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []UserAuditLog{}
+	for rows.Next() {
+		var i UserAuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Field,
+			&i.OldValue,
+			&i.NewValue,
+			&i.ClientIp,
+			&i.UserAgent,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -53,3 +53,76 @@ func TestGetUser(t *testing.T) {
 	require.WithinDuration(t, user1.PasswordChangedAt, user2.PasswordChangedAt, time.Second)
 	require.WithinDuration(t, user1.CreatedAt, user2.CreatedAt, time.Second)
 }
+
+func TestRecordFailedLogin(t *testing.T) {
+	user := createRandomUser(t)
+	arg := RecordFailedLoginParams{
+		Username:          user.Username,
+		MaxAttempts:       2,
+		LockoutSeconds:    60,
+		MaxLockoutSeconds: 90,
+	}
+
+	updated, err := testQueries.RecordFailedLogin(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), updated.FailedLoginAttempts)
+	require.True(t, updated.LockedUntil.IsZero())
+
+	updated, err = testQueries.RecordFailedLogin(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), updated.FailedLoginAttempts)
+	require.WithinDuration(t, time.Now().Add(time.Minute), updated.LockedUntil, 5*time.Second)
+
+	// the cool-down doubles, capped at the max lockout
+	updated, err = testQueries.RecordFailedLogin(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), updated.FailedLoginAttempts)
+	require.WithinDuration(t, time.Now().Add(90*time.Second), updated.LockedUntil, 5*time.Second)
+
+	err = testQueries.ResetFailedLogins(context.Background(), user.Username)
+	require.NoError(t, err)
+
+	reset, err := testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Zero(t, reset.FailedLoginAttempts)
+	require.True(t, reset.LockedUntil.IsZero())
+}
+
+func TestRecordFailedLoginWithoutLockout(t *testing.T) {
+	user := createRandomUser(t)
+
+	for i := 0; i < 3; i++ {
+		updated, err := testQueries.RecordFailedLogin(context.Background(), RecordFailedLoginParams{Username: user.Username})
+		require.NoError(t, err)
+		require.True(t, updated.LockedUntil.IsZero())
+	}
+}
+
+func TestRehashUserPassword(t *testing.T) {
+	user := createRandomUser(t)
+
+	newHash, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	// a stale old hash leaves the password alone
+	err = testQueries.RehashUserPassword(context.Background(), RehashUserPasswordParams{
+		Username: user.Username,
+		OldHash:  util.RandomString(32),
+		NewHash:  newHash,
+	})
+	require.NoError(t, err)
+	unchanged, err := testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.HashedPassword, unchanged.HashedPassword)
+
+	err = testQueries.RehashUserPassword(context.Background(), RehashUserPasswordParams{
+		Username: user.Username,
+		OldHash:  user.HashedPassword,
+		NewHash:  newHash,
+	})
+	require.NoError(t, err)
+	rehashed, err := testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, newHash, rehashed.HashedPassword)
+	require.Equal(t, user.PasswordChangedAt, rehashed.PasswordChangedAt)
+}
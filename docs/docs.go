@@ -0,0 +1,50 @@
+// Package docs embeds the OpenAPI specification generated from proto/ and serves it with Swagger UI
+package docs
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// SpecFile is the OpenAPI v2 specification of the HTTP gateway, generated by `make proto`
+const SpecFile = "simple_bank.swagger.json"
+
+//go:embed swagger
+var swagger embed.FS
+
+// swaggerInitializer replaces the initializer of the Swagger UI distribution,
+// which points at the petstore example, with one loading our spec
+const swaggerInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "` + SpecFile + `",
+    dom_id: '#swagger-ui',
+    deepLinking: true,
+    presets: [
+      SwaggerUIBundle.presets.apis,
+      SwaggerUIStandalonePreset
+    ],
+    plugins: [
+      SwaggerUIBundle.plugins.DownloadUrl
+    ],
+    layout: "StandaloneLayout"
+  });
+};
+`
+
+// Handler serves Swagger UI and the specification under prefix, e.g. "/swagger/"
+func Handler(prefix string) http.Handler {
+	spec, _ := fs.Sub(swagger, "swagger")
+
+	mux := http.NewServeMux()
+	mux.Handle(prefix+SpecFile, http.StripPrefix(prefix, http.FileServer(http.FS(spec))))
+	mux.HandleFunc(prefix+"swagger-initializer.js", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+		_, _ = w.Write([]byte(swaggerInitializer))
+	})
+	mux.Handle(prefix, http.StripPrefix(prefix, http.FileServer(http.FS(swaggerFiles.FS))))
+
+	return mux
+}
@@ -0,0 +1,68 @@
+package docs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	handler := Handler("/swagger/")
+
+	testCases := []struct {
+		name        string
+		path        string
+		contentType string
+		check       func(t *testing.T, body []byte)
+	}{
+		{
+			name:        "UI",
+			path:        "/swagger/",
+			contentType: "text/html; charset=utf-8",
+			check: func(t *testing.T, body []byte) {
+				require.Contains(t, string(body), "swagger-initializer.js")
+			},
+		},
+		{
+			name:        "Initializer",
+			path:        "/swagger/swagger-initializer.js",
+			contentType: "text/javascript; charset=utf-8",
+			check: func(t *testing.T, body []byte) {
+				require.Contains(t, string(body), SpecFile)
+				require.NotContains(t, string(body), "petstore")
+			},
+		},
+		{
+			name:        "Spec",
+			path:        "/swagger/" + SpecFile,
+			contentType: "application/json",
+			check: func(t *testing.T, body []byte) {
+				var spec struct {
+					Swagger string                     `json:"swagger"`
+					Paths   map[string]json.RawMessage `json:"paths"`
+				}
+				require.NoError(t, json.Unmarshal(body, &spec))
+				require.Equal(t, "2.0", spec.Swagger)
+				require.Contains(t, spec.Paths, "/v1/accounts/{id}")
+				require.Contains(t, spec.Paths, "/v1/transfers")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, tc.contentType, w.Header().Get("Content-Type"))
+			tc.check(t, w.Body.Bytes())
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	authorizationHeader = "authorization"
+	authorizationBearer = "Bearer"
+)
+
+// authorizeUser verifies the bearer token sent in the authorization metadata,
+// the same header the HTTP API reads, and checks it grants scope. Tokens
+// meant for the HTTP API only are refused, third-party ones included, and so
+// are tokens of revoked sessions and tokens issued before the last password
+// change. It returns the user of the token or a gRPC status error.
+func (server *Server) authorizeUser(ctx context.Context, scope string) (db.User, error) {
+	payload, err := server.verifyToken(ctx)
+	if err != nil {
+		return db.User{}, unauthenticatedError(err)
+	}
+	if !payload.IsFor(token.AudienceGRPC) {
+		return db.User{}, status.Error(codes.PermissionDenied, "token is not meant for the gRPC API")
+	}
+	if !payload.HasScope(scope) {
+		return db.User{}, status.Errorf(codes.PermissionDenied, "the %s scope is required", scope)
+	}
+	if payload.Session {
+		if err := server.authorizeSession(ctx, payload); err != nil {
+			return db.User{}, err
+		}
+	}
+
+	user, err := server.store.GetUser(ctx, payload.Username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return user, unauthenticatedError(errors.New("user of the token does not exist"))
+		}
+		return user, status.Error(codes.Internal, "failed to get user")
+	}
+
+	if payload.IssuedAt.Before(user.PasswordChangedAt) {
+		return user, unauthenticatedError(errors.New("token was issued before the last password change"))
+	}
+
+	return user, nil
+}
+
+// authorizeSession refuses the token of a revoked session and records that
+// the session was seen
+func (server *Server) authorizeSession(ctx context.Context, payload *token.Payload) error {
+	session, err := server.store.GetSession(ctx, payload.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return unauthenticatedError(errors.New("session of the token does not exist"))
+		}
+		return status.Error(codes.Internal, "failed to get session")
+	}
+
+	if !session.RevokedAt.IsZero() {
+		return unauthenticatedError(errors.New("session was revoked"))
+	}
+
+	if err := server.store.TouchSession(ctx, session.ID); err != nil {
+		return status.Error(codes.Internal, "failed to record session use")
+	}
+	return nil
+}
+
+// requireVerifiedEmail keeps users who didn't verify their email address away
+// from opening accounts and moving money
+func requireVerifiedEmail(user db.User) error {
+	if !user.EmailVerified {
+		return status.Errorf(codes.PermissionDenied, "email address %s is not verified", user.Email)
+	}
+	return nil
+}
+
+func (server *Server) verifyToken(ctx context.Context) (*token.Payload, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("missing metadata")
+	}
+
+	values := md.Get(authorizationHeader)
+	if len(values) == 0 {
+		return nil, fmt.Errorf("authorization header is not provided")
+	}
+
+	fields := strings.Fields(values[0])
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("invalid authorization header format")
+	}
+
+	authType := fields[0]
+	if authType != authorizationBearer {
+		return nil, fmt.Errorf("unsupported authorization type %s", authType)
+	}
+
+	payload, err := server.tokenMaker.VerifyToken(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid access token: %w", err)
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,191 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func TestAuthorizeUser(t *testing.T) {
+	user, _ := randomUser()
+
+	testCases := []struct {
+		buildContext func(t *testing.T, server *Server) context.Context
+		buildStubs   func(store *mockdb.MockStore)
+		name         string
+		code         codes.Code
+	}{
+		{
+			name: "OK",
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return newContextWithBearerToken(t, server, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				changed := user
+				changed.PasswordChangedAt = time.Now().Add(-time.Hour)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(changed, nil)
+			},
+			code: codes.OK,
+		},
+		{
+			name: "PasswordChangedSince",
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return newContextWithBearerToken(t, server, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				changed := user
+				changed.PasswordChangedAt = time.Now().Add(time.Second)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(changed, nil)
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "UserNotFound",
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return newContextWithBearerToken(t, server, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(user, sql.ErrNoRows)
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "InternalError",
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return newContextWithBearerToken(t, server, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(user, sql.ErrConnDone)
+			},
+			code: codes.Internal,
+		},
+		{
+			name: "ThirdPartyToken",
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return newContextWithOptions(t, server, user.Username, time.Minute, token.Options{
+					Audience: token.AudienceHTTP,
+					ClientID: "client",
+					Scopes:   []string{token.ScopeAccountsRead},
+				})
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			code: codes.PermissionDenied,
+		},
+		{
+			name: "GRPCAudience",
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return newContextWithOptions(t, server, user.Username, time.Minute, token.Options{Audience: token.AudienceGRPC})
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			},
+			code: codes.OK,
+		},
+		{
+			name: "HTTPAudience",
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return newContextWithOptions(t, server, user.Username, time.Minute, token.Options{Audience: token.AudienceHTTP})
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			code: codes.PermissionDenied,
+		},
+		{
+			name: "Scoped",
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return newContextWithOptions(t, server, user.Username, time.Minute, token.Options{Scopes: []string{token.ScopeAccountsRead}})
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			},
+			code: codes.OK,
+		},
+		{
+			name: "MissingScope",
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return newContextWithOptions(t, server, user.Username, time.Minute, token.Options{Scopes: []string{token.ScopeTransfersRead}})
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			code: codes.PermissionDenied,
+		},
+		{
+			name: "Session",
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return newContextWithOptions(t, server, user.Username, time.Minute, token.Options{Session: true})
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{Username: user.Username}, nil)
+				store.EXPECT().TouchSession(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			},
+			code: codes.OK,
+		},
+		{
+			name: "SessionRevoked",
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return newContextWithOptions(t, server, user.Username, time.Minute, token.Options{Session: true})
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{RevokedAt: time.Now()}, nil)
+				store.EXPECT().TouchSession(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "SessionNotFound",
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return newContextWithOptions(t, server, user.Username, time.Minute, token.Options{Session: true})
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{}, sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "NoMetadata",
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return context.Background()
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			code: codes.Unauthenticated,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			authUser, err := server.authorizeUser(tc.buildContext(t, server), token.ScopeAccountsRead)
+			if tc.code == codes.OK {
+				require.NoError(t, err)
+				require.Equal(t, user.Username, authUser.Username)
+				return
+			}
+			requireCode(t, err, tc.code)
+		})
+	}
+}
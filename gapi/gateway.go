@@ -0,0 +1,39 @@
+package gapi
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"simplebank/docs"
+	"simplebank/pb"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// NewGatewayHandler serves the rpcs of server as HTTP/JSON, following the google.api.http
+// annotations in proto/, next to Swagger UI and the generated OpenAPI spec under /swagger/.
+// The Authorization header is forwarded as the authorization metadata the rpcs read,
+// requests are logged and rate limited like the rpcs of the gRPC server.
+func NewGatewayHandler(ctx context.Context, server *Server) (http.Handler, error) {
+	jsonOption := runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+		MarshalOptions: protojson.MarshalOptions{
+			UseProtoNames: true,
+		},
+		UnmarshalOptions: protojson.UnmarshalOptions{
+			DiscardUnknown: true,
+		},
+	})
+
+	grpcMux := runtime.NewServeMux(jsonOption)
+	if err := pb.RegisterSimpleBankHandlerServer(ctx, grpcMux, rateLimitedServer{server}); err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", grpcMux)
+	mux.Handle("/swagger/", docs.Handler("/swagger/"))
+
+	return otelhttp.NewHandler(HttpLogger(slog.Default(), mux), "gateway"), nil
+}
@@ -0,0 +1,167 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/docs"
+	"simplebank/token"
+	"simplebank/util"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGatewayHandler(t *testing.T) {
+	user, password := randomUser()
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+	user.HashedPassword = hashedPassword
+
+	account := randomAccount(user.Username)
+
+	testCases := []struct {
+		buildRequest  func(t *testing.T, server *Server) *http.Request
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, w *httptest.ResponseRecorder)
+		name          string
+	}{
+		{
+			name: "LoginUser",
+			buildRequest: func(t *testing.T, server *Server) *http.Request {
+				body := fmt.Sprintf(`{"username": %q, "password": %q}`, user.Username, password)
+				return httptest.NewRequest(http.MethodPost, "/v1/users/login", strings.NewReader(body))
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateLogin(gomock.Any(), gomock.Eq(db.CreateLoginParams{
+						Username: user.Username,
+						ClientIp: "192.0.2.1",
+					})).
+					Times(1).
+					Return(db.Login{}, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+
+				var res map[string]json.RawMessage
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				require.Contains(t, res, "access_token")
+				require.Contains(t, string(res["user"]), `"full_name"`)
+			},
+		},
+		{
+			name: "GetAccount",
+			buildRequest: func(t *testing.T, server *Server) *http.Request {
+				accessToken, _, err := server.tokenMaker.CreateToken(user.Username, time.Minute, token.Options{})
+				require.NoError(t, err)
+
+				req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v1/accounts/%d", account.ID), nil)
+				req.Header.Set("Authorization", fmt.Sprintf("%s %s", authorizationBearer, accessToken))
+				return req
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+
+				var res struct {
+					Account struct {
+						ID       string `json:"id"`
+						Owner    string `json:"owner"`
+						Currency string `json:"currency"`
+					} `json:"account"`
+				}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				require.Equal(t, fmt.Sprint(account.ID), res.Account.ID)
+				require.Equal(t, account.Owner, res.Account.Owner)
+			},
+		},
+		{
+			name: "GetAccountNotFound",
+			buildRequest: func(t *testing.T, server *Server) *http.Request {
+				accessToken, _, err := server.tokenMaker.CreateToken(user.Username, time.Minute, token.Options{})
+				require.NoError(t, err)
+
+				req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v1/accounts/%d", account.ID), nil)
+				req.Header.Set("Authorization", fmt.Sprintf("%s %s", authorizationBearer, accessToken))
+				return req
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			buildRequest: func(t *testing.T, server *Server) *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/v1/accounts?page_size=5", nil)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, w.Code)
+			},
+		},
+		{
+			name: "SwaggerSpec",
+			buildRequest: func(t *testing.T, server *Server) *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/swagger/"+docs.SpecFile, nil)
+			},
+			buildStubs: func(store *mockdb.MockStore) {},
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, w.Code)
+				require.Contains(t, w.Body.String(), `"swagger": "2.0"`)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+
+			server := newTestServer(t, store)
+			handler, err := NewGatewayHandler(context.Background(), server)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, tc.buildRequest(t, server))
+			tc.checkResponse(t, w)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package gapi
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"simplebank/logging"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const requestIDHeader = "x-request-id"
+
+// GrpcLogger puts a logger with the request id in the context of every rpc,
+// the store logs through it, and writes one log line per rpc when it's done
+func GrpcLogger(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		var requestID string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(requestIDHeader); len(values) > 0 {
+				requestID = values[0]
+			}
+		}
+		requestID = logging.RequestID(requestID)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, requestID))
+
+		rpcLogger := logger.With(logging.KeyRequestID, requestID, logging.KeyRoute, info.FullMethod)
+		ctx = logging.WithContext(ctx, rpcLogger)
+
+		res, err := handler(ctx, req)
+
+		st := status.Convert(err)
+		attrs := []slog.Attr{
+			slog.String("code", st.Code().String()),
+			slog.Duration("duration", time.Since(start)),
+		}
+		level := slog.LevelInfo
+		if err != nil {
+			level = slog.LevelWarn
+			attrs = append(attrs, slog.String("error", st.Message()))
+		}
+		rpcLogger.LogAttrs(ctx, level, "rpc", attrs...)
+
+		return res, err
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// HttpLogger is GrpcLogger for the HTTP gateway, the rpcs it calls in process
+// don't pass through the gRPC interceptors
+func HttpLogger(logger *slog.Logger, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestID := logging.RequestID(r.Header.Get(requestIDHeader))
+		w.Header().Set(requestIDHeader, requestID)
+
+		reqLogger := logger.With(logging.KeyRequestID, requestID)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler.ServeHTTP(rec, r.WithContext(logging.WithContext(r.Context(), reqLogger)))
+
+		reqLogger.LogAttrs(r.Context(), logging.StatusLevel(rec.status), "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Duration("duration", time.Since(start)),
+		)
+	})
+}
@@ -0,0 +1,63 @@
+package gapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"simplebank/logging"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestGrpcLogger(t *testing.T) {
+	var buf bytes.Buffer
+	interceptor := GrpcLogger(logging.New(&buf, "info"))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestIDHeader, "test-request-id"))
+	info := &grpc.UnaryServerInfo{FullMethod: "/pb.SimpleBank/GetAccount"}
+
+	var handlerLogger *slog.Logger
+	_, err := interceptor(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+		handlerLogger = logging.FromContext(ctx)
+		return nil, status.Error(codes.NotFound, "account 1 not found")
+	})
+	require.Error(t, err)
+	require.NotEqual(t, slog.Default(), handlerLogger)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "rpc", entry["msg"])
+	require.Equal(t, "WARN", entry["level"])
+	require.Equal(t, "test-request-id", entry[logging.KeyRequestID])
+	require.Equal(t, info.FullMethod, entry[logging.KeyRoute])
+	require.Equal(t, codes.NotFound.String(), entry["code"])
+}
+
+func TestHttpLogger(t *testing.T) {
+	var buf bytes.Buffer
+	handler := HttpLogger(logging.New(&buf, "info"), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NotEqual(t, slog.Default(), logging.FromContext(r.Context()))
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/accounts/1", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	requestID := w.Header().Get(requestIDHeader)
+	require.NotEmpty(t, requestID)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, requestID, entry[logging.KeyRequestID])
+	require.Equal(t, "/v1/accounts/1", entry["path"])
+	require.Equal(t, float64(http.StatusUnauthorized), entry["status"])
+}
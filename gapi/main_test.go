@@ -0,0 +1,75 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/ratelimit"
+	"simplebank/token"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func newTestServer(t *testing.T, store db.Store) *Server {
+	config := util.Config{
+		TokenSymmetricKey:    util.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		TOTPEncryptionKey:    util.RandomString(32),
+		PasswordRejectCommon: true,
+	}
+
+	server, err := NewServer(config, store, ratelimit.NewMemoryLimiter(), ratelimit.Limits{})
+	require.NoError(t, err)
+
+	return server
+}
+
+// stubAuthUsers lets the token check load whichever user a test authenticates
+// as, tests stubbing GetUser themselves must do it first
+func stubAuthUsers(store *mockdb.MockStore) {
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ context.Context, username string) (db.User, error) {
+			return db.User{Username: username, EmailVerified: true}, nil
+		})
+}
+
+// newContextWithBearerToken returns an incoming context carrying an access token for username
+func newContextWithBearerToken(t *testing.T, server *Server, username string, duration time.Duration) context.Context {
+	return newContextWithOptions(t, server, username, duration, token.Options{})
+}
+
+// newContextWithOptions is newContextWithBearerToken for a token narrowed down by options
+func newContextWithOptions(t *testing.T, server *Server, username string, duration time.Duration, options token.Options) context.Context {
+	accessToken, _, err := server.tokenMaker.CreateToken(username, duration, options)
+	require.NoError(t, err)
+
+	md := metadata.MD{
+		authorizationHeader: []string{fmt.Sprintf("%s %s", authorizationBearer, accessToken)},
+	}
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+func randomUser() (db.User, string) {
+	return db.User{
+		Username: util.RandomOwner(),
+		FullName: util.RandomString(6),
+		Email:    util.RandomEmail(),
+	}, util.RandomString(6)
+}
+
+func randomAccount(owner string) db.Account {
+	return db.Account{
+		ID:       util.RandomInt(1, 1000),
+		Owner:    owner,
+		Balance:  util.RandomBalance(),
+		Currency: util.RandomCurrency(),
+	}
+}
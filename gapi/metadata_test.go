@@ -0,0 +1,53 @@
+package gapi
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestExtractMetadata(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	testCases := []struct {
+		ctx      context.Context
+		name     string
+		expected Metadata
+	}{
+		{
+			name: "Gateway",
+			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+				grpcGatewayUserAgentHeader, "curl/8.0",
+				xForwardedForHeader, "10.0.0.1, 192.0.2.1",
+			)),
+			expected: Metadata{UserAgent: "curl/8.0", ClientIP: "192.0.2.1"},
+		},
+		{
+			name: "Peer",
+			ctx: peer.NewContext(
+				metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+					userAgentHeader, "grpc-go/1.70.0",
+					xForwardedForHeader, "10.0.0.1",
+				)),
+				&peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("198.51.100.7"), Port: 50051}},
+			),
+			expected: Metadata{UserAgent: "grpc-go/1.70.0", ClientIP: "198.51.100.7"},
+		},
+		{
+			name: "NoMetadata",
+			ctx:  context.Background(),
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, *server.extractMetadata(tc.ctx))
+		})
+	}
+}
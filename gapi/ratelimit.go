@@ -0,0 +1,144 @@
+package gapi
+
+import (
+	"context"
+	"math"
+	"simplebank/logging"
+	"simplebank/pb"
+	"simplebank/ratelimit"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// rpcs anyone may call, they are limited by caller address
+var publicMethods = map[string]bool{
+	pb.SimpleBank_CreateUser_FullMethodName: true,
+	pb.SimpleBank_LoginUser_FullMethodName:  true,
+}
+
+// rpcs moving money, they are limited by the transfers limit on top
+var transferMethods = map[string]bool{
+	pb.SimpleBank_CreateTransfer_FullMethodName: true,
+}
+
+// RateLimiter applies the limits of the HTTP API to the rpcs of the SimpleBank
+// service, in the same buckets: public rpcs by caller address, the others by
+// caller address and by the user of the token. Rpcs over the limit fail with
+// RESOURCE_EXHAUSTED.
+func (server *Server) RateLimiter() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := server.rateLimit(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (server *Server) rateLimit(ctx context.Context, method string) error {
+	if !strings.HasPrefix(method, "/"+pb.SimpleBank_ServiceDesc.ServiceName+"/") {
+		return nil
+	}
+
+	if publicMethods[method] {
+		return server.allow(ctx, "public", server.rateLimits.Public, server.clientIPKey(ctx))
+	}
+
+	if err := server.allow(ctx, "api_ip", server.rateLimits.APIByIP, server.clientIPKey(ctx)); err != nil {
+		return err
+	}
+
+	key := server.usernameKey(ctx)
+	if err := server.allow(ctx, "api", server.rateLimits.API, key); err != nil {
+		return err
+	}
+	if transferMethods[method] {
+		return server.allow(ctx, "transfers", server.rateLimits.Transfers, key)
+	}
+	return nil
+}
+
+// allow takes a token from the bucket of key in group. The limiter failing
+// must not take the API down, the rpc goes through then.
+func (server *Server) allow(ctx context.Context, group string, limit ratelimit.Limit, key string) error {
+	if !limit.Enabled() {
+		return nil
+	}
+
+	result, err := server.limiter.Allow(ctx, group+":"+key, limit)
+	if err != nil {
+		logging.FromContext(ctx).Warn("rate limiter failed, rpc let through", "group", group, "error", err)
+		return nil
+	}
+	if !result.Allowed {
+		retryAfter := max(int(math.Ceil(result.RetryAfter.Seconds())), 1)
+		return status.Errorf(codes.ResourceExhausted, "too many requests, retry in %d seconds", retryAfter)
+	}
+	return nil
+}
+
+// clientIPKey limits public rpcs by caller address
+func (server *Server) clientIPKey(ctx context.Context) string {
+	return "ip:" + server.extractMetadata(ctx).ClientIP
+}
+
+// usernameKey limits rpcs by the user of the token, whatever address they
+// use. Without a valid token, which the rpc refuses anyway, the caller
+// address stands in.
+func (server *Server) usernameKey(ctx context.Context) string {
+	payload, err := server.verifyToken(ctx)
+	if err != nil {
+		return server.clientIPKey(ctx)
+	}
+	return "user:" + payload.Username
+}
+
+// rateLimitedServer is the Server for the HTTP gateway, the rpcs it calls in
+// process don't pass through the gRPC interceptors
+type rateLimitedServer struct {
+	*Server
+}
+
+func (server rateLimitedServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	if err := server.rateLimit(ctx, pb.SimpleBank_CreateUser_FullMethodName); err != nil {
+		return nil, err
+	}
+	return server.Server.CreateUser(ctx, req)
+}
+
+func (server rateLimitedServer) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	if err := server.rateLimit(ctx, pb.SimpleBank_LoginUser_FullMethodName); err != nil {
+		return nil, err
+	}
+	return server.Server.LoginUser(ctx, req)
+}
+
+func (server rateLimitedServer) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
+	if err := server.rateLimit(ctx, pb.SimpleBank_CreateAccount_FullMethodName); err != nil {
+		return nil, err
+	}
+	return server.Server.CreateAccount(ctx, req)
+}
+
+func (server rateLimitedServer) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.GetAccountResponse, error) {
+	if err := server.rateLimit(ctx, pb.SimpleBank_GetAccount_FullMethodName); err != nil {
+		return nil, err
+	}
+	return server.Server.GetAccount(ctx, req)
+}
+
+func (server rateLimitedServer) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	if err := server.rateLimit(ctx, pb.SimpleBank_ListAccounts_FullMethodName); err != nil {
+		return nil, err
+	}
+	return server.Server.ListAccounts(ctx, req)
+}
+
+func (server rateLimitedServer) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	if err := server.rateLimit(ctx, pb.SimpleBank_CreateTransfer_FullMethodName); err != nil {
+		return nil, err
+	}
+	return server.Server.CreateTransfer(ctx, req)
+}
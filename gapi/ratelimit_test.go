@@ -0,0 +1,205 @@
+package gapi
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	"simplebank/pb"
+	"simplebank/ratelimit"
+	"simplebank/util"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+)
+
+// oncePerHour allows a single request, the next one is over the limit
+var oncePerHour = ratelimit.Limit{Rate: 1.0 / 3600, Burst: 1}
+
+func withPeer(ctx context.Context, ip string) context.Context {
+	return peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 4000}})
+}
+
+func TestRateLimiter(t *testing.T) {
+	user1, _ := randomUser()
+	user2, _ := randomUser()
+
+	testCases := []struct {
+		limits  ratelimit.Limits
+		first   func(t *testing.T, server *Server) context.Context
+		second  func(t *testing.T, server *Server) context.Context
+		method  string
+		code    codes.Code
+		name    string
+		handled int
+	}{
+		{
+			name:   "PublicSameAddress",
+			limits: ratelimit.Limits{Public: oncePerHour},
+			method: pb.SimpleBank_LoginUser_FullMethodName,
+			first: func(t *testing.T, server *Server) context.Context {
+				return withPeer(context.Background(), "192.0.2.1")
+			},
+			second: func(t *testing.T, server *Server) context.Context {
+				return withPeer(context.Background(), "192.0.2.1")
+			},
+			code:    codes.ResourceExhausted,
+			handled: 1,
+		},
+		{
+			name:   "PublicOtherAddress",
+			limits: ratelimit.Limits{Public: oncePerHour},
+			method: pb.SimpleBank_CreateUser_FullMethodName,
+			first: func(t *testing.T, server *Server) context.Context {
+				return withPeer(context.Background(), "192.0.2.1")
+			},
+			second: func(t *testing.T, server *Server) context.Context {
+				return withPeer(context.Background(), "192.0.2.2")
+			},
+			code:    codes.OK,
+			handled: 2,
+		},
+		{
+			name:   "SameUserOtherAddress",
+			limits: ratelimit.Limits{API: oncePerHour},
+			method: pb.SimpleBank_GetAccount_FullMethodName,
+			first: func(t *testing.T, server *Server) context.Context {
+				return withPeer(newContextWithBearerToken(t, server, user1.Username, time.Minute), "192.0.2.1")
+			},
+			second: func(t *testing.T, server *Server) context.Context {
+				return withPeer(newContextWithBearerToken(t, server, user1.Username, time.Minute), "192.0.2.2")
+			},
+			code:    codes.ResourceExhausted,
+			handled: 1,
+		},
+		{
+			name:   "OtherUser",
+			limits: ratelimit.Limits{API: oncePerHour},
+			method: pb.SimpleBank_ListAccounts_FullMethodName,
+			first: func(t *testing.T, server *Server) context.Context {
+				return withPeer(newContextWithBearerToken(t, server, user1.Username, time.Minute), "192.0.2.1")
+			},
+			second: func(t *testing.T, server *Server) context.Context {
+				return withPeer(newContextWithBearerToken(t, server, user2.Username, time.Minute), "192.0.2.1")
+			},
+			code:    codes.OK,
+			handled: 2,
+		},
+		{
+			name:   "APIByIPOtherUser",
+			limits: ratelimit.Limits{APIByIP: oncePerHour},
+			method: pb.SimpleBank_ListAccounts_FullMethodName,
+			first: func(t *testing.T, server *Server) context.Context {
+				return withPeer(newContextWithBearerToken(t, server, user1.Username, time.Minute), "192.0.2.1")
+			},
+			second: func(t *testing.T, server *Server) context.Context {
+				return withPeer(newContextWithBearerToken(t, server, user2.Username, time.Minute), "192.0.2.1")
+			},
+			code:    codes.ResourceExhausted,
+			handled: 1,
+		},
+		{
+			name:   "Transfers",
+			limits: ratelimit.Limits{API: ratelimit.Limit{Rate: 1, Burst: 10}, Transfers: oncePerHour},
+			method: pb.SimpleBank_CreateTransfer_FullMethodName,
+			first: func(t *testing.T, server *Server) context.Context {
+				return newContextWithBearerToken(t, server, user1.Username, time.Minute)
+			},
+			second: func(t *testing.T, server *Server) context.Context {
+				return newContextWithBearerToken(t, server, user1.Username, time.Minute)
+			},
+			code:    codes.ResourceExhausted,
+			handled: 1,
+		},
+		{
+			name:   "WithoutToken",
+			limits: ratelimit.Limits{API: oncePerHour},
+			method: pb.SimpleBank_CreateAccount_FullMethodName,
+			first: func(t *testing.T, server *Server) context.Context {
+				return withPeer(context.Background(), "192.0.2.1")
+			},
+			second: func(t *testing.T, server *Server) context.Context {
+				return withPeer(context.Background(), "192.0.2.1")
+			},
+			code:    codes.ResourceExhausted,
+			handled: 1,
+		},
+		{
+			name:   "OtherService",
+			limits: ratelimit.Limits{Public: oncePerHour, API: oncePerHour},
+			method: healthpb.Health_Check_FullMethodName,
+			first: func(t *testing.T, server *Server) context.Context {
+				return withPeer(context.Background(), "192.0.2.1")
+			},
+			second: func(t *testing.T, server *Server) context.Context {
+				return withPeer(context.Background(), "192.0.2.1")
+			},
+			code:    codes.OK,
+			handled: 2,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server := newTestServer(t, mockdb.NewMockStore(ctrl))
+			server.rateLimits = tc.limits
+			interceptor := server.RateLimiter()
+
+			handled := 0
+			handler := func(ctx context.Context, req any) (any, error) {
+				handled++
+				return nil, nil
+			}
+			info := &grpc.UnaryServerInfo{FullMethod: tc.method}
+
+			_, err := interceptor(tc.first(t, server), nil, info, handler)
+			require.NoError(t, err)
+
+			_, err = interceptor(tc.second(t, server), nil, info, handler)
+			if tc.code == codes.OK {
+				require.NoError(t, err)
+			} else {
+				requireCode(t, err, tc.code)
+			}
+			require.Equal(t, tc.handled, handled)
+		})
+	}
+}
+
+func TestGatewayRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	// only the first request reaches the rpc, the password is rejected before the store
+	store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	server.rateLimits = ratelimit.Limits{Public: oncePerHour}
+	handler, err := NewGatewayHandler(context.Background(), server)
+	require.NoError(t, err)
+
+	login := func() *httptest.ResponseRecorder {
+		body := `{"username": "` + util.RandomOwner() + `", "password": "short"}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/users/login", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	require.Equal(t, http.StatusBadRequest, login().Code)
+	require.Equal(t, http.StatusTooManyRequests, login().Code)
+}
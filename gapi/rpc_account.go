@@ -0,0 +1,145 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"simplebank/token"
+
+	"github.com/lib/pq"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
+	authUser, err := server.authorizeUser(ctx, token.ScopeAccountsWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := requireVerifiedEmail(authUser); err != nil {
+		return nil, err
+	}
+
+	if err := validateCurrency(req.GetCurrency()); err != nil {
+		return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{fieldViolation("currency", err)})
+	}
+
+	account, err := server.store.CreateAccount(ctx, db.CreateAccountParams{
+		Owner:    authUser.Username,
+		Currency: req.GetCurrency(),
+		Balance:  0,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				return nil, status.Errorf(codes.AlreadyExists, "account in %s already exists", req.GetCurrency())
+			case "foreign_key_violation":
+				return nil, status.Errorf(codes.PermissionDenied, "user does not exist")
+			}
+		}
+		return nil, status.Error(codes.Internal, "failed to create account")
+	}
+
+	return &pb.CreateAccountResponse{Account: convertAccount(account)}, nil
+}
+
+func (server *Server) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.GetAccountResponse, error) {
+	authUser, err := server.authorizeUser(ctx, token.ScopeAccountsRead)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateID(req.GetId()); err != nil {
+		return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{fieldViolation("id", err)})
+	}
+
+	account, err := server.store.GetAccount(ctx, req.GetId())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "account %d not found", req.GetId())
+		}
+		return nil, status.Error(codes.Internal, "failed to get account")
+	}
+
+	if account.Owner != authUser.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "account doesn't belong to the authenticated user")
+	}
+
+	return &pb.GetAccountResponse{Account: convertAccount(account)}, nil
+}
+
+func (server *Server) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	authUser, err := server.authorizeUser(ctx, token.ScopeAccountsRead)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	if err := validatePageSize(req.GetPageSize()); err != nil {
+		violations = append(violations, fieldViolation("page_size", err))
+	}
+	afterID, err := decodePageToken(req.GetPageToken())
+	if err != nil {
+		violations = append(violations, fieldViolation("page_token", err))
+	}
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	accounts, err := server.store.ListAccounts(ctx, db.ListAccountsParams{
+		Owner:   authUser.Username,
+		AfterID: afterID,
+		Limit:   req.GetPageSize() + 1,
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to list accounts")
+	}
+
+	res := &pb.ListAccountsResponse{}
+	if len(accounts) > int(req.GetPageSize()) {
+		accounts = accounts[:req.GetPageSize()]
+		res.NextPageToken = encodePageToken(accounts[len(accounts)-1].ID)
+	}
+	for _, account := range accounts {
+		res.Accounts = append(res.Accounts, convertAccount(account))
+	}
+
+	return res, nil
+}
+
+// page tokens use the same format as the cursors of the HTTP API, which can
+// page backward too. ListAccounts only pages forward, a backward cursor is
+// refused rather than read as a forward one.
+type pageToken struct {
+	ID       int64 `json:"id"`
+	Backward bool  `json:"backward,omitempty"`
+}
+
+func encodePageToken(id int64) string {
+	data, _ := json.Marshal(pageToken{ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageToken(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	var token pageToken
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(data, &token) != nil || token.ID < 1 {
+		return 0, fmt.Errorf("invalid page token")
+	}
+	if token.Backward {
+		return 0, fmt.Errorf("backward page tokens are not supported")
+	}
+	return token.ID, nil
+}
@@ -0,0 +1,131 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"simplebank/token"
+	"simplebank/totp"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	authUser, err := server.authorizeUser(ctx, token.ScopeTransfersWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := requireVerifiedEmail(authUser); err != nil {
+		return nil, err
+	}
+
+	if violations := validateCreateTransferRequest(req); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	fromAccount, err := server.validAccount(ctx, req.GetFromAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	if fromAccount.Owner != authUser.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "from account doesn't belong to the authenticated user")
+	}
+
+	if _, err := server.validAccount(ctx, req.GetToAccountId(), req.GetCurrency()); err != nil {
+		return nil, err
+	}
+
+	if err := server.requireFreshTOTP(ctx, authUser, req.GetAmount(), req.GetTotpCode()); err != nil {
+		return nil, err
+	}
+
+	result, err := server.store.TransferTx(ctx, db.TransferTxParams{
+		FromAccountID: req.GetFromAccountId(),
+		ToAccountID:   req.GetToAccountId(),
+		Amount:        req.GetAmount(),
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to transfer")
+	}
+
+	res := &pb.CreateTransferResponse{
+		Transfer:    convertTransfer(result.Transfer),
+		FromAccount: convertAccount(result.FromAccount),
+		ToAccount:   convertAccount(result.ToAccount),
+		FromEntry:   convertEntry(result.FromEntry),
+		ToEntry:     convertEntry(result.ToEntry),
+	}
+	return res, nil
+}
+
+// requireFreshTOTP asks users who enabled TOTP for a code of the
+// authenticator app on transfers of TOTPTransferThreshold or more, like the
+// HTTP API
+func (server *Server) requireFreshTOTP(ctx context.Context, user db.User, amount int64, code string) error {
+	threshold := server.config.TOTPTransferThreshold
+	if threshold <= 0 || amount < threshold {
+		return nil
+	}
+	if !user.TotpEnabled && !server.config.TOTPTransferRequired {
+		return nil
+	}
+
+	if err := lockedError(user); err != nil {
+		return err
+	}
+
+	err := server.totp.Verify(ctx, user, code)
+	if errors.Is(err, totp.ErrInvalidCode) {
+		if err := server.recordFailedLogin(ctx, user); err != nil {
+			return status.Error(codes.Internal, "failed to record failed login")
+		}
+	}
+	if err != nil {
+		return totpError(err)
+	}
+	return nil
+}
+
+// validAccount loads the account and checks it holds currency, it returns a gRPC status error otherwise
+func (server *Server) validAccount(ctx context.Context, accountID int64, currency string) (db.Account, error) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return account, status.Errorf(codes.NotFound, "account %d not found", accountID)
+		}
+		return account, status.Error(codes.Internal, "failed to get account")
+	}
+
+	if account.Currency != currency {
+		return account, status.Errorf(codes.InvalidArgument, "account %d currency mismatch: %s vs %s", accountID, account.Currency, currency)
+	}
+
+	return account, nil
+}
+
+func validateCreateTransferRequest(req *pb.CreateTransferRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validateID(req.GetFromAccountId()); err != nil {
+		violations = append(violations, fieldViolation("from_account_id", err))
+	}
+	if err := validateID(req.GetToAccountId()); err != nil {
+		violations = append(violations, fieldViolation("to_account_id", err))
+	}
+	if err := validateAmount(req.GetAmount()); err != nil {
+		violations = append(violations, fieldViolation("amount", err))
+	}
+	if err := validateCurrency(req.GetCurrency()); err != nil {
+		violations = append(violations, fieldViolation("currency", err))
+	}
+	if req.GetTotpCode() != "" {
+		if err := validateTOTPCode(req.GetTotpCode()); err != nil {
+			violations = append(violations, fieldViolation("totp_code", err))
+		}
+	}
+	return violations
+}
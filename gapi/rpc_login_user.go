@@ -0,0 +1,176 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
+	"simplebank/pb"
+	"simplebank/token"
+	"simplebank/totp"
+	"simplebank/util"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	if violations := validateLoginUserRequest(req); violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	user, err := server.store.GetUser(ctx, req.GetUsername())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to find user")
+	}
+
+	if err := lockedError(user); err != nil {
+		return nil, err
+	}
+
+	if err := util.CheckPassword(req.GetPassword(), user.HashedPassword); err != nil {
+		if err := server.recordFailedLogin(ctx, user); err != nil {
+			return nil, status.Error(codes.Internal, "failed to record failed login")
+		}
+		return nil, status.Errorf(codes.Unauthenticated, "incorrect password")
+	}
+
+	// wrong codes count as failed logins, the lockout stops guessing them too
+	err = server.totp.VerifyLogin(ctx, user, req.GetTotpCode(), req.GetRecoveryCode())
+	if errors.Is(err, totp.ErrInvalidCode) {
+		if err := server.recordFailedLogin(ctx, user); err != nil {
+			return nil, status.Error(codes.Internal, "failed to record failed login")
+		}
+	}
+	if err != nil {
+		return nil, totpError(err)
+	}
+
+	if user.FailedLoginAttempts > 0 {
+		if err := server.store.ResetFailedLogins(ctx, user.Username); err != nil {
+			return nil, status.Error(codes.Internal, "failed to reset failed logins")
+		}
+	}
+	server.rehashPassword(ctx, user, req.GetPassword())
+
+	mtdt := server.extractMetadata(ctx)
+	_, err = server.store.CreateLogin(ctx, db.CreateLoginParams{
+		Username:  user.Username,
+		ClientIp:  mtdt.ClientIP,
+		UserAgent: mtdt.UserAgent,
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to record login")
+	}
+
+	accessToken, payload, err := server.tokenMaker.CreateToken(user.Username, server.config.AccessTokenDuration, token.Options{
+		Audience: req.GetAudience(),
+		Scopes:   req.GetScopes(),
+		Session:  true,
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to create access token")
+	}
+
+	_, err = server.store.CreateSession(ctx, db.CreateSessionParams{
+		ID:        payload.ID,
+		Username:  user.Username,
+		ClientIp:  mtdt.ClientIP,
+		UserAgent: mtdt.UserAgent,
+		ExpiresAt: payload.ExpiredAt,
+		CreatedAt: payload.IssuedAt,
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to create session")
+	}
+
+	res := &pb.LoginUserResponse{
+		User:        convertUser(user),
+		AccessToken: accessToken,
+	}
+	return res, nil
+}
+
+// lockedError is the status of a user locked after failed logins, nil when not locked
+func lockedError(user db.User) error {
+	lockedFor := time.Until(user.LockedUntil)
+	if lockedFor <= 0 {
+		return nil
+	}
+	return status.Errorf(codes.PermissionDenied, "too many failed login attempts, retry in %d seconds", int(math.Ceil(lockedFor.Seconds())))
+}
+
+// recordFailedLogin counts the wrong password of user and locks them once
+// there are too many
+func (server *Server) recordFailedLogin(ctx context.Context, user db.User) error {
+	updated, err := server.store.RecordFailedLogin(ctx, db.RecordFailedLoginParams{
+		Username:          user.Username,
+		MaxAttempts:       server.config.LoginMaxAttempts,
+		LockoutSeconds:    server.config.LoginLockout.Seconds(),
+		MaxLockoutSeconds: server.config.LoginMaxLockout.Seconds(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if updated.LockedUntil.After(user.LockedUntil) {
+		logging.FromContext(ctx).Warn("user locked after failed logins",
+			"user", user.Username,
+			"failed_login_attempts", updated.FailedLoginAttempts,
+			"locked_until", updated.LockedUntil,
+			"client_ip", server.extractMetadata(ctx).ClientIP,
+		)
+	}
+	return nil
+}
+
+// rehashPassword stores a new hash of the password user just logged in with
+// when the stored one is bcrypt or argon2id of older parameters. It never
+// fails the login, the next one tries again.
+func (server *Server) rehashPassword(ctx context.Context, user db.User, password string) {
+	if !util.NeedsRehash(user.HashedPassword) {
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(password)
+	if err == nil {
+		err = server.store.RehashUserPassword(ctx, db.RehashUserPasswordParams{
+			Username: user.Username,
+			OldHash:  user.HashedPassword,
+			NewHash:  hashedPassword,
+		})
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("can not rehash password", "user", user.Username, "error", err)
+	}
+}
+
+func validateLoginUserRequest(req *pb.LoginUserRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validateUsername(req.GetUsername()); err != nil {
+		violations = append(violations, fieldViolation("username", err))
+	}
+	if err := validatePassword(req.GetPassword()); err != nil {
+		violations = append(violations, fieldViolation("password", err))
+	}
+	if req.GetTotpCode() != "" {
+		if err := validateTOTPCode(req.GetTotpCode()); err != nil {
+			violations = append(violations, fieldViolation("totp_code", err))
+		}
+	}
+	if err := validateScopes(req.GetScopes()); err != nil {
+		violations = append(violations, fieldViolation("scopes", err))
+	}
+	if req.GetAudience() != "" {
+		if err := validateAudience(req.GetAudience()); err != nil {
+			violations = append(violations, fieldViolation("audience", err))
+		}
+	}
+	return violations
+}
@@ -0,0 +1,64 @@
+package gapi
+
+import (
+	"fmt"
+	db "simplebank/db/sqlc"
+	"simplebank/mail"
+	"simplebank/pb"
+	"simplebank/ratelimit"
+	"simplebank/token"
+	"simplebank/totp"
+	"simplebank/util"
+	"simplebank/verification"
+)
+
+// Server serves gRPC requests for our banking service
+type Server struct {
+	pb.UnimplementedSimpleBankServer
+	config     util.Config
+	store      db.Store
+	tokenMaker *token.PasetoMaker
+	totp       *totp.Verifier
+	verifier   *verification.Verifier
+	limiter    ratelimit.Limiter
+	rateLimits ratelimit.Limits
+}
+
+// NewServer creates the gRPC server. limiter is shared with the HTTP API, a
+// client gets the same limits over either API.
+func NewServer(config util.Config, store db.Store, limiter ratelimit.Limiter, limits ratelimit.Limits) (*Server, error) {
+	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("can not create token maker: %w", err)
+	}
+
+	totpVerifier, err := totp.NewVerifier(store, config.TOTPEncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("can not create totp verifier: %w", err)
+	}
+
+	mailer, err := mail.NewSender(mail.Config{
+		Sender:       config.MailSender,
+		From:         config.MailFrom,
+		FileDir:      config.MailFileDir,
+		SMTPHost:     config.SMTPHost,
+		SMTPPort:     config.SMTPPort,
+		SMTPUsername: config.SMTPUsername,
+		SMTPPassword: config.SMTPPassword,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can not create mail sender: %w", err)
+	}
+
+	server := &Server{
+		config:     config,
+		store:      store,
+		tokenMaker: tokenMaker,
+		totp:       totpVerifier,
+		verifier:   verification.NewVerifier(store, mailer, config.AppURL, config.EmailVerifyDuration),
+		limiter:    limiter,
+		rateLimits: limits,
+	}
+
+	return server, nil
+}
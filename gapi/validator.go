@@ -0,0 +1,101 @@
+package gapi
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"simplebank/token"
+	"simplebank/totp"
+	"simplebank/util"
+	"slices"
+)
+
+// the same rules the gin binding tags of the HTTP API enforce
+var isAlphanumeric = regexp.MustCompile(`^[a-zA-Z0-9]+$`).MatchString
+
+func validateUsername(value string) error {
+	if value == "" {
+		return fmt.Errorf("must not be empty")
+	}
+	if !isAlphanumeric(value) {
+		return fmt.Errorf("must contain only letters and digits")
+	}
+	return nil
+}
+
+func validatePassword(value string) error {
+	if len(value) < 6 {
+		return fmt.Errorf("must contain at least 6 characters")
+	}
+	return nil
+}
+
+func validateFullName(value string) error {
+	if value == "" {
+		return fmt.Errorf("must not be empty")
+	}
+	return nil
+}
+
+func validateEmail(value string) error {
+	if _, err := mail.ParseAddress(value); err != nil {
+		return fmt.Errorf("is not a valid email address")
+	}
+	return nil
+}
+
+func validateCurrency(value string) error {
+	if !util.IsSupportedCurrency(value) {
+		return fmt.Errorf("currency %q is not supported", value)
+	}
+	return nil
+}
+
+func validateID(value int64) error {
+	if value < 1 {
+		return fmt.Errorf("must be a positive number")
+	}
+	return nil
+}
+
+func validateAmount(value int64) error {
+	if value <= 0 {
+		return fmt.Errorf("must be greater than 0")
+	}
+	return nil
+}
+
+func validatePageSize(value int32) error {
+	if value < 5 || value > 10 {
+		return fmt.Errorf("must be between 5 and 10")
+	}
+	return nil
+}
+
+var isDigits = regexp.MustCompile(`^[0-9]+$`).MatchString
+
+func validateTOTPCode(value string) error {
+	if len(value) != totp.Digits || !isDigits(value) {
+		return fmt.Errorf("must be %d digits", totp.Digits)
+	}
+	return nil
+}
+
+func validateScopes(values []string) error {
+	for i, value := range values {
+		if !token.IsValidScope(value) {
+			return fmt.Errorf("scope %q is not supported", value)
+		}
+		if slices.Contains(values[:i], value) {
+			return fmt.Errorf("scope %q is repeated", value)
+		}
+	}
+	return nil
+}
+
+func validateAudience(value string) error {
+	if value != token.AudienceHTTP && value != token.AudienceGRPC {
+		return fmt.Errorf("must be %s or %s", token.AudienceHTTP, token.AudienceGRPC)
+	}
+	return nil
+}
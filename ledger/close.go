@@ -0,0 +1,115 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
+	"time"
+)
+
+// closeDelay is how long after midnight UTC the previous day is closed
+const closeDelay = time.Minute
+
+var ErrDayNotOver = errors.New("business date is not over yet")
+
+// Closer runs the end-of-day close of the general ledger
+type Closer struct {
+	store  db.Store
+	signer *Signer
+	now    func() time.Time
+}
+
+func NewCloser(store db.Store, signer *Signer) *Closer {
+	return &Closer{
+		store:  store,
+		signer: signer,
+		now:    time.Now,
+	}
+}
+
+// CloseDay closes the business date, which must be over in UTC
+func (closer *Closer) CloseDay(ctx context.Context, businessDate time.Time) (db.CloseDayTxResult, error) {
+	if !businessDate.Before(today(closer.now())) {
+		return db.CloseDayTxResult{}, fmt.Errorf("%w: %s", ErrDayNotOver, businessDate.Format(time.DateOnly))
+	}
+
+	return closer.store.CloseDayTx(ctx, db.CloseDayTxParams{
+		BusinessDate: businessDate,
+		Sign:         closer.signer.Sign,
+	})
+}
+
+// CatchUp closes every business date from the day after the latest close up to yesterday,
+// one day at a time. Without any close yet, it only closes yesterday.
+func (closer *Closer) CatchUp(ctx context.Context) error {
+	yesterday := today(closer.now()).AddDate(0, 0, -1)
+
+	next := yesterday
+	latest, err := closer.store.GetLatestDailyClose(ctx)
+	if err == nil {
+		next = latest.BusinessDate.AddDate(0, 0, 1)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	for day := next; !day.After(yesterday); day = day.AddDate(0, 0, 1) {
+		if _, err := closer.CloseDay(ctx, day); err != nil {
+			return fmt.Errorf("cannot close %s: %w", day.Format(time.DateOnly), err)
+		}
+		logging.FromContext(ctx).InfoContext(ctx, "closed business date", "business_date", day.Format(time.DateOnly))
+	}
+
+	return nil
+}
+
+// Run catches up on start and then closes each day shortly after it ends, until ctx is done
+func (closer *Closer) Run(ctx context.Context) {
+	for {
+		if err := closer.CatchUp(ctx); err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "daily close failed", "error", err)
+		}
+
+		now := closer.now()
+		timer := time.NewTimer(today(now).AddDate(0, 0, 1).Add(closeDelay).Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// Verify loads a stored close and checks its signature against the stored balances
+func (closer *Closer) Verify(ctx context.Context, businessDate time.Time) error {
+	dailyClose, err := closer.store.GetDailyClose(ctx, businessDate)
+	if err != nil {
+		return err
+	}
+
+	trialBalance := db.TrialBalance{
+		BusinessDate: dailyClose.BusinessDate,
+		PreviousDate: dailyClose.PreviousDate,
+	}
+
+	trialBalance.Accounts, err = closer.store.ListLedgerBalances(ctx, businessDate)
+	if err != nil {
+		return err
+	}
+
+	trialBalance.Currencies, err = closer.store.GetTrialBalance(ctx, businessDate)
+	if err != nil {
+		return err
+	}
+
+	return closer.signer.Verify(trialBalance, dailyClose.Signature)
+}
+
+// today returns the current UTC calendar date
+func today(now time.Time) time.Time {
+	year, month, day := now.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
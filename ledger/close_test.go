@@ -0,0 +1,209 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCloser(t *testing.T, store db.Store, now time.Time) *Closer {
+	signer, err := NewSigner(util.RandomString(32))
+	require.NoError(t, err)
+
+	closer := NewCloser(store, signer)
+	closer.now = func() time.Time { return now }
+	return closer
+}
+
+// signCloseDayTx stubs CloseDayTx by signing a trial balance of the requested date
+func signCloseDayTx(ctx context.Context, arg db.CloseDayTxParams) (db.CloseDayTxResult, error) {
+	trialBalance := randomTrialBalance()
+	trialBalance.BusinessDate = arg.BusinessDate
+
+	signature, err := arg.Sign(trialBalance)
+	if err != nil {
+		return db.CloseDayTxResult{}, err
+	}
+
+	return db.CloseDayTxResult{
+		DailyClose:   db.DailyClose{BusinessDate: arg.BusinessDate, Signature: signature},
+		TrialBalance: trialBalance,
+	}, nil
+}
+
+func TestCloseDay(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 5, 0, 0, time.UTC)
+	yesterday := time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		CloseDayTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(signCloseDayTx)
+
+	closer := newTestCloser(t, store, now)
+	result, err := closer.CloseDay(context.Background(), yesterday)
+	require.NoError(t, err)
+	require.Equal(t, yesterday, result.DailyClose.BusinessDate)
+	require.NoError(t, closer.signer.Verify(result.TrialBalance, result.DailyClose.Signature))
+}
+
+func TestCloseDayNotOver(t *testing.T) {
+	now := time.Date(2026, 10, 1, 23, 59, 0, 0, time.UTC)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		CloseDayTx(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	closer := newTestCloser(t, store, now)
+	_, err := closer.CloseDay(context.Background(), time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+	require.ErrorIs(t, err, ErrDayNotOver)
+}
+
+func TestCatchUp(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 5, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		checkDates func(t *testing.T, dates []time.Time, err error)
+	}{
+		{
+			name: "FirstClose",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLatestDailyClose(gomock.Any()).
+					Times(1).
+					Return(db.DailyClose{}, sql.ErrNoRows)
+			},
+			checkDates: func(t *testing.T, dates []time.Time, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []time.Time{time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)}, dates)
+			},
+		},
+		{
+			name: "MissedDays",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLatestDailyClose(gomock.Any()).
+					Times(1).
+					Return(db.DailyClose{BusinessDate: time.Date(2026, 9, 27, 0, 0, 0, 0, time.UTC)}, nil)
+			},
+			checkDates: func(t *testing.T, dates []time.Time, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []time.Time{
+					time.Date(2026, 9, 28, 0, 0, 0, 0, time.UTC),
+					time.Date(2026, 9, 29, 0, 0, 0, 0, time.UTC),
+					time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC),
+				}, dates)
+			},
+		},
+		{
+			name: "UpToDate",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLatestDailyClose(gomock.Any()).
+					Times(1).
+					Return(db.DailyClose{BusinessDate: time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)}, nil)
+			},
+			checkDates: func(t *testing.T, dates []time.Time, err error) {
+				require.NoError(t, err)
+				require.Empty(t, dates)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLatestDailyClose(gomock.Any()).
+					Times(1).
+					Return(db.DailyClose{}, sql.ErrConnDone)
+			},
+			checkDates: func(t *testing.T, dates []time.Time, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+				require.Empty(t, dates)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			var dates []time.Time
+			store.EXPECT().
+				CloseDayTx(gomock.Any(), gomock.Any()).
+				AnyTimes().
+				DoAndReturn(func(ctx context.Context, arg db.CloseDayTxParams) (db.CloseDayTxResult, error) {
+					dates = append(dates, arg.BusinessDate)
+					return signCloseDayTx(ctx, arg)
+				})
+
+			closer := newTestCloser(t, store, now)
+			err := closer.CatchUp(context.Background())
+			tc.checkDates(t, dates, err)
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 5, 0, 0, time.UTC)
+	trialBalance := randomTrialBalance()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	closer := newTestCloser(t, store, now)
+
+	signature, err := closer.signer.Sign(trialBalance)
+	require.NoError(t, err)
+
+	store.EXPECT().
+		GetDailyClose(gomock.Any(), gomock.Eq(trialBalance.BusinessDate)).
+		Times(2).
+		Return(db.DailyClose{
+			BusinessDate: trialBalance.BusinessDate,
+			PreviousDate: trialBalance.PreviousDate,
+			Signature:    signature,
+		}, nil)
+	store.EXPECT().
+		GetTrialBalance(gomock.Any(), gomock.Eq(trialBalance.BusinessDate)).
+		Times(2).
+		Return(trialBalance.Currencies, nil)
+
+	store.EXPECT().
+		ListLedgerBalances(gomock.Any(), gomock.Eq(trialBalance.BusinessDate)).
+		Times(1).
+		Return(trialBalance.Accounts, nil)
+	require.NoError(t, closer.Verify(context.Background(), trialBalance.BusinessDate))
+
+	// a balance changed after the close
+	tampered := append([]db.LedgerBalance{}, trialBalance.Accounts...)
+	tampered[1].Closing--
+	store.EXPECT().
+		ListLedgerBalances(gomock.Any(), gomock.Eq(trialBalance.BusinessDate)).
+		Times(1).
+		Return(tampered, nil)
+	require.ErrorIs(t, closer.Verify(context.Background(), trialBalance.BusinessDate), ErrInvalidSignature)
+}
@@ -0,0 +1,73 @@
+package ledger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	db "simplebank/db/sqlc"
+	"strings"
+	"time"
+)
+
+const minSecretKeySize = 32
+
+var ErrInvalidSignature = errors.New("daily close signature is invalid")
+
+// Signer signs daily close records with HMAC-SHA256
+type Signer struct {
+	secretKey []byte
+}
+
+func NewSigner(secretKey string) (*Signer, error) {
+	if len(secretKey) < minSecretKeySize {
+		return nil, fmt.Errorf("invalid key size: must be at least %d characters", minSecretKeySize)
+	}
+	return &Signer{secretKey: []byte(secretKey)}, nil
+}
+
+// Sign returns the hex encoded signature of the trial balance
+func (signer *Signer) Sign(trialBalance db.TrialBalance) (string, error) {
+	mac := hmac.New(sha256.New, signer.secretKey)
+	if _, err := mac.Write(canonical(trialBalance)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Verify checks signature was produced by Sign for the same trial balance
+func (signer *Signer) Verify(trialBalance db.TrialBalance, signature string) error {
+	want, err := signer.Sign(trialBalance)
+	if err != nil {
+		return err
+	}
+
+	if !hmac.Equal([]byte(want), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// canonical serialises the trial balance one record per line, in the order the store returns them
+func canonical(trialBalance db.TrialBalance) []byte {
+	var b strings.Builder
+
+	previous := "-"
+	if trialBalance.PreviousDate.Valid {
+		previous = trialBalance.PreviousDate.Time.Format(time.DateOnly)
+	}
+	fmt.Fprintf(&b, "close %s %s\n", trialBalance.BusinessDate.Format(time.DateOnly), previous)
+
+	for _, balance := range trialBalance.Accounts {
+		fmt.Fprintf(&b, "account %d %d %d %d %d\n",
+			balance.LedgerAccountID, balance.Opening, balance.Debits, balance.Credits, balance.Closing)
+	}
+
+	for _, row := range trialBalance.Currencies {
+		fmt.Fprintf(&b, "currency %s %d %d %d %d\n",
+			row.Currency, row.Debits, row.Credits, row.DebitBalances, row.CreditBalances)
+	}
+
+	return []byte(b.String())
+}
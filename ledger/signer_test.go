@@ -0,0 +1,63 @@
+package ledger
+
+import (
+	"database/sql"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func randomTrialBalance() db.TrialBalance {
+	businessDate := time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)
+	amount := util.RandomBalance()
+
+	return db.TrialBalance{
+		BusinessDate: businessDate,
+		PreviousDate: sql.NullTime{Time: businessDate.AddDate(0, 0, -1), Valid: true},
+		Accounts: []db.LedgerBalance{
+			{BusinessDate: businessDate, LedgerAccountID: 1, Debits: amount, Closing: amount},
+			{BusinessDate: businessDate, LedgerAccountID: 2, Credits: amount, Closing: -amount},
+		},
+		Currencies: []db.GetTrialBalanceRow{
+			{Currency: util.USD, Debits: amount, Credits: amount, DebitBalances: amount, CreditBalances: amount},
+		},
+	}
+}
+
+func TestSigner(t *testing.T) {
+	signer, err := NewSigner(util.RandomString(32))
+	require.NoError(t, err)
+
+	trialBalance := randomTrialBalance()
+	signature, err := signer.Sign(trialBalance)
+	require.NoError(t, err)
+	require.Len(t, signature, 64)
+
+	require.NoError(t, signer.Verify(trialBalance, signature))
+
+	// any change to a balance invalidates the signature
+	trialBalance.Accounts[0].Closing++
+	require.ErrorIs(t, signer.Verify(trialBalance, signature), ErrInvalidSignature)
+}
+
+func TestSignerWrongKey(t *testing.T) {
+	signer1, err := NewSigner(util.RandomString(32))
+	require.NoError(t, err)
+	signer2, err := NewSigner(util.RandomString(32))
+	require.NoError(t, err)
+
+	trialBalance := randomTrialBalance()
+	signature, err := signer1.Sign(trialBalance)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, signer2.Verify(trialBalance, signature), ErrInvalidSignature)
+}
+
+func TestSignerInvalidKeySize(t *testing.T) {
+	signer, err := NewSigner(util.RandomString(31))
+	require.Error(t, err)
+	require.Nil(t, signer)
+}
@@ -0,0 +1,78 @@
+// Package logging sets up the structured logger and carries the logger of a
+// request through context.Context, so every layer logs with the same request id
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// attribute keys shared by every component
+const (
+	KeyRequestID = "request_id"
+	KeyUsername  = "username"
+	KeyRoute     = "route"
+	KeyTraceID   = "trace_id"
+)
+
+type loggerKey struct{}
+
+// New creates a JSON logger writing to w, level is one of debug, info, warn or error
+func New(w io.Writer, level string) *slog.Logger {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		lvl = slog.LevelInfo
+	}
+
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: lvl}))
+}
+
+// WithContext returns a copy of ctx carrying logger
+func WithContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger of ctx, or the default logger if there is none
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// With returns a copy of ctx whose logger has the additional attributes
+func With(ctx context.Context, args ...any) context.Context {
+	return WithContext(ctx, FromContext(ctx).With(args...))
+}
+
+// RequestID keeps the request id sent by the caller when it is valid and
+// generates a new one otherwise. Valid ids have up to 64 printable ASCII
+// characters, anything else could be used to forge log lines.
+func RequestID(id string) string {
+	if id == "" || len(id) > 64 {
+		return uuid.NewString()
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return uuid.NewString()
+		}
+	}
+	return id
+}
+
+// StatusLevel is the level of an access log line for an HTTP status
+func StatusLevel(status int) slog.Level {
+	switch {
+	case status >= 500:
+		return slog.LevelError
+	case status >= 400:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "warn")
+
+	logger.Info("hidden")
+	logger.Warn("shown", KeyRequestID, "abc")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	require.Equal(t, "shown", entry["msg"])
+	require.Equal(t, "WARN", entry["level"])
+	require.Equal(t, "abc", entry[KeyRequestID])
+}
+
+func TestNewInvalidLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "verbose")
+
+	logger.Debug("hidden")
+	logger.Info("shown")
+	require.Equal(t, 1, strings.Count(buf.String(), "\n"))
+}
+
+func TestFromContext(t *testing.T) {
+	require.Equal(t, slog.Default(), FromContext(context.Background()))
+
+	var buf bytes.Buffer
+	ctx := WithContext(context.Background(), New(&buf, "info"))
+	ctx = With(ctx, KeyUsername, "alice")
+
+	FromContext(ctx).Info("hello")
+	require.Contains(t, buf.String(), `"username":"alice"`)
+}
+
+func TestRequestID(t *testing.T) {
+	require.Equal(t, "4f1c2a9e-req", RequestID("4f1c2a9e-req"))
+
+	for _, id := range []string{"", strings.Repeat("a", 65), "id\nforged log line", "id with spaces"} {
+		generated := RequestID(id)
+		require.NotEqual(t, id, generated)
+		require.Len(t, generated, 36)
+	}
+}
+
+func TestStatusLevel(t *testing.T) {
+	require.Equal(t, slog.LevelInfo, StatusLevel(http.StatusOK))
+	require.Equal(t, slog.LevelWarn, StatusLevel(http.StatusNotFound))
+	require.Equal(t, slog.LevelError, StatusLevel(http.StatusInternalServerError))
+}
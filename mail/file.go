@@ -0,0 +1,35 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// FileSender writes every email as an .eml file into a directory instead of
+// sending it, to read the links locally
+type FileSender struct {
+	from  string
+	dir   string
+	count atomic.Int64
+}
+
+func NewFileSender(from, dir string) *FileSender {
+	return &FileSender{from: from, dir: dir}
+}
+
+func (sender *FileSender) Send(ctx context.Context, email Email) error {
+	if err := os.MkdirAll(sender.dir, 0o700); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	recipient := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(email.To)
+	name := fmt.Sprintf("%s-%03d-%s.eml", now.Format("20060102T150405.000000"), sender.count.Add(1)%1000, recipient)
+
+	return os.WriteFile(filepath.Join(sender.dir, name), message(sender.from, email, now), 0o600)
+}
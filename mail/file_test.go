@@ -0,0 +1,38 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSender(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	sender := NewFileSender("SimpleBank <no-reply@simplebank.local>", dir)
+
+	err := sender.Send(context.Background(), Email{
+		To:      "alice@example.com",
+		Subject: "Reset your password",
+		Body:    "line 1\nline 2",
+	})
+	require.NoError(t, err)
+
+	err = sender.Send(context.Background(), Email{To: "../bob@example.com", Subject: "Hello", Body: "hi"})
+	require.NoError(t, err)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	require.Contains(t, files[0].Name(), "alice_at_example.com")
+	require.Contains(t, string(data), "From: SimpleBank <no-reply@simplebank.local>\r\n")
+	require.Contains(t, string(data), "To: alice@example.com\r\n")
+	require.Contains(t, string(data), "Subject: Reset your password\r\n")
+	require.Contains(t, string(data), "\r\n\r\nline 1\r\nline 2")
+	require.NotContains(t, files[1].Name(), "/")
+}
@@ -0,0 +1,29 @@
+package mail
+
+import "fmt"
+
+// Config picks the sender of NewSender and sets it up
+type Config struct {
+	// Sender is "file" (default) or "smtp"
+	Sender       string
+	From         string
+	FileDir      string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+}
+
+func NewSender(config Config) (Sender, error) {
+	switch config.Sender {
+	case "", "file":
+		return NewFileSender(config.From, config.FileDir), nil
+	case "smtp":
+		if config.SMTPHost == "" || config.SMTPPort == 0 {
+			return nil, fmt.Errorf("smtp mail sender needs a host and a port")
+		}
+		return NewSMTPSender(config.From, config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword), nil
+	default:
+		return nil, fmt.Errorf("unknown mail sender %q", config.Sender)
+	}
+}
@@ -0,0 +1,103 @@
+package mail
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer accepts one session without TLS nor auth and returns the
+// commands and the message it received
+func fakeSMTPServer(t *testing.T) (int, <-chan []string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan []string, 1)
+	go func() {
+		var lines []string
+		defer func() { received <- lines }()
+
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		text := textproto.NewConn(conn)
+		_ = text.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			lines = append(lines, line)
+
+			switch command := strings.ToUpper(strings.Fields(line)[0]); command {
+			case "EHLO", "HELO":
+				_ = text.PrintfLine("250 localhost")
+			case "DATA":
+				_ = text.PrintfLine("354 go ahead")
+				data, err := text.ReadDotLines()
+				if err != nil {
+					return
+				}
+				lines = append(lines, data...)
+				_ = text.PrintfLine("250 queued")
+			case "QUIT":
+				_ = text.PrintfLine("221 bye")
+				return
+			default:
+				_ = text.PrintfLine("250 ok")
+			}
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port, received
+}
+
+func TestSMTPSender(t *testing.T) {
+	port, received := fakeSMTPServer(t)
+	sender := NewSMTPSender("SimpleBank <no-reply@simplebank.local>", "127.0.0.1", port, "", "")
+
+	err := sender.Send(context.Background(), Email{
+		To:      "alice@example.com",
+		Subject: "Verify your email",
+		Body:    "line 1\n.line 2",
+	})
+	require.NoError(t, err)
+
+	lines := <-received
+	require.Contains(t, lines, "MAIL FROM:<no-reply@simplebank.local>")
+	require.Contains(t, lines, "RCPT TO:<alice@example.com>")
+	require.Contains(t, lines, "Subject: Verify your email")
+	require.Contains(t, lines, ".line 2")
+	require.Equal(t, "QUIT", lines[len(lines)-1])
+}
+
+func TestSMTPSenderInvalidRecipient(t *testing.T) {
+	sender := NewSMTPSender("no-reply@simplebank.local", "127.0.0.1", 25, "", "")
+	err := sender.Send(context.Background(), Email{To: "not an address"})
+	require.ErrorContains(t, err, "invalid recipient address")
+}
+
+func TestNewSender(t *testing.T) {
+	sender, err := NewSender(Config{FileDir: t.TempDir()})
+	require.NoError(t, err)
+	require.IsType(t, &FileSender{}, sender)
+
+	sender, err = NewSender(Config{Sender: "smtp", SMTPHost: "localhost", SMTPPort: 587})
+	require.NoError(t, err)
+	require.IsType(t, &SMTPSender{}, sender)
+
+	_, err = NewSender(Config{Sender: "smtp"})
+	require.Error(t, err)
+
+	_, err = NewSender(Config{Sender: "carrier-pigeon"})
+	require.ErrorContains(t, err, strconv.Quote("carrier-pigeon"))
+}
@@ -1,13 +1,35 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
 	"simplebank/api"
 	db "simplebank/db/sqlc"
+	"simplebank/gapi"
+	"simplebank/ledger"
+	"simplebank/logging"
+	"simplebank/metrics"
+	"simplebank/pb"
+	"simplebank/ratelimit"
+	"simplebank/tracing"
 	"simplebank/util"
+	"syscall"
 
 	_ "github.com/lib/pq"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
 func main() {
@@ -15,19 +37,223 @@ func main() {
 	if err != nil {
 		log.Fatal("cannot load config: ", err.Error())
 	}
+	slog.SetDefault(logging.New(os.Stdout, config.LogLevel))
+
+	shutdownTracing, err := tracing.Setup(context.Background(), config.TracingExporter, os.Stdout)
+	if err != nil {
+		log.Fatal("cannot set up tracing: ", err.Error())
+	}
+	defer func() {
+		shutdownCtx, cancel := shutdownContext(config)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			slog.Error("cannot flush traces", "error", err)
+		}
+	}()
+
 	conn, err := sql.Open(config.DBDrive, config.DBSource)
 	if err != nil {
 		log.Fatal("cannot connect to db:", err.Error())
 	}
 
-	store := db.NewStore(conn)
-	server, err := api.NewServer(config, store)
+	if err := metrics.RegisterDBStats(conn); err != nil {
+		log.Fatal("cannot register db metrics: ", err.Error())
+	}
+
+	store := metrics.NewStore(db.NewStore(conn))
+
+	// one limiter for the HTTP API, the gRPC server and the gateway, a client
+	// switching between them still draws from the same buckets
+	limiter, limits, err := ratelimit.New(config, store)
 	if err != nil {
-		log.Fatal("cannot create server: ", err.Error())
+		log.Fatal("cannot create rate limiter: ", err.Error())
 	}
 
-	err = server.Start(config.ServerAddress)
+	signer, err := ledger.NewSigner(config.CloseSigningKey)
 	if err != nil {
-		log.Fatal("cannot start server: ", err.Error())
+		log.Fatal("cannot create close signer: ", err.Error())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	waitGroup, ctx := errgroup.WithContext(ctx)
+
+	waitGroup.Go(func() error {
+		// a close interrupted by the shutdown is rolled back and redone by the next start
+		ledger.NewCloser(store, signer).Run(ctx)
+		slog.Info("daily close worker stopped")
+		return nil
+	})
+
+	runGrpcServer(ctx, waitGroup, config, store, limiter, limits)
+	runGatewayServer(ctx, waitGroup, config, store, limiter, limits)
+	runGinServer(ctx, waitGroup, config, store, limiter, limits)
+	runMetricsServer(ctx, waitGroup, config)
+
+	if err := waitGroup.Wait(); err != nil {
+		log.Fatal("error from wait group: ", err.Error())
+	}
+
+	if err := conn.Close(); err != nil {
+		slog.Error("cannot close db connections", "error", err)
 	}
+	slog.Info("shutdown complete")
+}
+
+// shutdownContext bounds how long a server may drain its in-flight requests
+func shutdownContext(config util.Config) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), config.ShutdownTimeout)
+}
+
+func runGrpcServer(ctx context.Context, waitGroup *errgroup.Group, config util.Config, store db.Store, limiter ratelimit.Limiter, limits ratelimit.Limits) {
+	server, err := gapi.NewServer(config, store, limiter, limits)
+	if err != nil {
+		log.Fatal("cannot create gRPC server: ", err.Error())
+	}
+
+	grpcServer := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(gapi.GrpcLogger(slog.Default()), server.RateLimiter()),
+	)
+	pb.RegisterSimpleBankServer(grpcServer, server)
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	reflection.Register(grpcServer)
+
+	listener, err := net.Listen("tcp", config.GRPCServerAddress)
+	if err != nil {
+		log.Fatal("cannot create listener: ", err.Error())
+	}
+
+	waitGroup.Go(func() error {
+		slog.Info("start gRPC server", "address", listener.Addr().String())
+		if err := grpcServer.Serve(listener); err != nil {
+			return fmt.Errorf("gRPC server failed: %w", err)
+		}
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+		slog.Info("graceful shutdown gRPC server")
+		healthServer.Shutdown()
+
+		shutdownCtx, cancel := shutdownContext(config)
+		defer cancel()
+
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-shutdownCtx.Done():
+			slog.Warn("gRPC server shutdown timed out, closing open connections")
+			grpcServer.Stop()
+		}
+		slog.Info("gRPC server stopped")
+		return nil
+	})
+}
+
+func runGatewayServer(ctx context.Context, waitGroup *errgroup.Group, config util.Config, store db.Store, limiter ratelimit.Limiter, limits ratelimit.Limits) {
+	server, err := gapi.NewServer(config, store, limiter, limits)
+	if err != nil {
+		log.Fatal("cannot create gRPC server: ", err.Error())
+	}
+
+	handler, err := gapi.NewGatewayHandler(ctx, server)
+	if err != nil {
+		log.Fatal("cannot register gateway handler: ", err.Error())
+	}
+
+	httpServer := &http.Server{
+		Addr:    config.HTTPGatewayAddress,
+		Handler: handler,
+	}
+
+	waitGroup.Go(func() error {
+		slog.Info("start HTTP gateway server", "address", config.HTTPGatewayAddress)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("HTTP gateway server failed: %w", err)
+		}
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+		slog.Info("graceful shutdown HTTP gateway server")
+
+		shutdownCtx, cancel := shutdownContext(config)
+		defer cancel()
+
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("cannot shut down HTTP gateway server: %w", err)
+		}
+		slog.Info("HTTP gateway server stopped")
+		return nil
+	})
+}
+
+func runGinServer(ctx context.Context, waitGroup *errgroup.Group, config util.Config, store db.Store, limiter ratelimit.Limiter, limits ratelimit.Limits) {
+	server, err := api.NewServer(config, store, limiter, limits)
+	if err != nil {
+		log.Fatal("cannot create server: ", err.Error())
+	}
+
+	waitGroup.Go(func() error {
+		slog.Info("start HTTP server", "address", config.ServerAddress)
+		if err := server.Start(config.ServerAddress); err != nil {
+			return fmt.Errorf("HTTP server failed: %w", err)
+		}
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+		slog.Info("graceful shutdown HTTP server")
+
+		shutdownCtx, cancel := shutdownContext(config)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("cannot shut down HTTP server: %w", err)
+		}
+		slog.Info("HTTP server stopped")
+		return nil
+	})
+}
+
+// runMetricsServer serves the Prometheus metrics on their own address, which
+// is kept internal. Without METRICS_ADDRESS they aren't served.
+func runMetricsServer(ctx context.Context, waitGroup *errgroup.Group, config util.Config) {
+	if config.MetricsAddress == "" {
+		return
+	}
+
+	httpServer := metrics.NewServer(config.MetricsAddress)
+
+	waitGroup.Go(func() error {
+		slog.Info("start metrics server", "address", config.MetricsAddress)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("metrics server failed: %w", err)
+		}
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := shutdownContext(config)
+		defer cancel()
+
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("cannot shut down metrics server: %w", err)
+		}
+		slog.Info("metrics server stopped")
+		return nil
+	})
 }
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveHTTPRequest(t *testing.T) {
+	before := testutil.CollectAndCount(httpRequestDuration)
+
+	ObserveHTTPRequest(http.MethodGet, "/accounts/:id", http.StatusOK, 10*time.Millisecond)
+	ObserveHTTPRequest(http.MethodGet, "", http.StatusNotFound, time.Millisecond)
+
+	require.Equal(t, before+2, testutil.CollectAndCount(httpRequestDuration))
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `simple_bank_http_request_duration_seconds_count{method="GET",route="/accounts/:id",status="200"}`)
+	require.Contains(t, w.Body.String(), `route="unmatched",status="404"`)
+}
+
+func TestObserveHTTPRequestMethod(t *testing.T) {
+	ObserveHTTPRequest("PROPFIND", "/accounts", http.StatusNotFound, time.Millisecond)
+	ObserveHTTPRequest("X-RANDOM-1234", "/accounts", http.StatusNotFound, time.Millisecond)
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Contains(t, w.Body.String(), `simple_bank_http_request_duration_seconds_count{method="other",route="/accounts",status="404"}`)
+	require.NotContains(t, w.Body.String(), "PROPFIND")
+	require.NotContains(t, w.Body.String(), "X-RANDOM-1234")
+}
+
+func TestServer(t *testing.T) {
+	server := NewServer("127.0.0.1:0")
+
+	w := httptest.NewRecorder()
+	server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), "go_goroutines")
+
+	w = httptest.NewRecorder()
+	server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/accounts", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRegisterDBStats(t *testing.T) {
+	conn, err := sql.Open("postgres", "postgres://localhost/simple_bank?sslmode=disable")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, RegisterDBStats(conn))
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Contains(t, w.Body.String(), "go_sql_max_open_connections")
+}
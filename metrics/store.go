@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"context"
+	db "simplebank/db/sqlc"
+	"time"
+)
+
+// store records metrics of the transactions of the Store it decorates
+type store struct {
+	db.Store
+}
+
+// NewStore decorates a Store with transaction metrics
+func NewStore(s db.Store) db.Store {
+	return &store{Store: s}
+}
+
+func (s *store) TransferTx(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+	start := time.Now()
+	result, err := s.Store.TransferTx(ctx, arg)
+
+	outcome := OutcomeSuccess
+	if err != nil {
+		outcome = OutcomeError
+	}
+	transferTxDuration.WithLabelValues(outcome).Observe(time.Since(start).Seconds())
+	transferTxTotal.WithLabelValues(outcome).Inc()
+
+	if err == nil {
+		// amounts are kept in cents
+		transferredAmount.WithLabelValues(result.FromAccount.Currency).Add(float64(arg.Amount) / 100)
+	}
+
+	return result, err
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreTransferTx(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mockdb.NewMockStore(ctrl)
+	store := NewStore(mockStore)
+
+	arg := db.TransferTxParams{FromAccountID: 1, ToAccountID: 2, Amount: 1050}
+	result := db.TransferTxResult{
+		Transfer:    db.Transfer{ID: 1},
+		FromAccount: db.Account{ID: 1, Currency: util.EUR},
+	}
+
+	success := testutil.ToFloat64(transferTxTotal.WithLabelValues(OutcomeSuccess))
+	failure := testutil.ToFloat64(transferTxTotal.WithLabelValues(OutcomeError))
+	amount := testutil.ToFloat64(transferredAmount.WithLabelValues(util.EUR))
+
+	mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+	got, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, result, got)
+
+	mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.TransferTxResult{}, sql.ErrConnDone)
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+
+	require.Equal(t, success+1, testutil.ToFloat64(transferTxTotal.WithLabelValues(OutcomeSuccess)))
+	require.Equal(t, failure+1, testutil.ToFloat64(transferTxTotal.WithLabelValues(OutcomeError)))
+	require.InDelta(t, amount+10.50, testutil.ToFloat64(transferredAmount.WithLabelValues(util.EUR)), 1e-9)
+}
+
+func TestStoreDelegates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mockdb.NewMockStore(ctrl)
+	store := NewStore(mockStore)
+
+	account := db.Account{ID: 1, Owner: util.RandomOwner()}
+	mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+	got, err := store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account, got)
+}
@@ -0,0 +1,55 @@
+package oauth
+
+import (
+	"context"
+	db "simplebank/db/sqlc"
+	"slices"
+	"time"
+)
+
+// Permissions a consent grants over its accounts
+const (
+	// PermissionBalances reads the accounts and their balances
+	PermissionBalances = "balances"
+	// PermissionTransactions reads the entries, transfers and statements of
+	// the accounts
+	PermissionTransactions = "transactions"
+)
+
+// AccountAccess is the consent a user gives along with an approval, which
+// accounts the client may read and how. There is none when AccountIDs is
+// empty.
+type AccountAccess struct {
+	AccountIDs  []int64
+	Permissions []string
+	Duration    time.Duration
+}
+
+func (provider *Provider) createConsent(ctx context.Context, username, clientID string, access AccountAccess) error {
+	if len(access.AccountIDs) == 0 {
+		return nil
+	}
+	if len(access.Permissions) == 0 || access.Duration <= 0 {
+		return newError(ErrCodeInvalidRequest, "a consent needs permissions and a duration")
+	}
+	for _, permission := range access.Permissions {
+		if permission != PermissionBalances && permission != PermissionTransactions {
+			return newError(ErrCodeInvalidRequest, "unknown permission %s", permission)
+		}
+	}
+
+	_, err := provider.store.CreateConsent(ctx, db.CreateConsentParams{
+		ClientID:    clientID,
+		Username:    username,
+		AccountIds:  access.AccountIDs,
+		Permissions: access.Permissions,
+		ExpiresAt:   time.Now().Add(access.Duration),
+	})
+	return err
+}
+
+// ConsentAllows tells whether consent lets its client read accountID with
+// permission
+func ConsentAllows(consent db.Consent, accountID int64, permission string) bool {
+	return slices.Contains(consent.AccountIds, accountID) && slices.Contains(consent.Permissions, permission)
+}
@@ -0,0 +1,112 @@
+package oauth
+
+import (
+	"context"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApproveAccountAccess(t *testing.T) {
+	client, _ := randomClient(t)
+	username := util.RandomOwner()
+	req := AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            client.ID,
+		CodeChallenge:       CodeChallenge(testVerifier),
+		CodeChallengeMethod: "S256",
+	}
+
+	testCases := []struct {
+		name    string
+		access  AccountAccess
+		errCode string
+	}{
+		{
+			name: "OK",
+			access: AccountAccess{
+				AccountIDs:  []int64{1, 2},
+				Permissions: []string{PermissionBalances, PermissionTransactions},
+				Duration:    30 * 24 * time.Hour,
+			},
+		},
+		{
+			name: "UnknownPermission",
+			access: AccountAccess{
+				AccountIDs:  []int64{1},
+				Permissions: []string{"payments"},
+				Duration:    time.Hour,
+			},
+			errCode: ErrCodeInvalidRequest,
+		},
+		{
+			name: "NoPermissions",
+			access: AccountAccess{
+				AccountIDs: []int64{1},
+				Duration:   time.Hour,
+			},
+			errCode: ErrCodeInvalidRequest,
+		},
+		{
+			name: "NoDuration",
+			access: AccountAccess{
+				AccountIDs:  []int64{1},
+				Permissions: []string{PermissionBalances},
+			},
+			errCode: ErrCodeInvalidRequest,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ID)).Times(1).Return(client, nil)
+			if tc.errCode == "" {
+				store.EXPECT().
+					CreateConsent(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateConsentParams) (db.Consent, error) {
+						require.Equal(t, client.ID, arg.ClientID)
+						require.Equal(t, username, arg.Username)
+						require.Equal(t, tc.access.AccountIDs, arg.AccountIds)
+						require.Equal(t, tc.access.Permissions, arg.Permissions)
+						require.WithinDuration(t, time.Now().Add(tc.access.Duration), arg.ExpiresAt, time.Second)
+						return db.Consent{}, nil
+					})
+				store.EXPECT().CreateOAuthAuthorizationCode(gomock.Any(), gomock.Any()).Times(1)
+			} else {
+				store.EXPECT().CreateConsent(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateOAuthAuthorizationCode(gomock.Any(), gomock.Any()).Times(0)
+			}
+			provider, _ := newTestProvider(t, store)
+
+			_, err := provider.Approve(context.Background(), username, req, tc.access)
+			if tc.errCode != "" {
+				requireOAuthError(t, err, tc.errCode)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestConsentAllows(t *testing.T) {
+	consent := db.Consent{
+		AccountIds:  []int64{1, 2},
+		Permissions: []string{PermissionBalances},
+	}
+
+	require.True(t, ConsentAllows(consent, 1, PermissionBalances))
+	require.False(t, ConsentAllows(consent, 3, PermissionBalances))
+	require.False(t, ConsentAllows(consent, 1, PermissionTransactions))
+}
@@ -0,0 +1,32 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"regexp"
+)
+
+// code verifiers are 43 to 128 unreserved characters, the S256 challenge of
+// one is always 43 characters of base64url
+var (
+	isCodeVerifier  = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`).MatchString
+	isCodeChallenge = regexp.MustCompile(`^[A-Za-z0-9\-_]{43}$`).MatchString
+)
+
+func isValidCodeChallenge(challenge string) bool {
+	return isCodeChallenge(challenge)
+}
+
+// CodeChallenge returns the S256 code challenge of verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func verifyCodeChallenge(verifier, challenge string) bool {
+	if !isCodeVerifier(verifier) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(CodeChallenge(verifier)), []byte(challenge)) == 1
+}
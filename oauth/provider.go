@@ -0,0 +1,367 @@
+// Package oauth is the OAuth 2.0 authorization server third-party apps get
+// delegated access through, with the authorization code flow bound to PKCE
+// (RFC 7636) and the client credentials flow
+package oauth
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// codeDuration is how long an authorization code can be exchanged, RFC
+	// 6749 recommends at most 10 minutes
+	codeDuration = 10 * time.Minute
+	// clientSecretPrefix starts every client secret, secret scanners and
+	// people can tell them apart from other tokens
+	clientSecretPrefix = "sbs_"
+)
+
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeClientCredentials = "client_credentials"
+)
+
+// Error codes of RFC 6749 section 4.1.2.1 and 5.2
+const (
+	ErrCodeInvalidRequest          = "invalid_request"
+	ErrCodeInvalidClient           = "invalid_client"
+	ErrCodeInvalidGrant            = "invalid_grant"
+	ErrCodeUnsupportedGrantType    = "unsupported_grant_type"
+	ErrCodeUnsupportedResponseType = "unsupported_response_type"
+	ErrCodeInvalidScope            = "invalid_scope"
+	ErrCodeAccessDenied            = "access_denied"
+)
+
+// Error is an OAuth error whose Code is one of the RFC 6749 error codes, its
+// description is safe to show to the client
+type Error struct {
+	Code        string
+	Description string
+}
+
+func newError(code, format string, args ...any) *Error {
+	return &Error{Code: code, Description: fmt.Sprintf(format, args...)}
+}
+
+func (err *Error) Error() string {
+	return err.Code + ": " + err.Description
+}
+
+// Provider registers clients, asks users for consent and issues the tokens
+// clients act with
+type Provider struct {
+	store         db.Store
+	tokenMaker    *token.PasetoMaker
+	tokenDuration time.Duration
+}
+
+// NewProvider creates a Provider issuing tokens of tokenMaker valid for
+// tokenDuration
+func NewProvider(store db.Store, tokenMaker *token.PasetoMaker, tokenDuration time.Duration) *Provider {
+	return &Provider{
+		store:         store,
+		tokenMaker:    tokenMaker,
+		tokenDuration: tokenDuration,
+	}
+}
+
+// RegisterClientParams describe a new client. Scopes are the most it may
+// ever ask for.
+type RegisterClientParams struct {
+	Owner        string
+	Name         string
+	RedirectURIs []string
+	Scopes       []string
+}
+
+// RegisterClient creates a client owned by a user and returns it with its
+// secret, which is only known to the caller from then on
+func (provider *Provider) RegisterClient(ctx context.Context, arg RegisterClientParams) (db.OauthClient, string, error) {
+	for _, redirectURI := range arg.RedirectURIs {
+		if err := validateRedirectURI(redirectURI); err != nil {
+			return db.OauthClient{}, "", err
+		}
+	}
+	for _, scope := range arg.Scopes {
+		if !token.IsValidScope(scope) {
+			return db.OauthClient{}, "", newError(ErrCodeInvalidScope, "unknown scope %s", scope)
+		}
+	}
+
+	secret, _, err := util.NewSecretToken()
+	if err != nil {
+		return db.OauthClient{}, "", err
+	}
+	secret = clientSecretPrefix + secret
+
+	client, err := provider.store.CreateOAuthClient(ctx, db.CreateOAuthClientParams{
+		ID:           uuid.NewString(),
+		Owner:        arg.Owner,
+		Name:         arg.Name,
+		SecretHash:   util.HashSecretToken(secret),
+		RedirectUris: arg.RedirectURIs,
+		Scopes:       arg.Scopes,
+	})
+	return client, secret, err
+}
+
+// validateRedirectURI accepts absolute https URIs without fragment, plain
+// http only to the loopback address of native apps
+func validateRedirectURI(redirectURI string) error {
+	uri, err := url.Parse(redirectURI)
+	if err != nil || !uri.IsAbs() || uri.Host == "" || uri.Fragment != "" {
+		return newError(ErrCodeInvalidRequest, "redirect URI %s must be absolute and without fragment", redirectURI)
+	}
+
+	switch {
+	case uri.Scheme == "https":
+	case uri.Scheme == "http" && (uri.Hostname() == "localhost" || uri.Hostname() == "127.0.0.1"):
+	default:
+		return newError(ErrCodeInvalidRequest, "redirect URI %s must use https", redirectURI)
+	}
+	return nil
+}
+
+// AuthorizeRequest are the parameters of an authorization request
+type AuthorizeRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// Consent is what the user is asked to approve
+type Consent struct {
+	Client      db.OauthClient
+	Scopes      []string
+	RedirectURI string
+	State       string
+}
+
+// Authorize validates an authorization request and returns what the user
+// has to consent to. Errors are never sent to the redirect URI, it may not
+// belong to the client.
+func (provider *Provider) Authorize(ctx context.Context, req AuthorizeRequest) (Consent, error) {
+	client, err := provider.store.GetOAuthClient(ctx, req.ClientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Consent{}, newError(ErrCodeInvalidClient, "unknown client %s", req.ClientID)
+		}
+		return Consent{}, err
+	}
+
+	redirectURI := req.RedirectURI
+	if redirectURI == "" && len(client.RedirectUris) == 1 {
+		redirectURI = client.RedirectUris[0]
+	}
+	if !slices.Contains(client.RedirectUris, redirectURI) {
+		return Consent{}, newError(ErrCodeInvalidRequest, "redirect URI is not registered for the client")
+	}
+
+	if req.ResponseType != "code" {
+		return Consent{}, newError(ErrCodeUnsupportedResponseType, "response type must be code")
+	}
+	if req.CodeChallengeMethod != "S256" || !isValidCodeChallenge(req.CodeChallenge) {
+		return Consent{}, newError(ErrCodeInvalidRequest, "a PKCE code challenge of method S256 is required")
+	}
+
+	scopes, err := parseScope(req.Scope, client.Scopes)
+	if err != nil {
+		return Consent{}, err
+	}
+
+	return Consent{
+		Client:      client,
+		Scopes:      scopes,
+		RedirectURI: redirectURI,
+		State:       req.State,
+	}, nil
+}
+
+// Approve issues an authorization code for the consent username gave and
+// returns the redirect URI carrying it. Account access given along
+// supersedes the earlier consents of the user to the client.
+func (provider *Provider) Approve(ctx context.Context, username string, req AuthorizeRequest, access AccountAccess) (string, error) {
+	consent, err := provider.Authorize(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	if err := provider.createConsent(ctx, username, consent.Client.ID, access); err != nil {
+		return "", err
+	}
+
+	code, codeHash, err := util.NewSecretToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = provider.store.CreateOAuthAuthorizationCode(ctx, db.CreateOAuthAuthorizationCodeParams{
+		CodeHash:      codeHash,
+		ClientID:      consent.Client.ID,
+		Username:      username,
+		RedirectUri:   consent.RedirectURI,
+		Scopes:        consent.Scopes,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     time.Now().Add(codeDuration),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return redirectWith(consent.RedirectURI, url.Values{"code": {code}}, consent.State), nil
+}
+
+// Deny returns the redirect URI telling the client the user refused
+func (provider *Provider) Deny(ctx context.Context, req AuthorizeRequest) (string, error) {
+	consent, err := provider.Authorize(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	return redirectWith(consent.RedirectURI, url.Values{"error": {ErrCodeAccessDenied}}, consent.State), nil
+}
+
+func redirectWith(redirectURI string, params url.Values, state string) string {
+	// the URI was validated at registration
+	uri, _ := url.Parse(redirectURI)
+	query := uri.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	if state != "" {
+		query.Set("state", state)
+	}
+	uri.RawQuery = query.Encode()
+	return uri.String()
+}
+
+// TokenRequest are the parameters of a token request
+type TokenRequest struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	Scope        string
+}
+
+// TokenResponse is the successful token response of RFC 6749 section 5.1
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	Scope       string `json:"scope"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// Token authenticates the client and issues a token for the grant. The
+// authorization code grant acts for the user who approved it, the client
+// credentials grant for the owner of the client.
+func (provider *Provider) Token(ctx context.Context, req TokenRequest) (TokenResponse, error) {
+	switch req.GrantType {
+	case GrantTypeAuthorizationCode, GrantTypeClientCredentials:
+	default:
+		return TokenResponse{}, newError(ErrCodeUnsupportedGrantType, "grant type must be %s or %s", GrantTypeAuthorizationCode, GrantTypeClientCredentials)
+	}
+
+	client, err := provider.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	if req.GrantType == GrantTypeClientCredentials {
+		scopes, err := parseScope(req.Scope, client.Scopes)
+		if err != nil {
+			return TokenResponse{}, err
+		}
+		return provider.issue(client.Owner, client.ID, scopes)
+	}
+
+	// the code is used up whatever happens next, a code sent by the wrong
+	// client or with the wrong verifier may have been stolen
+	code, err := provider.store.UseOAuthAuthorizationCode(ctx, util.HashSecretToken(req.Code))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TokenResponse{}, newError(ErrCodeInvalidGrant, "authorization code is invalid or expired")
+		}
+		return TokenResponse{}, err
+	}
+	if code.ClientID != client.ID || code.RedirectUri != req.RedirectURI {
+		return TokenResponse{}, newError(ErrCodeInvalidGrant, "authorization code was issued to another client or redirect URI")
+	}
+	if !verifyCodeChallenge(req.CodeVerifier, code.CodeChallenge) {
+		return TokenResponse{}, newError(ErrCodeInvalidGrant, "code verifier does not match the code challenge")
+	}
+
+	return provider.issue(code.Username, client.ID, code.Scopes)
+}
+
+func (provider *Provider) authenticateClient(ctx context.Context, clientID, secret string) (db.OauthClient, error) {
+	client, err := provider.store.GetOAuthClient(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return client, newError(ErrCodeInvalidClient, "client authentication failed")
+		}
+		return client, err
+	}
+
+	secretHash := util.HashSecretToken(secret)
+	if subtle.ConstantTimeCompare([]byte(secretHash), []byte(client.SecretHash)) != 1 {
+		return client, newError(ErrCodeInvalidClient, "client authentication failed")
+	}
+	return client, nil
+}
+
+func (provider *Provider) issue(username, clientID string, scopes []string) (TokenResponse, error) {
+	// third-party tokens are only meant for the HTTP API, it enforces their
+	// consents
+	accessToken, _, err := provider.tokenMaker.CreateToken(username, provider.tokenDuration, token.Options{
+		Audience: token.AudienceHTTP,
+		ClientID: clientID,
+		Scopes:   scopes,
+	})
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	return TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		Scope:       strings.Join(scopes, " "),
+		ExpiresIn:   int64(provider.tokenDuration.Seconds()),
+	}, nil
+}
+
+// parseScope splits the space separated scope parameter, every scope has to
+// be one the client may ask for. No scope asks for all of them.
+func parseScope(scope string, allowed []string) ([]string, error) {
+	scopes := strings.Fields(scope)
+	if len(scopes) == 0 {
+		return allowed, nil
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(allowed, scope) {
+			return nil, newError(ErrCodeInvalidScope, "scope %s is not allowed for the client", scope)
+		}
+	}
+	slices.Sort(scopes)
+	return slices.Compact(scopes), nil
+}
@@ -0,0 +1,483 @@
+package oauth
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+const testVerifier = "dBjftJeZ4CVP-mJ92K1riWB8UgwDjSL1XiVgd3qoWSLJAIWGlMT8T0"
+
+func newTestProvider(t *testing.T, store db.Store) (*Provider, *token.PasetoMaker) {
+	tokenMaker, err := token.NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+	return NewProvider(store, tokenMaker, time.Minute), tokenMaker
+}
+
+func randomClient(t *testing.T) (db.OauthClient, string) {
+	secret, _, err := util.NewSecretToken()
+	require.NoError(t, err)
+	secret = clientSecretPrefix + secret
+
+	return db.OauthClient{
+		ID:           util.RandomString(16),
+		Owner:        util.RandomOwner(),
+		Name:         util.RandomOwner(),
+		SecretHash:   util.HashSecretToken(secret),
+		RedirectUris: []string{"https://app.example.com/callback"},
+		Scopes:       []string{token.ScopeAccountsRead, token.ScopeTransfersRead},
+		CreatedAt:    time.Now(),
+	}, secret
+}
+
+func requireOAuthError(t *testing.T, err error, code string) {
+	var oauthErr *Error
+	require.ErrorAs(t, err, &oauthErr)
+	require.Equal(t, code, oauthErr.Code)
+}
+
+func TestCodeChallenge(t *testing.T) {
+	verifier := testVerifier
+	require.Equal(t, "-oLZIQMnYSumertLFr5Aap8Lo059IADhEA-n2zcJaQo", CodeChallenge(verifier))
+	require.True(t, verifyCodeChallenge(verifier, CodeChallenge(verifier)))
+	require.False(t, verifyCodeChallenge(verifier, CodeChallenge(verifier+"x")))
+	require.False(t, verifyCodeChallenge("short", CodeChallenge("short")))
+}
+
+func TestRegisterClient(t *testing.T) {
+	testCases := []struct {
+		name         string
+		redirectURIs []string
+		scopes       []string
+		errCode      string
+	}{
+		{
+			name:         "OK",
+			redirectURIs: []string{"https://app.example.com/callback", "http://127.0.0.1:8000/callback"},
+			scopes:       []string{token.ScopeAccountsRead},
+		},
+		{
+			name:         "PlainHTTP",
+			redirectURIs: []string{"http://app.example.com/callback"},
+			scopes:       []string{token.ScopeAccountsRead},
+			errCode:      ErrCodeInvalidRequest,
+		},
+		{
+			name:         "Fragment",
+			redirectURIs: []string{"https://app.example.com/callback#token"},
+			scopes:       []string{token.ScopeAccountsRead},
+			errCode:      ErrCodeInvalidRequest,
+		},
+		{
+			name:         "Relative",
+			redirectURIs: []string{"/callback"},
+			scopes:       []string{token.ScopeAccountsRead},
+			errCode:      ErrCodeInvalidRequest,
+		},
+		{
+			name:         "UnknownScope",
+			redirectURIs: []string{"https://app.example.com/callback"},
+			scopes:       []string{"users:write"},
+			errCode:      ErrCodeInvalidScope,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			provider, _ := newTestProvider(t, store)
+			owner := util.RandomOwner()
+
+			if tc.errCode == "" {
+				store.EXPECT().
+					CreateOAuthClient(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateOAuthClientParams) (db.OauthClient, error) {
+						require.Equal(t, owner, arg.Owner)
+						require.Equal(t, tc.redirectURIs, arg.RedirectUris)
+						require.NotEmpty(t, arg.ID)
+						return db.OauthClient{ID: arg.ID, SecretHash: arg.SecretHash}, nil
+					})
+			} else {
+				store.EXPECT().CreateOAuthClient(gomock.Any(), gomock.Any()).Times(0)
+			}
+
+			client, secret, err := provider.RegisterClient(context.Background(), RegisterClientParams{
+				Owner:        owner,
+				Name:         "app",
+				RedirectURIs: tc.redirectURIs,
+				Scopes:       tc.scopes,
+			})
+			if tc.errCode != "" {
+				requireOAuthError(t, err, tc.errCode)
+				return
+			}
+			require.NoError(t, err)
+			require.Regexp(t, "^"+clientSecretPrefix, secret)
+			require.Equal(t, util.HashSecretToken(secret), client.SecretHash)
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	client, _ := randomClient(t)
+	challenge := CodeChallenge(testVerifier)
+
+	validRequest := func() AuthorizeRequest {
+		return AuthorizeRequest{
+			ResponseType:        "code",
+			ClientID:            client.ID,
+			RedirectURI:         client.RedirectUris[0],
+			Scope:               token.ScopeAccountsRead,
+			State:               "xyz",
+			CodeChallenge:       challenge,
+			CodeChallengeMethod: "S256",
+		}
+	}
+
+	testCases := []struct {
+		name    string
+		modify  func(req *AuthorizeRequest)
+		getErr  error
+		errCode string
+		scopes  []string
+	}{
+		{
+			name:   "OK",
+			modify: func(req *AuthorizeRequest) {},
+			scopes: []string{token.ScopeAccountsRead},
+		},
+		{
+			name: "AllScopesAndOnlyRedirectURI",
+			modify: func(req *AuthorizeRequest) {
+				req.Scope = ""
+				req.RedirectURI = ""
+			},
+			scopes: client.Scopes,
+		},
+		{
+			name:    "UnknownClient",
+			modify:  func(req *AuthorizeRequest) {},
+			getErr:  sql.ErrNoRows,
+			errCode: ErrCodeInvalidClient,
+		},
+		{
+			name: "UnregisteredRedirectURI",
+			modify: func(req *AuthorizeRequest) {
+				req.RedirectURI = "https://evil.example.com/callback"
+			},
+			errCode: ErrCodeInvalidRequest,
+		},
+		{
+			name: "Token",
+			modify: func(req *AuthorizeRequest) {
+				req.ResponseType = "token"
+			},
+			errCode: ErrCodeUnsupportedResponseType,
+		},
+		{
+			name: "PlainChallenge",
+			modify: func(req *AuthorizeRequest) {
+				req.CodeChallengeMethod = "plain"
+			},
+			errCode: ErrCodeInvalidRequest,
+		},
+		{
+			name: "ScopeNotAllowed",
+			modify: func(req *AuthorizeRequest) {
+				req.Scope = token.ScopeTransfersWrite
+			},
+			errCode: ErrCodeInvalidScope,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetOAuthClient(gomock.Any(), gomock.Eq(client.ID)).
+				Times(1).
+				Return(client, tc.getErr)
+			provider, _ := newTestProvider(t, store)
+
+			req := validRequest()
+			tc.modify(&req)
+			consent, err := provider.Authorize(context.Background(), req)
+			if tc.errCode != "" {
+				requireOAuthError(t, err, tc.errCode)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, client.ID, consent.Client.ID)
+			require.Equal(t, client.RedirectUris[0], consent.RedirectURI)
+			require.Equal(t, tc.scopes, consent.Scopes)
+			require.Equal(t, req.State, consent.State)
+		})
+	}
+}
+
+func TestApproveAndDeny(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client, _ := randomClient(t)
+	username := util.RandomOwner()
+	req := AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            client.ID,
+		State:               "xyz",
+		CodeChallenge:       CodeChallenge(testVerifier),
+		CodeChallengeMethod: "S256",
+	}
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ID)).Times(2).Return(client, nil)
+	store.EXPECT().CreateConsent(gomock.Any(), gomock.Any()).Times(0)
+
+	var codeHash string
+	store.EXPECT().
+		CreateOAuthAuthorizationCode(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateOAuthAuthorizationCodeParams) (db.OauthAuthorizationCode, error) {
+			require.Equal(t, client.ID, arg.ClientID)
+			require.Equal(t, username, arg.Username)
+			require.Equal(t, client.RedirectUris[0], arg.RedirectUri)
+			require.Equal(t, client.Scopes, arg.Scopes)
+			require.Equal(t, req.CodeChallenge, arg.CodeChallenge)
+			require.WithinDuration(t, time.Now().Add(codeDuration), arg.ExpiresAt, time.Second)
+			codeHash = arg.CodeHash
+			return db.OauthAuthorizationCode{}, nil
+		})
+	provider, _ := newTestProvider(t, store)
+
+	redirectTo, err := provider.Approve(context.Background(), username, req, AccountAccess{})
+	require.NoError(t, err)
+	uri, err := url.Parse(redirectTo)
+	require.NoError(t, err)
+	require.Equal(t, "app.example.com", uri.Host)
+	require.Equal(t, "xyz", uri.Query().Get("state"))
+	require.Equal(t, codeHash, util.HashSecretToken(uri.Query().Get("code")))
+
+	redirectTo, err = provider.Deny(context.Background(), req)
+	require.NoError(t, err)
+	uri, err = url.Parse(redirectTo)
+	require.NoError(t, err)
+	require.Equal(t, ErrCodeAccessDenied, uri.Query().Get("error"))
+	require.Equal(t, "xyz", uri.Query().Get("state"))
+	require.Empty(t, uri.Query().Get("code"))
+}
+
+func TestTokenAuthorizationCode(t *testing.T) {
+	client, secret := randomClient(t)
+	username := util.RandomOwner()
+	code := util.RandomString(32)
+
+	validRequest := func() TokenRequest {
+		return TokenRequest{
+			GrantType:    GrantTypeAuthorizationCode,
+			ClientID:     client.ID,
+			ClientSecret: secret,
+			Code:         code,
+			RedirectURI:  client.RedirectUris[0],
+			CodeVerifier: testVerifier,
+		}
+	}
+	authCode := db.OauthAuthorizationCode{
+		CodeHash:      util.HashSecretToken(code),
+		ClientID:      client.ID,
+		Username:      username,
+		RedirectUri:   client.RedirectUris[0],
+		Scopes:        []string{token.ScopeAccountsRead},
+		CodeChallenge: CodeChallenge(testVerifier),
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}
+
+	testCases := []struct {
+		name       string
+		modify     func(req *TokenRequest)
+		buildStubs func(store *mockdb.MockStore)
+		errCode    string
+	}{
+		{
+			name:   "OK",
+			modify: func(req *TokenRequest) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UseOAuthAuthorizationCode(gomock.Any(), gomock.Eq(authCode.CodeHash)).
+					Times(1).
+					Return(authCode, nil)
+			},
+		},
+		{
+			name: "WrongSecret",
+			modify: func(req *TokenRequest) {
+				req.ClientSecret = clientSecretPrefix + util.RandomString(32)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UseOAuthAuthorizationCode(gomock.Any(), gomock.Any()).Times(0)
+			},
+			errCode: ErrCodeInvalidClient,
+		},
+		{
+			name:   "UsedOrExpiredCode",
+			modify: func(req *TokenRequest) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UseOAuthAuthorizationCode(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.OauthAuthorizationCode{}, sql.ErrNoRows)
+			},
+			errCode: ErrCodeInvalidGrant,
+		},
+		{
+			name: "WrongRedirectURI",
+			modify: func(req *TokenRequest) {
+				req.RedirectURI = "https://app.example.com/other"
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UseOAuthAuthorizationCode(gomock.Any(), gomock.Any()).Times(1).Return(authCode, nil)
+			},
+			errCode: ErrCodeInvalidGrant,
+		},
+		{
+			name:   "OtherClient",
+			modify: func(req *TokenRequest) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				other := authCode
+				other.ClientID = util.RandomString(16)
+				store.EXPECT().UseOAuthAuthorizationCode(gomock.Any(), gomock.Any()).Times(1).Return(other, nil)
+			},
+			errCode: ErrCodeInvalidGrant,
+		},
+		{
+			name: "WrongVerifier",
+			modify: func(req *TokenRequest) {
+				req.CodeVerifier = util.RandomString(43)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UseOAuthAuthorizationCode(gomock.Any(), gomock.Any()).Times(1).Return(authCode, nil)
+			},
+			errCode: ErrCodeInvalidGrant,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ID)).Times(1).Return(client, nil)
+			tc.buildStubs(store)
+			provider, tokenMaker := newTestProvider(t, store)
+
+			req := validRequest()
+			tc.modify(&req)
+			res, err := provider.Token(context.Background(), req)
+			if tc.errCode != "" {
+				requireOAuthError(t, err, tc.errCode)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "Bearer", res.TokenType)
+			require.Equal(t, token.ScopeAccountsRead, res.Scope)
+			require.Equal(t, int64(60), res.ExpiresIn)
+
+			payload, err := tokenMaker.VerifyToken(res.AccessToken)
+			require.NoError(t, err)
+			require.Equal(t, username, payload.Username)
+			require.Equal(t, client.ID, payload.ClientID)
+			require.Equal(t, authCode.Scopes, payload.Scopes)
+		})
+	}
+}
+
+func TestTokenClientCredentials(t *testing.T) {
+	client, secret := randomClient(t)
+
+	testCases := []struct {
+		name    string
+		scope   string
+		errCode string
+		scopes  []string
+	}{
+		{
+			name:   "AllScopes",
+			scopes: client.Scopes,
+		},
+		{
+			name:   "SomeScopes",
+			scope:  token.ScopeTransfersRead + " " + token.ScopeTransfersRead,
+			scopes: []string{token.ScopeTransfersRead},
+		},
+		{
+			name:    "ScopeNotAllowed",
+			scope:   token.ScopeAccountsWrite,
+			errCode: ErrCodeInvalidScope,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ID)).Times(1).Return(client, nil)
+			provider, tokenMaker := newTestProvider(t, store)
+
+			res, err := provider.Token(context.Background(), TokenRequest{
+				GrantType:    GrantTypeClientCredentials,
+				ClientID:     client.ID,
+				ClientSecret: secret,
+				Scope:        tc.scope,
+			})
+			if tc.errCode != "" {
+				requireOAuthError(t, err, tc.errCode)
+				return
+			}
+			require.NoError(t, err)
+
+			payload, err := tokenMaker.VerifyToken(res.AccessToken)
+			require.NoError(t, err)
+			require.Equal(t, client.Owner, payload.Username)
+			require.Equal(t, client.ID, payload.ClientID)
+			require.Equal(t, tc.scopes, payload.Scopes)
+		})
+	}
+}
+
+func TestTokenUnsupportedGrantType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(0)
+	provider, _ := newTestProvider(t, store)
+
+	_, err := provider.Token(context.Background(), TokenRequest{GrantType: "password"})
+	requireOAuthError(t, err, ErrCodeUnsupportedGrantType)
+}
@@ -0,0 +1,333 @@
+package payment
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"simplebank/util"
+	"strconv"
+	"strings"
+)
+
+const pain001Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"
+
+// maximum size of a pain.001 file we accept
+const MaxFileSize = 1 << 20
+
+// MaxAmount is the largest amount of a single credit transfer, in minor
+// units. Larger lines are rejected, which keeps the sums of a file far from
+// overflowing.
+const MaxAmount = 1_000_000_000_00
+
+var ErrInvalidFile = errors.New("invalid pain.001 file")
+
+type pain001Document struct {
+	XMLName xml.Name                `xml:"Document"`
+	Initn   pain001CstmrCdtTrfInitn `xml:"CstmrCdtTrfInitn"`
+}
+
+type pain001CstmrCdtTrfInitn struct {
+	GrpHdr pain001GrpHdr   `xml:"GrpHdr"`
+	PmtInf []pain001PmtInf `xml:"PmtInf"`
+}
+
+type pain001GrpHdr struct {
+	MsgID   string `xml:"MsgId"`
+	CreDtTm string `xml:"CreDtTm"`
+	NbOfTxs string `xml:"NbOfTxs"`
+	CtrlSum string `xml:"CtrlSum"`
+}
+
+type pain001PmtInf struct {
+	PmtInfID    string               `xml:"PmtInfId"`
+	PmtMtd      string               `xml:"PmtMtd"`
+	NbOfTxs     string               `xml:"NbOfTxs"`
+	CtrlSum     string               `xml:"CtrlSum"`
+	DbtrAcct    pain001Account       `xml:"DbtrAcct"`
+	CdtTrfTxInf []pain001CdtTrfTxInf `xml:"CdtTrfTxInf"`
+}
+
+type pain001Account struct {
+	ID  string `xml:"Id>Othr>Id"`
+	Ccy string `xml:"Ccy"`
+}
+
+type pain001CdtTrfTxInf struct {
+	InstrID    string         `xml:"PmtId>InstrId"`
+	EndToEndID string         `xml:"PmtId>EndToEndId"`
+	InstdAmt   pain001Amount  `xml:"Amt>InstdAmt"`
+	CdtrAcct   pain001Account `xml:"CdtrAcct"`
+	Ustrd      string         `xml:"RmtInf>Ustrd"`
+}
+
+type pain001Amount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Initiation is a parsed pain.001 customer credit transfer initiation
+type Initiation struct {
+	MessageID    string
+	NumberOfTxs  int
+	ControlSum   string
+	PaymentInfos []PaymentInfo
+}
+
+// TransferCount counts the credit transfers that are not rejected by the file
+// itself, those a batch tries to execute
+func (initiation Initiation) TransferCount() int {
+	var count int
+	for _, info := range initiation.PaymentInfos {
+		for _, transfer := range info.Transfers {
+			if transfer.Err == nil {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// TotalAmounts sums the credit transfers that are not rejected by the file
+// itself per currency, in minor units. Lines that fail later, e.g. for an
+// unknown creditor, still count. A total that would overflow stays at
+// math.MaxInt64.
+func (initiation Initiation) TotalAmounts() map[string]int64 {
+	totals := make(map[string]int64)
+	for _, info := range initiation.PaymentInfos {
+		for _, transfer := range info.Transfers {
+			if transfer.Err != nil {
+				continue
+			}
+			total, ok := addAmount(totals[transfer.Currency], transfer.Amount)
+			if !ok {
+				total = math.MaxInt64
+			}
+			totals[transfer.Currency] = total
+		}
+	}
+	return totals
+}
+
+// PaymentInfo is one debtor account and the credit transfers it pays for
+type PaymentInfo struct {
+	ID              string
+	DebtorAccountID int64
+	Transfers       []CreditTransfer
+}
+
+// CreditTransfer is one line of a payment information block.
+// Err is set when the line itself is malformed, such lines are rejected
+// in the status report while the rest of the file is still processed.
+type CreditTransfer struct {
+	InstructionID     string
+	EndToEndID        string
+	CreditorAccountID int64
+	Amount            int64
+	Currency          string
+	RemittanceInfo    string
+	Err               *StatusReason
+}
+
+// ParsePain001 reads and validates a pain.001.001.03 document.
+// Errors in the group header or payment information blocks invalidate the whole file
+// and are reported with ErrInvalidFile, errors in single credit transfers are kept on the line.
+func ParsePain001(r io.Reader) (Initiation, error) {
+	var doc pain001Document
+	dec := xml.NewDecoder(io.LimitReader(r, MaxFileSize))
+	if err := dec.Decode(&doc); err != nil {
+		return Initiation{}, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	if doc.XMLName.Space != pain001Namespace {
+		return Initiation{}, fmt.Errorf("%w: unsupported namespace %q, expected %q", ErrInvalidFile, doc.XMLName.Space, pain001Namespace)
+	}
+
+	hdr := doc.Initn.GrpHdr
+	if err := checkText("GrpHdr/MsgId", hdr.MsgID, 35); err != nil {
+		return Initiation{}, err
+	}
+
+	numberOfTxs, err := strconv.Atoi(hdr.NbOfTxs)
+	if err != nil || numberOfTxs < 1 {
+		return Initiation{}, fmt.Errorf("%w: GrpHdr/NbOfTxs must be a positive number", ErrInvalidFile)
+	}
+
+	if len(doc.Initn.PmtInf) == 0 {
+		return Initiation{}, fmt.Errorf("%w: no PmtInf block", ErrInvalidFile)
+	}
+
+	init := Initiation{
+		MessageID:   hdr.MsgID,
+		NumberOfTxs: numberOfTxs,
+		ControlSum:  hdr.CtrlSum,
+	}
+
+	var count int
+	var sum int64
+	for i, pmtInf := range doc.Initn.PmtInf {
+		info, err := parsePaymentInfo(i, pmtInf)
+		if err != nil {
+			return Initiation{}, err
+		}
+
+		for _, transfer := range info.Transfers {
+			if sum, err = sumAmount("GrpHdr", sum, transfer.Amount); err != nil {
+				return Initiation{}, err
+			}
+		}
+		count += len(info.Transfers)
+		init.PaymentInfos = append(init.PaymentInfos, info)
+	}
+
+	if count != numberOfTxs {
+		return Initiation{}, fmt.Errorf("%w: GrpHdr/NbOfTxs is %d but the file holds %d transactions", ErrInvalidFile, numberOfTxs, count)
+	}
+
+	if err := checkControlSum("GrpHdr/CtrlSum", hdr.CtrlSum, sum); err != nil {
+		return Initiation{}, err
+	}
+
+	return init, nil
+}
+
+func parsePaymentInfo(i int, pmtInf pain001PmtInf) (PaymentInfo, error) {
+	path := fmt.Sprintf("PmtInf[%d]", i)
+	if err := checkText(path+"/PmtInfId", pmtInf.PmtInfID, 35); err != nil {
+		return PaymentInfo{}, err
+	}
+
+	if pmtInf.PmtMtd != "TRF" {
+		return PaymentInfo{}, fmt.Errorf("%w: %s/PmtMtd must be TRF", ErrInvalidFile, path)
+	}
+
+	debtorAccountID, err := parseAccountID(pmtInf.DbtrAcct.ID)
+	if err != nil {
+		return PaymentInfo{}, fmt.Errorf("%w: %s/DbtrAcct: %v", ErrInvalidFile, path, err)
+	}
+
+	if len(pmtInf.CdtTrfTxInf) == 0 {
+		return PaymentInfo{}, fmt.Errorf("%w: %s has no CdtTrfTxInf", ErrInvalidFile, path)
+	}
+
+	if pmtInf.NbOfTxs != "" && pmtInf.NbOfTxs != strconv.Itoa(len(pmtInf.CdtTrfTxInf)) {
+		return PaymentInfo{}, fmt.Errorf("%w: %s/NbOfTxs does not match the number of transactions", ErrInvalidFile, path)
+	}
+
+	info := PaymentInfo{
+		ID:              pmtInf.PmtInfID,
+		DebtorAccountID: debtorAccountID,
+		Transfers:       make([]CreditTransfer, 0, len(pmtInf.CdtTrfTxInf)),
+	}
+
+	var sum int64
+	for _, tx := range pmtInf.CdtTrfTxInf {
+		transfer := parseCreditTransfer(tx)
+		if sum, err = sumAmount(path, sum, transfer.Amount); err != nil {
+			return PaymentInfo{}, err
+		}
+		info.Transfers = append(info.Transfers, transfer)
+	}
+
+	if err := checkControlSum(path+"/CtrlSum", pmtInf.CtrlSum, sum); err != nil {
+		return PaymentInfo{}, err
+	}
+
+	return info, nil
+}
+
+func parseCreditTransfer(tx pain001CdtTrfTxInf) CreditTransfer {
+	transfer := CreditTransfer{
+		InstructionID:  tx.InstrID,
+		EndToEndID:     tx.EndToEndID,
+		Currency:       tx.InstdAmt.Ccy,
+		RemittanceInfo: tx.Ustrd,
+	}
+
+	if tx.EndToEndID == "" || len(tx.EndToEndID) > 35 {
+		transfer.Err = &StatusReason{Code: ReasonInvalidFormat, Info: "EndToEndId must be 1 to 35 characters"}
+		return transfer
+	}
+
+	amount, err := util.ParseAmount(strings.TrimSpace(tx.InstdAmt.Value))
+	if err != nil {
+		transfer.Err = &StatusReason{Code: ReasonInvalidAmount, Info: err.Error()}
+		return transfer
+	}
+	transfer.Amount = amount
+
+	if amount <= 0 {
+		transfer.Err = &StatusReason{Code: ReasonInvalidAmount, Info: "amount must be positive"}
+		return transfer
+	}
+
+	if amount > MaxAmount {
+		transfer.Err = &StatusReason{Code: ReasonInvalidAmount, Info: fmt.Sprintf("amount must not exceed %s", util.FormatAmount(MaxAmount))}
+		return transfer
+	}
+
+	if !util.IsSupportedCurrency(tx.InstdAmt.Ccy) {
+		transfer.Err = &StatusReason{Code: ReasonNotAllowedCurrency, Info: fmt.Sprintf("currency %q is not supported", tx.InstdAmt.Ccy)}
+		return transfer
+	}
+
+	transfer.CreditorAccountID, err = parseAccountID(tx.CdtrAcct.ID)
+	if err != nil {
+		transfer.Err = &StatusReason{Code: ReasonIncorrectAccount, Info: err.Error()}
+	}
+
+	return transfer
+}
+
+// parseAccountID reads our internal account id from the Othr/Id of an account identification
+func parseAccountID(s string) (int64, error) {
+	if strings.TrimSpace(s) == "" {
+		return 0, errors.New("account must be identified by Othr/Id")
+	}
+
+	id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid account id %q", s)
+	}
+	return id, nil
+}
+
+func checkText(path, s string, maxLength int) error {
+	if s == "" || len(s) > maxLength {
+		return fmt.Errorf("%w: %s must be 1 to %d characters", ErrInvalidFile, path, maxLength)
+	}
+	return nil
+}
+
+// addAmount adds two amounts of minor units, ok is false when the sum
+// overflows
+func addAmount(sum, amount int64) (int64, bool) {
+	if amount > math.MaxInt64-sum {
+		return sum, false
+	}
+	return sum + amount, true
+}
+
+// sumAmount adds amount to the sum of the transactions of path, a sum that
+// overflows rejects the whole file
+func sumAmount(path string, sum, amount int64) (int64, error) {
+	sum, ok := addAmount(sum, amount)
+	if !ok {
+		reason := &StatusReason{Code: ReasonInvalidAmount, Info: "the sum of the transactions is too large"}
+		return 0, fmt.Errorf("%w: %s: %v", ErrInvalidFile, path, reason)
+	}
+	return sum, nil
+}
+
+func checkControlSum(path, controlSum string, sum int64) error {
+	if controlSum == "" {
+		return nil
+	}
+
+	want, err := util.ParseAmount(strings.TrimSpace(controlSum))
+	if err != nil || want != sum {
+		return fmt.Errorf("%w: %s %q does not match the sum of the transactions %s", ErrInvalidFile, path, controlSum, util.FormatAmount(sum))
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package payment
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePain001(t *testing.T) {
+	file, err := os.Open("testdata/pain001.xml")
+	require.NoError(t, err)
+	defer file.Close()
+
+	init, err := ParsePain001(file)
+	require.NoError(t, err)
+
+	require.Equal(t, "PAYROLL-2026-09", init.MessageID)
+	require.Equal(t, 4, init.NumberOfTxs)
+	require.Len(t, init.PaymentInfos, 2)
+
+	usd := init.PaymentInfos[0]
+	require.Equal(t, "PAYROLL-USD", usd.ID)
+	require.Equal(t, int64(1), usd.DebtorAccountID)
+	require.Len(t, usd.Transfers, 3)
+
+	require.Equal(t, CreditTransfer{
+		InstructionID:     "INSTR-1",
+		EndToEndID:        "E2E-1",
+		CreditorAccountID: 2,
+		Amount:            100050,
+		Currency:          "USD",
+		RemittanceInfo:    "Salary September",
+	}, usd.Transfers[0])
+
+	require.NotNil(t, usd.Transfers[1].Err)
+	require.Equal(t, ReasonNotAllowedCurrency, usd.Transfers[1].Err.Code)
+
+	require.NotNil(t, usd.Transfers[2].Err)
+	require.Equal(t, ReasonIncorrectAccount, usd.Transfers[2].Err.Code)
+
+	eur := init.PaymentInfos[1]
+	require.Equal(t, int64(4), eur.DebtorAccountID)
+	require.Len(t, eur.Transfers, 1)
+	require.Nil(t, eur.Transfers[0].Err)
+	require.Equal(t, int64(2000), eur.Transfers[0].Amount)
+
+	// the rejected lines don't count, each currency is summed on its own
+	require.Equal(t, map[string]int64{"USD": 100050, "EUR": 2000}, init.TotalAmounts())
+	require.Equal(t, 2, init.TransferCount())
+}
+
+func TestParsePain001Overflow(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/pain001.xml")
+	require.NoError(t, err)
+
+	// two lines of the largest amount wrap the sum around if it isn't checked
+	data := string(fixture)
+	data = strings.Replace(data, `<InstdAmt Ccy="JPY">200</InstdAmt>`, `<InstdAmt Ccy="USD">92233720368547758.07</InstdAmt>`, 1)
+	data = strings.Replace(data, `<InstdAmt Ccy="USD">50</InstdAmt>`, `<InstdAmt Ccy="USD">92233720368547758.07</InstdAmt>`, 1)
+
+	_, err = ParsePain001(strings.NewReader(data))
+	require.ErrorIs(t, err, ErrInvalidFile)
+	require.ErrorContains(t, err, ReasonInvalidAmount)
+	require.ErrorContains(t, err, "PmtInf[0]")
+}
+
+func TestTotalAmountsOverflow(t *testing.T) {
+	transfer := CreditTransfer{Amount: math.MaxInt64 - 1, Currency: "USD"}
+	init := Initiation{PaymentInfos: []PaymentInfo{
+		{Transfers: []CreditTransfer{transfer, transfer}},
+	}}
+
+	require.Equal(t, map[string]int64{"USD": math.MaxInt64}, init.TotalAmounts())
+}
+
+func TestParsePain001InvalidFile(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/pain001.xml")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		old     string
+		new     string
+		message string
+	}{
+		{
+			name:    "NotXML",
+			old:     "<Document",
+			new:     "<<Document",
+			message: "invalid pain.001 file",
+		},
+		{
+			name:    "WrongNamespace",
+			old:     "pain.001.001.03",
+			new:     "pain.001.001.09",
+			message: "unsupported namespace",
+		},
+		{
+			name:    "MissingMsgId",
+			old:     "<MsgId>PAYROLL-2026-09</MsgId>",
+			new:     "",
+			message: "GrpHdr/MsgId",
+		},
+		{
+			name:    "NumberOfTxsMismatch",
+			old:     "<NbOfTxs>4</NbOfTxs>",
+			new:     "<NbOfTxs>5</NbOfTxs>",
+			message: "GrpHdr/NbOfTxs is 5",
+		},
+		{
+			name:    "ControlSumMismatch",
+			old:     "<CtrlSum>1270.50</CtrlSum>",
+			new:     "<CtrlSum>1270.00</CtrlSum>",
+			message: "GrpHdr/CtrlSum",
+		},
+		{
+			name:    "PaymentInfoControlSumMismatch",
+			old:     "<CtrlSum>1250.50</CtrlSum>",
+			new:     "<CtrlSum>1.00</CtrlSum>",
+			message: "PmtInf[0]/CtrlSum",
+		},
+		{
+			name:    "UnsupportedPaymentMethod",
+			old:     "<PmtMtd>TRF</PmtMtd>",
+			new:     "<PmtMtd>CHK</PmtMtd>",
+			message: "PmtInf[0]/PmtMtd",
+		},
+		{
+			name:    "InvalidDebtorAccount",
+			old:     "<Id>1</Id>",
+			new:     "<Id>ACME-1</Id>",
+			message: "PmtInf[0]/DbtrAcct",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			data := strings.Replace(string(fixture), tc.old, tc.new, 1)
+			require.NotEqual(t, string(fixture), data)
+
+			_, err := ParsePain001(strings.NewReader(data))
+			require.ErrorIs(t, err, ErrInvalidFile)
+			require.ErrorContains(t, err, tc.message)
+		})
+	}
+}
+
+func TestParseCreditTransfer(t *testing.T) {
+	tx := pain001CdtTrfTxInf{
+		EndToEndID: "E2E",
+		InstdAmt:   pain001Amount{Ccy: "TWD", Value: "10"},
+		CdtrAcct:   pain001Account{ID: "7"},
+	}
+
+	transfer := parseCreditTransfer(tx)
+	require.Nil(t, transfer.Err)
+	require.Equal(t, int64(1000), transfer.Amount)
+
+	tx.InstdAmt.Value = "0.00"
+	require.Equal(t, ReasonInvalidAmount, parseCreditTransfer(tx).Err.Code)
+
+	tx.InstdAmt.Value = "1.234"
+	require.Equal(t, ReasonInvalidAmount, parseCreditTransfer(tx).Err.Code)
+
+	tx.InstdAmt.Value = "1000000000.01"
+	require.Equal(t, ReasonInvalidAmount, parseCreditTransfer(tx).Err.Code)
+
+	tx.InstdAmt.Value = "10"
+	tx.EndToEndID = ""
+	require.Equal(t, ReasonInvalidFormat, parseCreditTransfer(tx).Err.Code)
+}
@@ -0,0 +1,195 @@
+package payment
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+const pain002Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.002.001.03"
+
+// ISO 20022 external status reason codes used in our status reports
+const (
+	ReasonIncorrectAccount   = "AC01"
+	ReasonNotAllowedCurrency = "AM03"
+	ReasonInvalidAmount      = "AM12"
+	ReasonForbidden          = "AG01"
+	ReasonInvalidFormat      = "FF01"
+	ReasonDuplicateMessage   = "DU01"
+	ReasonNotSpecified       = "MS03"
+)
+
+// transaction and group status codes
+const (
+	StatusSettled  = "ACSC"
+	StatusPartial  = "PART"
+	StatusRejected = "RJCT"
+)
+
+// StatusReason explains why a transaction or file was rejected
+type StatusReason struct {
+	Code string
+	Info string
+}
+
+func (reason *StatusReason) Error() string {
+	return fmt.Sprintf("%s: %s", reason.Code, reason.Info)
+}
+
+// TransferStatus is the outcome of one credit transfer of an initiation
+type TransferStatus struct {
+	PaymentInfoID string
+	Transfer      CreditTransfer
+	TransferID    int64
+	Reason        *StatusReason
+}
+
+// Report is a pain.002 customer payment status report for one initiation
+type Report struct {
+	MessageID string
+	CreatedAt time.Time
+	Original  Initiation
+	Statuses  []TransferStatus
+	// Reason rejects the whole initiation, e.g. for duplicate files
+	Reason *StatusReason
+}
+
+// GroupStatus summarises the transaction statuses of the report
+func (report Report) GroupStatus() string {
+	if report.Reason != nil {
+		return StatusRejected
+	}
+
+	accepted := 0
+	for _, status := range report.Statuses {
+		if status.Reason == nil {
+			accepted++
+		}
+	}
+
+	switch accepted {
+	case len(report.Statuses):
+		return StatusSettled
+	case 0:
+		return StatusRejected
+	default:
+		return StatusPartial
+	}
+}
+
+type pain002Document struct {
+	XMLName xml.Name              `xml:"Document"`
+	Xmlns   string                `xml:"xmlns,attr"`
+	Rpt     pain002CstmrPmtStsRpt `xml:"CstmrPmtStsRpt"`
+}
+
+type pain002CstmrPmtStsRpt struct {
+	GrpHdr            pain002GrpHdr            `xml:"GrpHdr"`
+	OrgnlGrpInfAndSts pain002OrgnlGrpInfAndSts `xml:"OrgnlGrpInfAndSts"`
+	OrgnlPmtInfAndSts []pain002OrgnlPmtInf     `xml:"OrgnlPmtInfAndSts"`
+}
+
+type pain002GrpHdr struct {
+	MsgID   string `xml:"MsgId"`
+	CreDtTm string `xml:"CreDtTm"`
+}
+
+type pain002OrgnlGrpInfAndSts struct {
+	OrgnlMsgID   string            `xml:"OrgnlMsgId"`
+	OrgnlMsgNmID string            `xml:"OrgnlMsgNmId"`
+	OrgnlNbOfTxs string            `xml:"OrgnlNbOfTxs,omitempty"`
+	OrgnlCtrlSum string            `xml:"OrgnlCtrlSum,omitempty"`
+	GrpSts       string            `xml:"GrpSts"`
+	StsRsnInf    *pain002StsRsnInf `xml:"StsRsnInf,omitempty"`
+}
+
+type pain002OrgnlPmtInf struct {
+	OrgnlPmtInfID string               `xml:"OrgnlPmtInfId"`
+	TxInfAndSts   []pain002TxInfAndSts `xml:"TxInfAndSts"`
+}
+
+type pain002TxInfAndSts struct {
+	StsID           string            `xml:"StsId,omitempty"`
+	OrgnlInstrID    string            `xml:"OrgnlInstrId,omitempty"`
+	OrgnlEndToEndID string            `xml:"OrgnlEndToEndId,omitempty"`
+	TxSts           string            `xml:"TxSts"`
+	StsRsnInf       *pain002StsRsnInf `xml:"StsRsnInf,omitempty"`
+}
+
+type pain002StsRsnInf struct {
+	Cd       string `xml:"Rsn>Cd"`
+	AddtlInf string `xml:"AddtlInf,omitempty"`
+}
+
+// WritePain002 renders the report as a pain.002.001.03 document
+func WritePain002(w io.Writer, report Report) error {
+	doc := pain002Document{
+		Xmlns: pain002Namespace,
+		Rpt: pain002CstmrPmtStsRpt{
+			GrpHdr: pain002GrpHdr{
+				MsgID:   report.MessageID,
+				CreDtTm: report.CreatedAt.UTC().Format(time.RFC3339),
+			},
+			OrgnlGrpInfAndSts: pain002OrgnlGrpInfAndSts{
+				OrgnlMsgID:   report.Original.MessageID,
+				OrgnlMsgNmID: "pain.001.001.03",
+				OrgnlCtrlSum: report.Original.ControlSum,
+				GrpSts:       report.GroupStatus(),
+				StsRsnInf:    newStsRsnInf(report.Reason),
+			},
+		},
+	}
+	if report.Original.NumberOfTxs > 0 {
+		doc.Rpt.OrgnlGrpInfAndSts.OrgnlNbOfTxs = fmt.Sprint(report.Original.NumberOfTxs)
+	}
+
+	for _, status := range report.Statuses {
+		n := len(doc.Rpt.OrgnlPmtInfAndSts)
+		if n == 0 || doc.Rpt.OrgnlPmtInfAndSts[n-1].OrgnlPmtInfID != status.PaymentInfoID {
+			doc.Rpt.OrgnlPmtInfAndSts = append(doc.Rpt.OrgnlPmtInfAndSts, pain002OrgnlPmtInf{OrgnlPmtInfID: status.PaymentInfoID})
+			n++
+		}
+
+		txSts := pain002TxInfAndSts{
+			OrgnlInstrID:    status.Transfer.InstructionID,
+			OrgnlEndToEndID: status.Transfer.EndToEndID,
+			TxSts:           StatusSettled,
+			StsRsnInf:       newStsRsnInf(status.Reason),
+		}
+		if status.Reason != nil {
+			txSts.TxSts = StatusRejected
+		} else {
+			txSts.StsID = fmt.Sprint(status.TransferID)
+		}
+
+		pmtInf := &doc.Rpt.OrgnlPmtInfAndSts[n-1]
+		pmtInf.TxInfAndSts = append(pmtInf.TxInfAndSts, txSts)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func newStsRsnInf(reason *StatusReason) *pain002StsRsnInf {
+	if reason == nil {
+		return nil
+	}
+	return &pain002StsRsnInf{Cd: reason.Code, AddtlInf: truncate(reason.Info, 105)}
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,89 @@
+package payment
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testReport(t *testing.T) Report {
+	file, err := os.Open("testdata/pain001.xml")
+	require.NoError(t, err)
+	defer file.Close()
+
+	init, err := ParsePain001(file)
+	require.NoError(t, err)
+
+	report := Report{
+		MessageID: "STS-1",
+		CreatedAt: time.Date(2026, 9, 30, 8, 5, 0, 0, time.UTC),
+		Original:  init,
+	}
+	transferID := int64(100)
+	for _, info := range init.PaymentInfos {
+		for _, transfer := range info.Transfers {
+			status := TransferStatus{PaymentInfoID: info.ID, Transfer: transfer, Reason: transfer.Err}
+			if status.Reason == nil {
+				status.TransferID = transferID
+				transferID++
+			}
+			report.Statuses = append(report.Statuses, status)
+		}
+	}
+	return report
+}
+
+func TestWritePain002(t *testing.T) {
+	report := testReport(t)
+	require.Equal(t, StatusPartial, report.GroupStatus())
+
+	var buf bytes.Buffer
+	err := WritePain002(&buf, report)
+	require.NoError(t, err)
+
+	var doc pain002Document
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	require.Equal(t, pain002Namespace, doc.XMLName.Space)
+	require.Len(t, doc.Rpt.OrgnlPmtInfAndSts, 2)
+	require.Len(t, doc.Rpt.OrgnlPmtInfAndSts[0].TxInfAndSts, 3)
+	require.Len(t, doc.Rpt.OrgnlPmtInfAndSts[1].TxInfAndSts, 1)
+
+	fixture, err := os.ReadFile("testdata/pain002.xml")
+	require.NoError(t, err)
+	require.Equal(t, string(fixture), buf.String())
+}
+
+func TestWritePain002Duplicate(t *testing.T) {
+	report := Report{
+		MessageID: "STS-DUP-1",
+		CreatedAt: time.Now(),
+		Original:  Initiation{MessageID: "PAYROLL-2026-09"},
+		Reason:    &StatusReason{Code: ReasonDuplicateMessage, Info: "message PAYROLL-2026-09 was already submitted"},
+	}
+	require.Equal(t, StatusRejected, report.GroupStatus())
+
+	var buf bytes.Buffer
+	err := WritePain002(&buf, report)
+	require.NoError(t, err)
+
+	var doc pain002Document
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	grp := doc.Rpt.OrgnlGrpInfAndSts
+	require.Equal(t, StatusRejected, grp.GrpSts)
+	require.NotNil(t, grp.StsRsnInf)
+	require.Equal(t, ReasonDuplicateMessage, grp.StsRsnInf.Cd)
+	require.Empty(t, doc.Rpt.OrgnlPmtInfAndSts)
+}
+
+func TestGroupStatus(t *testing.T) {
+	settled := TransferStatus{TransferID: 1}
+	rejected := TransferStatus{Reason: &StatusReason{Code: ReasonIncorrectAccount}}
+
+	require.Equal(t, StatusSettled, Report{Statuses: []TransferStatus{settled, settled}}.GroupStatus())
+	require.Equal(t, StatusPartial, Report{Statuses: []TransferStatus{settled, rejected}}.GroupStatus())
+	require.Equal(t, StatusRejected, Report{Statuses: []TransferStatus{rejected}}.GroupStatus())
+}
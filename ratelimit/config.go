@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"fmt"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+)
+
+// Limits of each group of requests, the HTTP API and gRPC apply the same ones.
+// A zero limit lets everything through.
+type Limits struct {
+	// Public limits the requests without a user by caller address
+	Public Limit
+	// APIByIP limits the requests to the authenticated API by caller address,
+	// before the token is checked, so requests with invalid tokens count too
+	APIByIP Limit
+	// API limits the requests of a user
+	API Limit
+	// Transfers limits the requests of a user moving money, on top of API
+	Transfers Limit
+}
+
+// New creates the limiter of the configured backend and parses the limits of
+// config
+func New(config util.Config, store db.Store) (Limiter, Limits, error) {
+	var (
+		limits Limits
+		err    error
+	)
+	if limits.Public, err = ParseLimit(config.RateLimitPublic); err != nil {
+		return nil, limits, err
+	}
+	if limits.APIByIP, err = ParseLimit(config.RateLimitAPIByIP); err != nil {
+		return nil, limits, err
+	}
+	if limits.API, err = ParseLimit(config.RateLimitAPI); err != nil {
+		return nil, limits, err
+	}
+	if limits.Transfers, err = ParseLimit(config.RateLimitTransfers); err != nil {
+		return nil, limits, err
+	}
+
+	switch config.RateLimitBackend {
+	case "", "memory":
+		return NewMemoryLimiter(), limits, nil
+	case "postgres":
+		return NewPostgresLimiter(store), limits, nil
+	default:
+		return nil, limits, fmt.Errorf("unknown rate limit backend %q", config.RateLimitBackend)
+	}
+}
@@ -0,0 +1,36 @@
+package ratelimit
+
+import (
+	"simplebank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config util.Config
+		ok     bool
+	}{
+		{name: "Memory", config: util.Config{RateLimitAPI: "100/1m"}, ok: true},
+		{name: "InvalidLimit", config: util.Config{RateLimitAPI: "100"}},
+		{name: "UnknownBackend", config: util.Config{RateLimitBackend: "redis"}},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			limiter, limits, err := New(tc.config, nil)
+			if !tc.ok {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.IsType(t, &MemoryLimiter{}, limiter)
+			require.Equal(t, Limit{Rate: 100.0 / 60, Burst: 100}, limits.API)
+			require.False(t, limits.Public.Enabled())
+		})
+	}
+}
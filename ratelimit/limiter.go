@@ -0,0 +1,76 @@
+// Package ratelimit implements token bucket rate limiting with an in-memory
+// backend for single instances and a Postgres backend shared by all instances
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Burst requests at once, refilled at Rate requests per second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// ParseLimit reads a limit formatted as "<requests>/<period>", e.g. "5/1m" for
+// 5 requests a minute. The burst is the number of requests of one period.
+// An empty string is the zero Limit, which disables limiting.
+func ParseLimit(s string) (Limit, error) {
+	if strings.TrimSpace(s) == "" {
+		return Limit{}, nil
+	}
+
+	count, period, found := strings.Cut(strings.TrimSpace(s), "/")
+	if !found {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: must be formatted as <requests>/<period>", s)
+	}
+
+	requests, err := strconv.Atoi(count)
+	if err != nil || requests < 1 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: requests must be a positive number", s)
+	}
+
+	duration, err := time.ParseDuration(period)
+	if err != nil || duration <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: period must be a positive duration", s)
+	}
+
+	return Limit{Rate: float64(requests) / duration.Seconds(), Burst: requests}, nil
+}
+
+// Enabled tells whether the limit restricts anything
+func (limit Limit) Enabled() bool {
+	return limit.Rate > 0 && limit.Burst > 0
+}
+
+// Result is the outcome of one request against a limit
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long until the next request would be allowed, zero when allowed
+	RetryAfter time.Duration
+}
+
+// newResult builds the result from the tokens left in the bucket. When the
+// request for n tokens was denied, tokens are those that prevented it.
+func newResult(limit Limit, allowed bool, tokens float64, n int) Result {
+	result := Result{Allowed: allowed, Remaining: max(int(math.Floor(tokens)), 0)}
+	if !allowed {
+		result.RetryAfter = time.Duration((float64(n) - tokens) / limit.Rate * float64(time.Second))
+	}
+	return result
+}
+
+// Limiter takes tokens from the bucket of key, creating it full if needed
+type Limiter interface {
+	// Allow takes one token
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+	// AllowN takes n tokens at once, or none when fewer are left. More than
+	// the burst of limit are never allowed.
+	AllowN(ctx context.Context, key string, limit Limit, n int) (Result, error)
+}
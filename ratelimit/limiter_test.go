@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	testCases := []struct {
+		input string
+		limit Limit
+		err   string
+	}{
+		{input: "5/1m", limit: Limit{Rate: 5.0 / 60, Burst: 5}},
+		{input: "10/1s", limit: Limit{Rate: 10, Burst: 10}},
+		{input: " 120/1h ", limit: Limit{Rate: 120.0 / 3600, Burst: 120}},
+		{input: "", limit: Limit{}},
+		{input: "5", err: `invalid rate limit "5": must be formatted as <requests>/<period>`},
+		{input: "0/1m", err: `invalid rate limit "0/1m": requests must be a positive number`},
+		{input: "five/1m", err: `invalid rate limit "five/1m": requests must be a positive number`},
+		{input: "5/minute", err: `invalid rate limit "5/minute": period must be a positive duration`},
+		{input: "5/-1m", err: `invalid rate limit "5/-1m": period must be a positive duration`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			limit, err := ParseLimit(tc.input)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.InDelta(t, tc.limit.Rate, limit.Rate, 1e-12)
+			require.Equal(t, tc.limit.Burst, limit.Burst)
+			require.Equal(t, tc.input != "", limit.Enabled())
+		})
+	}
+}
+
+func TestNewResult(t *testing.T) {
+	limit := Limit{Rate: 0.5, Burst: 3}
+
+	result := newResult(limit, true, 1.7, 1)
+	require.Equal(t, Result{Allowed: true, Remaining: 1}, result)
+
+	result = newResult(limit, false, 0.25, 1)
+	require.False(t, result.Allowed)
+	require.Zero(t, result.Remaining)
+	require.Equal(t, 1500*time.Millisecond, result.RetryAfter)
+
+	// waiting for several tokens takes longer
+	result = newResult(limit, false, 1.25, 2)
+	require.False(t, result.Allowed)
+	require.Equal(t, 1, result.Remaining)
+	require.Equal(t, 1500*time.Millisecond, result.RetryAfter)
+}
@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// buckets idle for this long are full again and can be dropped
+const idleBucketTTL = time.Hour
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// MemoryLimiter keeps the buckets in process memory, every instance limits on its own
+type MemoryLimiter struct {
+	mu          sync.Mutex
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+	now         func() time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (limiter *MemoryLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	return limiter.AllowN(ctx, key, limit, 1)
+}
+
+func (limiter *MemoryLimiter) AllowN(ctx context.Context, key string, limit Limit, n int) (Result, error) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := limiter.now()
+	limiter.cleanup(now)
+
+	b, ok := limiter.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updatedAt: now}
+		limiter.buckets[key] = b
+	}
+
+	b.tokens = min(float64(limit.Burst), b.tokens+now.Sub(b.updatedAt).Seconds()*limit.Rate)
+	b.updatedAt = now
+
+	if b.tokens < float64(n) {
+		return newResult(limit, false, b.tokens, n), nil
+	}
+
+	b.tokens -= float64(n)
+	return newResult(limit, true, b.tokens, n), nil
+}
+
+// cleanup drops idle buckets now and then, so keys like client IPs don't pile up
+func (limiter *MemoryLimiter) cleanup(now time.Time) {
+	if now.Sub(limiter.lastCleanup) < idleBucketTTL {
+		return
+	}
+
+	for key, b := range limiter.buckets {
+		if now.Sub(b.updatedAt) >= idleBucketTTL {
+			delete(limiter.buckets, key)
+		}
+	}
+	limiter.lastCleanup = now
+}
@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Burst: 3}
+
+	for i := 2; i >= 0; i-- {
+		result, err := limiter.Allow(ctx, "user:alice", limit)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, i, result.Remaining)
+	}
+
+	result, err := limiter.Allow(ctx, "user:alice", limit)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, time.Second, result.RetryAfter)
+
+	// other keys have their own bucket
+	result, err = limiter.Allow(ctx, "user:bob", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	now = now.Add(1500 * time.Millisecond)
+	result, err = limiter.Allow(ctx, "user:alice", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Zero(t, result.Remaining)
+
+	result, err = limiter.Allow(ctx, "user:alice", limit)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, 500*time.Millisecond, result.RetryAfter)
+
+	// a long pause refills the bucket up to the burst only
+	now = now.Add(time.Hour)
+	result, err = limiter.Allow(ctx, "user:alice", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 2, result.Remaining)
+}
+
+func TestMemoryLimiterAllowN(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Burst: 5}
+
+	result, err := limiter.AllowN(ctx, "user:alice", limit, 3)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 2, result.Remaining)
+
+	// fewer tokens than asked for are left, none are taken
+	result, err = limiter.AllowN(ctx, "user:alice", limit, 3)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, 2, result.Remaining)
+	require.Equal(t, time.Second, result.RetryAfter)
+
+	result, err = limiter.AllowN(ctx, "user:alice", limit, 2)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Zero(t, result.Remaining)
+}
+
+func TestMemoryLimiterCleanup(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Burst: 3}
+
+	_, err := limiter.Allow(ctx, "ip:10.0.0.1", limit)
+	require.NoError(t, err)
+
+	now = now.Add(30 * time.Minute)
+	_, err = limiter.Allow(ctx, "ip:10.0.0.2", limit)
+	require.NoError(t, err)
+	require.Len(t, limiter.buckets, 2)
+
+	now = now.Add(45 * time.Minute)
+	_, err = limiter.Allow(ctx, "ip:10.0.0.3", limit)
+	require.NoError(t, err)
+	require.Len(t, limiter.buckets, 2)
+	require.NotContains(t, limiter.buckets, "ip:10.0.0.1")
+}
@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	db "simplebank/db/sqlc"
+	"sync"
+	"time"
+)
+
+// PostgresLimiter keeps the buckets in the rate_limit_buckets table, so the
+// limits hold across every instance of the app. Each call costs one query,
+// two when it's denied.
+type PostgresLimiter struct {
+	store db.Store
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+	now         func() time.Time
+}
+
+func NewPostgresLimiter(store db.Store) *PostgresLimiter {
+	return &PostgresLimiter{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+func (limiter *PostgresLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	return limiter.AllowN(ctx, key, limit, 1)
+}
+
+func (limiter *PostgresLimiter) AllowN(ctx context.Context, key string, limit Limit, n int) (Result, error) {
+	limiter.cleanup(ctx)
+
+	tokens, err := limiter.store.TakeRateLimitToken(ctx, db.TakeRateLimitTokenParams{
+		Key:   key,
+		Burst: float64(limit.Burst),
+		Rate:  limit.Rate,
+		Cost:  float64(n),
+	})
+	if err == nil {
+		return newResult(limit, true, tokens, n), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return Result{}, err
+	}
+
+	tokens, err = limiter.store.GetRateLimitTokens(ctx, db.GetRateLimitTokensParams{
+		Key:   key,
+		Burst: float64(limit.Burst),
+		Rate:  limit.Rate,
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return newResult(limit, false, tokens, n), nil
+}
+
+// cleanup deletes idle buckets now and then, one instance doing it is enough
+// but it doesn't hurt if several do
+func (limiter *PostgresLimiter) cleanup(ctx context.Context) {
+	limiter.mu.Lock()
+	now := limiter.now()
+	due := now.Sub(limiter.lastCleanup) >= idleBucketTTL
+	if due {
+		limiter.lastCleanup = now
+	}
+	limiter.mu.Unlock()
+
+	if due {
+		// a failed cleanup is retried after the next TTL, it must not fail the request
+		_ = limiter.store.DeleteRateLimitBucketsBefore(ctx, now.Add(-idleBucketTTL))
+	}
+}
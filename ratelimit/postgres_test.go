@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresLimiter(t *testing.T) {
+	limit := Limit{Rate: 0.5, Burst: 5}
+	key := "user:alice"
+
+	takeParams := db.TakeRateLimitTokenParams{Key: key, Burst: 5, Rate: 0.5, Cost: 1}
+	getParams := db.GetRateLimitTokensParams{Key: key, Burst: 5, Rate: 0.5}
+
+	testCases := []struct {
+		buildStubs  func(store *mockdb.MockStore)
+		checkResult func(t *testing.T, result Result, err error)
+		name        string
+	}{
+		{
+			name: "Allowed",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TakeRateLimitToken(gomock.Any(), gomock.Eq(takeParams)).Times(1).Return(3.4, nil)
+				store.EXPECT().GetRateLimitTokens(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResult: func(t *testing.T, result Result, err error) {
+				require.NoError(t, err)
+				require.Equal(t, Result{Allowed: true, Remaining: 3}, result)
+			},
+		},
+		{
+			name: "Denied",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TakeRateLimitToken(gomock.Any(), gomock.Eq(takeParams)).Times(1).Return(0.0, sql.ErrNoRows)
+				store.EXPECT().GetRateLimitTokens(gomock.Any(), gomock.Eq(getParams)).Times(1).Return(0.5, nil)
+			},
+			checkResult: func(t *testing.T, result Result, err error) {
+				require.NoError(t, err)
+				require.False(t, result.Allowed)
+				require.Equal(t, time.Second, result.RetryAfter)
+			},
+		},
+		{
+			name: "StoreError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TakeRateLimitToken(gomock.Any(), gomock.Any()).Times(1).Return(0.0, sql.ErrConnDone)
+				store.EXPECT().GetRateLimitTokens(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResult: func(t *testing.T, result Result, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().DeleteRateLimitBucketsBefore(gomock.Any(), gomock.Any()).Times(1)
+			tc.buildStubs(store)
+
+			limiter := NewPostgresLimiter(store)
+			result, err := limiter.Allow(context.Background(), key, limit)
+			tc.checkResult(t, result, err)
+		})
+	}
+}
+
+func TestPostgresLimiterCleanup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	store := mockdb.NewMockStore(ctrl)
+	limiter := NewPostgresLimiter(store)
+	limiter.now = func() time.Time { return now }
+
+	store.EXPECT().TakeRateLimitToken(gomock.Any(), gomock.Any()).Times(3).Return(1.0, nil)
+	store.EXPECT().DeleteRateLimitBucketsBefore(gomock.Any(), gomock.Eq(now.Add(-idleBucketTTL))).Times(1).Return(nil)
+	store.EXPECT().DeleteRateLimitBucketsBefore(gomock.Any(), gomock.Eq(now.Add(time.Hour-idleBucketTTL))).Times(1).Return(sql.ErrConnDone)
+
+	limit := Limit{Rate: 1, Burst: 5}
+	for _, elapsed := range []time.Duration{0, 30 * time.Minute, 30 * time.Minute} {
+		now = now.Add(elapsed)
+		_, err := limiter.Allow(context.Background(), "ip:10.0.0.1", limit)
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,255 @@
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"simplebank/util"
+	"strconv"
+	"time"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// the types below follow the element order of the camt.053.001.02 schema,
+// only the elements we actually fill in are declared
+
+type camtDocument struct {
+	XMLName xml.Name          `xml:"Document"`
+	Xmlns   string            `xml:"xmlns,attr"`
+	Stmt    camtBkToCstmrStmt `xml:"BkToCstmrStmt"`
+}
+
+type camtBkToCstmrStmt struct {
+	GrpHdr camtGrpHdr    `xml:"GrpHdr"`
+	Stmt   camtStatement `xml:"Stmt"`
+}
+
+type camtGrpHdr struct {
+	MsgID   string `xml:"MsgId"`
+	CreDtTm string `xml:"CreDtTm"`
+}
+
+type camtStatement struct {
+	ID        string      `xml:"Id"`
+	CreDtTm   string      `xml:"CreDtTm"`
+	FrToDt    camtFrToDt  `xml:"FrToDt"`
+	Acct      camtAccount `xml:"Acct"`
+	Bal       []camtBal   `xml:"Bal"`
+	TxsSummry camtSummary `xml:"TxsSummry"`
+	Ntry      []camtEntry `xml:"Ntry"`
+}
+
+type camtFrToDt struct {
+	FrDtTm string `xml:"FrDtTm"`
+	ToDtTm string `xml:"ToDtTm"`
+}
+
+type camtAccount struct {
+	ID   camtAccountID `xml:"Id"`
+	Ccy  string        `xml:"Ccy"`
+	Ownr camtParty     `xml:"Ownr"`
+}
+
+type camtAccountID struct {
+	Othr camtOtherID `xml:"Othr"`
+}
+
+type camtOtherID struct {
+	ID string `xml:"Id"`
+}
+
+type camtParty struct {
+	Nm string `xml:"Nm"`
+}
+
+type camtAmount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type camtBal struct {
+	Tp        camtBalType `xml:"Tp"`
+	Amt       camtAmount  `xml:"Amt"`
+	CdtDbtInd string      `xml:"CdtDbtInd"`
+	Dt        camtDate    `xml:"Dt"`
+}
+
+type camtBalType struct {
+	CdOrPrtry camtCode `xml:"CdOrPrtry"`
+}
+
+type camtCode struct {
+	Cd string `xml:"Cd"`
+}
+
+type camtDate struct {
+	Dt   string `xml:"Dt,omitempty"`
+	DtTm string `xml:"DtTm,omitempty"`
+}
+
+type camtSummary struct {
+	TtlNtries    camtTotal `xml:"TtlNtries"`
+	TtlCdtNtries camtTotal `xml:"TtlCdtNtries"`
+	TtlDbtNtries camtTotal `xml:"TtlDbtNtries"`
+}
+
+type camtTotal struct {
+	NbOfNtries string `xml:"NbOfNtries"`
+	Sum        string `xml:"Sum"`
+}
+
+type camtEntry struct {
+	NtryRef   string          `xml:"NtryRef"`
+	Amt       camtAmount      `xml:"Amt"`
+	CdtDbtInd string          `xml:"CdtDbtInd"`
+	Sts       string          `xml:"Sts"`
+	BookgDt   camtDate        `xml:"BookgDt"`
+	ValDt     camtDate        `xml:"ValDt"`
+	BkTxCd    camtBkTxCd      `xml:"BkTxCd"`
+	NtryDtls  camtEntryDetail `xml:"NtryDtls"`
+}
+
+type camtBkTxCd struct {
+	Domn camtDomain `xml:"Domn"`
+}
+
+type camtDomain struct {
+	Cd   string     `xml:"Cd"`
+	Fmly camtFamily `xml:"Fmly"`
+}
+
+type camtFamily struct {
+	Cd        string `xml:"Cd"`
+	SubFmlyCd string `xml:"SubFmlyCd"`
+}
+
+type camtEntryDetail struct {
+	TxDtls camtTxDetail `xml:"TxDtls"`
+}
+
+type camtTxDetail struct {
+	Refs       *camtRefs `xml:"Refs,omitempty"`
+	AddtlTxInf string    `xml:"AddtlTxInf"`
+}
+
+type camtRefs struct {
+	EndToEndID string `xml:"EndToEndId"`
+}
+
+// WriteCamt053 renders the statement as an ISO 20022 camt.053.001.02 bank to customer statement
+func WriteCamt053(w io.Writer, st Statement, createdAt time.Time) error {
+	currency := st.Account.Currency
+	id := fmt.Sprintf("STMT-%d-%s", st.Account.ID, st.Period.From.Format("20060102"))
+	lastDay := st.Period.To.AddDate(0, 0, -1)
+
+	doc := camtDocument{
+		Xmlns: camt053Namespace,
+		Stmt: camtBkToCstmrStmt{
+			GrpHdr: camtGrpHdr{
+				MsgID:   id,
+				CreDtTm: createdAt.UTC().Format(time.RFC3339),
+			},
+			Stmt: camtStatement{
+				ID:      id,
+				CreDtTm: createdAt.UTC().Format(time.RFC3339),
+				FrToDt: camtFrToDt{
+					FrDtTm: st.Period.From.UTC().Format(time.RFC3339),
+					ToDtTm: st.Period.To.UTC().Add(-time.Second).Format(time.RFC3339),
+				},
+				Acct: camtAccount{
+					ID:   camtAccountID{Othr: camtOtherID{ID: strconv.FormatInt(st.Account.ID, 10)}},
+					Ccy:  currency,
+					Ownr: camtParty{Nm: st.Account.Owner},
+				},
+				Bal: []camtBal{
+					camtBalance("OPBD", st.OpeningBalance, currency, st.Period.From),
+					camtBalance("CLBD", st.ClosingBalance, currency, lastDay),
+				},
+				TxsSummry: camtSummary{
+					TtlNtries: camtTotal{
+						NbOfNtries: strconv.Itoa(len(st.Lines)),
+						Sum:        util.FormatAmount(st.TotalCredits() + st.TotalDebits()),
+					},
+					TtlCdtNtries: camtTotal{
+						NbOfNtries: strconv.Itoa(st.countLines(1)),
+						Sum:        util.FormatAmount(st.TotalCredits()),
+					},
+					TtlDbtNtries: camtTotal{
+						NbOfNtries: strconv.Itoa(st.countLines(-1)),
+						Sum:        util.FormatAmount(st.TotalDebits()),
+					},
+				},
+				Ntry: make([]camtEntry, 0, len(st.Lines)),
+			},
+		},
+	}
+
+	for _, line := range st.Lines {
+		amount, indicator := creditDebit(line.Amount)
+		family := "RCDT" // received credit transfer
+		if indicator == "DBIT" {
+			family = "ICDT" // issued credit transfer
+		}
+
+		entry := camtEntry{
+			NtryRef:   strconv.FormatInt(line.EntryID, 10),
+			Amt:       camtAmount{Ccy: currency, Value: util.FormatAmount(amount)},
+			CdtDbtInd: indicator,
+			Sts:       "BOOK",
+			BookgDt:   camtDate{DtTm: line.BookedAt.UTC().Format(time.RFC3339)},
+			ValDt:     camtDate{Dt: line.BookedAt.UTC().Format(time.DateOnly)},
+			BkTxCd: camtBkTxCd{Domn: camtDomain{
+				Cd:   "PMNT",
+				Fmly: camtFamily{Cd: family, SubFmlyCd: "BOOK"},
+			}},
+			NtryDtls: camtEntryDetail{TxDtls: camtTxDetail{AddtlTxInf: line.Description}},
+		}
+		if line.TransferID != 0 {
+			entry.NtryDtls.TxDtls.Refs = &camtRefs{EndToEndID: strconv.FormatInt(line.TransferID, 10)}
+		}
+
+		doc.Stmt.Stmt.Ntry = append(doc.Stmt.Stmt.Ntry, entry)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func camtBalance(code string, balance int64, currency string, date time.Time) camtBal {
+	amount, indicator := creditDebit(balance)
+	return camtBal{
+		Tp:        camtBalType{CdOrPrtry: camtCode{Cd: code}},
+		Amt:       camtAmount{Ccy: currency, Value: util.FormatAmount(amount)},
+		CdtDbtInd: indicator,
+		Dt:        camtDate{Dt: date.Format(time.DateOnly)},
+	}
+}
+
+// creditDebit splits a signed amount into the absolute amount and its ISO 20022 credit/debit indicator
+func creditDebit(amount int64) (int64, string) {
+	if amount < 0 {
+		return -amount, "DBIT"
+	}
+	return amount, "CRDT"
+}
+
+// countLines counts the credit (sign > 0) or debit (sign < 0) lines of the statement
+func (st Statement) countLines(sign int) int {
+	n := 0
+	for _, line := range st.Lines {
+		if (sign > 0 && line.Amount > 0) || (sign < 0 && line.Amount < 0) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,304 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// xsdElement is one particle of a complex type's sequence (or choice) in the camt.053.001.02 schema
+type xsdElement struct {
+	name string
+	typ  string
+	min  int
+	max  int // -1 means unbounded
+}
+
+type xsdComplexType struct {
+	elements []xsdElement
+	choice   bool
+}
+
+const unbounded = -1
+
+// camt053Schema transcribes the parts of camt.053.001.02.xsd reachable from the elements we emit.
+// Sequences are complete so that ordering is checked, types we never emit are left empty.
+var camt053Schema = map[string]xsdComplexType{
+	"Document": {elements: []xsdElement{
+		{"BkToCstmrStmt", "BankToCustomerStatementV02", 1, 1},
+	}},
+	"BankToCustomerStatementV02": {elements: []xsdElement{
+		{"GrpHdr", "GroupHeader42", 1, 1},
+		{"Stmt", "AccountStatement2", 1, unbounded},
+	}},
+	"GroupHeader42": {elements: []xsdElement{
+		{"MsgId", "Max35Text", 1, 1},
+		{"CreDtTm", "ISODateTime", 1, 1},
+		{"MsgRcpt", "", 0, 1},
+		{"MsgPgntn", "", 0, 1},
+		{"AddtlInf", "Max500Text", 0, 1},
+	}},
+	"AccountStatement2": {elements: []xsdElement{
+		{"Id", "Max35Text", 1, 1},
+		{"ElctrncSeqNb", "", 0, 1},
+		{"LglSeqNb", "", 0, 1},
+		{"CreDtTm", "ISODateTime", 1, 1},
+		{"FrToDt", "DateTimePeriodDetails", 0, 1},
+		{"CpyDplctInd", "", 0, 1},
+		{"RptgSrc", "", 0, 1},
+		{"Acct", "CashAccount20", 1, 1},
+		{"RltdAcct", "", 0, 1},
+		{"Intrst", "", 0, unbounded},
+		{"Bal", "CashBalance3", 1, unbounded},
+		{"TxsSummry", "TotalTransactions2", 0, 1},
+		{"Ntry", "ReportEntry2", 0, unbounded},
+		{"AddtlStmtInf", "Max500Text", 0, 1},
+	}},
+	"DateTimePeriodDetails": {elements: []xsdElement{
+		{"FrDtTm", "ISODateTime", 1, 1},
+		{"ToDtTm", "ISODateTime", 1, 1},
+	}},
+	"CashAccount20": {elements: []xsdElement{
+		{"Id", "AccountIdentification4Choice", 1, 1},
+		{"Tp", "", 0, 1},
+		{"Ccy", "ActiveOrHistoricCurrencyCode", 0, 1},
+		{"Nm", "Max70Text", 0, 1},
+		{"Ownr", "PartyIdentification32", 0, 1},
+		{"Svcr", "", 0, 1},
+	}},
+	"AccountIdentification4Choice": {choice: true, elements: []xsdElement{
+		{"IBAN", "", 1, 1},
+		{"Othr", "GenericAccountIdentification1", 1, 1},
+	}},
+	"GenericAccountIdentification1": {elements: []xsdElement{
+		{"Id", "Max34Text", 1, 1},
+		{"SchmeNm", "", 0, 1},
+		{"Issr", "", 0, 1},
+	}},
+	"PartyIdentification32": {elements: []xsdElement{
+		{"Nm", "Max140Text", 0, 1},
+		{"PstlAdr", "", 0, 1},
+		{"Id", "", 0, 1},
+		{"CtryOfRes", "", 0, 1},
+		{"CtctDtls", "", 0, 1},
+	}},
+	"CashBalance3": {elements: []xsdElement{
+		{"Tp", "BalanceType12", 1, 1},
+		{"CdtLine", "", 0, 1},
+		{"Amt", "ActiveOrHistoricCurrencyAndAmount", 1, 1},
+		{"CdtDbtInd", "CreditDebitCode", 1, 1},
+		{"Dt", "DateAndDateTimeChoice", 1, 1},
+		{"Avlbty", "", 0, unbounded},
+	}},
+	"BalanceType12": {elements: []xsdElement{
+		{"CdOrPrtry", "BalanceType5Choice", 1, 1},
+		{"SubTp", "", 0, 1},
+	}},
+	"BalanceType5Choice": {choice: true, elements: []xsdElement{
+		{"Cd", "BalanceType12Code", 1, 1},
+		{"Prtry", "Max35Text", 1, 1},
+	}},
+	"DateAndDateTimeChoice": {choice: true, elements: []xsdElement{
+		{"Dt", "ISODate", 1, 1},
+		{"DtTm", "ISODateTime", 1, 1},
+	}},
+	"TotalTransactions2": {elements: []xsdElement{
+		{"TtlNtries", "NumberAndSumOfTransactions2", 0, 1},
+		{"TtlCdtNtries", "NumberAndSumOfTransactions1", 0, 1},
+		{"TtlDbtNtries", "NumberAndSumOfTransactions1", 0, 1},
+		{"TtlNtriesPerBkTxCd", "", 0, unbounded},
+	}},
+	"NumberAndSumOfTransactions2": {elements: []xsdElement{
+		{"NbOfNtries", "Max15NumericText", 0, 1},
+		{"Sum", "DecimalNumber", 0, 1},
+		{"TtlNetNtryAmt", "DecimalNumber", 0, 1},
+		{"CdtDbtInd", "CreditDebitCode", 0, 1},
+	}},
+	"NumberAndSumOfTransactions1": {elements: []xsdElement{
+		{"NbOfNtries", "Max15NumericText", 0, 1},
+		{"Sum", "DecimalNumber", 0, 1},
+	}},
+	"ReportEntry2": {elements: []xsdElement{
+		{"NtryRef", "Max35Text", 0, 1},
+		{"Amt", "ActiveOrHistoricCurrencyAndAmount", 1, 1},
+		{"CdtDbtInd", "CreditDebitCode", 1, 1},
+		{"RvslInd", "", 0, 1},
+		{"Sts", "EntryStatus2Code", 1, 1},
+		{"BookgDt", "DateAndDateTimeChoice", 0, 1},
+		{"ValDt", "DateAndDateTimeChoice", 0, 1},
+		{"AcctSvcrRef", "Max35Text", 0, 1},
+		{"Avlbty", "", 0, unbounded},
+		{"BkTxCd", "BankTransactionCodeStructure4", 1, 1},
+		{"ComssnWvrInd", "", 0, 1},
+		{"AddtlInfInd", "", 0, 1},
+		{"AmtDtls", "", 0, 1},
+		{"Chrgs", "", 0, unbounded},
+		{"TechInptChanl", "", 0, 1},
+		{"Intrst", "", 0, unbounded},
+		{"NtryDtls", "EntryDetails1", 0, unbounded},
+		{"AddtlNtryInf", "Max500Text", 0, 1},
+	}},
+	"BankTransactionCodeStructure4": {elements: []xsdElement{
+		{"Domn", "BankTransactionCodeStructure5", 0, 1},
+		{"Prtry", "", 0, 1},
+	}},
+	"BankTransactionCodeStructure5": {elements: []xsdElement{
+		{"Cd", "Max4Text", 1, 1},
+		{"Fmly", "BankTransactionCodeStructure6", 1, 1},
+	}},
+	"BankTransactionCodeStructure6": {elements: []xsdElement{
+		{"Cd", "Max4Text", 1, 1},
+		{"SubFmlyCd", "Max4Text", 1, 1},
+	}},
+	"EntryDetails1": {elements: []xsdElement{
+		{"Btch", "", 0, 1},
+		{"TxDtls", "EntryTransaction2", 0, unbounded},
+	}},
+	"EntryTransaction2": {elements: []xsdElement{
+		{"Refs", "TransactionReferences2", 0, 1},
+		{"AmtDtls", "", 0, 1},
+		{"Avlbty", "", 0, unbounded},
+		{"BkTxCd", "", 0, 1},
+		{"Chrgs", "", 0, unbounded},
+		{"Intrst", "", 0, unbounded},
+		{"RltdPties", "", 0, 1},
+		{"RltdAgts", "", 0, 1},
+		{"Purp", "", 0, 1},
+		{"RltdRmtInf", "", 0, 10},
+		{"RmtInf", "", 0, 1},
+		{"RltdDts", "", 0, 1},
+		{"RltdPric", "", 0, 1},
+		{"RltdQties", "", 0, unbounded},
+		{"FinInstrmId", "", 0, 1},
+		{"Tax", "", 0, 1},
+		{"RtrInf", "", 0, 1},
+		{"CorpActn", "", 0, 1},
+		{"SfkpgAcct", "", 0, 1},
+		{"AddtlTxInf", "Max500Text", 0, 1},
+	}},
+	"TransactionReferences2": {elements: []xsdElement{
+		{"MsgId", "Max35Text", 0, 1},
+		{"AcctSvcrRef", "Max35Text", 0, 1},
+		{"PmtInfId", "Max35Text", 0, 1},
+		{"InstrId", "Max35Text", 0, 1},
+		{"EndToEndId", "Max35Text", 0, 1},
+		{"TxId", "Max35Text", 0, 1},
+		{"MndtId", "Max35Text", 0, 1},
+		{"ChqNb", "Max35Text", 0, 1},
+		{"ClrSysRef", "Max35Text", 0, 1},
+		{"Prtry", "", 0, 1},
+	}},
+}
+
+var camt053SimpleTypes = map[string]*regexp.Regexp{
+	"Max4Text":                          regexp.MustCompile(`^.{1,4}$`),
+	"Max34Text":                         regexp.MustCompile(`^.{1,34}$`),
+	"Max35Text":                         regexp.MustCompile(`^.{1,35}$`),
+	"Max70Text":                         regexp.MustCompile(`^.{1,70}$`),
+	"Max140Text":                        regexp.MustCompile(`^.{1,140}$`),
+	"Max500Text":                        regexp.MustCompile(`^.{1,500}$`),
+	"Max15NumericText":                  regexp.MustCompile(`^[0-9]{1,15}$`),
+	"DecimalNumber":                     regexp.MustCompile(`^-?[0-9]{1,18}(\.[0-9]{1,17})?$`),
+	"ISODate":                           regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`),
+	"ISODateTime":                       regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})?$`),
+	"ActiveOrHistoricCurrencyCode":      regexp.MustCompile(`^[A-Z]{3}$`),
+	"ActiveOrHistoricCurrencyAndAmount": regexp.MustCompile(`^[0-9]{1,13}(\.[0-9]{1,5})?$`),
+	"CreditDebitCode":                   regexp.MustCompile(`^(CRDT|DBIT)$`),
+	"EntryStatus2Code":                  regexp.MustCompile(`^(BOOK|PDNG|INFO)$`),
+	"BalanceType12Code":                 regexp.MustCompile(`^(XPCD|OPAV|ITAV|CLAV|FWAV|CLBD|ITBD|OPBD|PRCD|INFO)$`),
+}
+
+type xmlNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []xmlNode  `xml:",any"`
+	Text     string     `xml:",chardata"`
+}
+
+// validateCamt053 checks a document against the transcribed content model
+func validateCamt053(t *testing.T, data []byte) {
+	var root xmlNode
+	require.NoError(t, xml.Unmarshal(data, &root))
+	require.Equal(t, "Document", root.XMLName.Local)
+	require.Equal(t, camt053Namespace, root.XMLName.Space)
+
+	validateNode(t, "Document", root, "Document")
+}
+
+func validateNode(t *testing.T, path string, node xmlNode, typ string) {
+	if pattern, ok := camt053SimpleTypes[typ]; ok {
+		require.Empty(t, node.Children, path)
+		require.Regexp(t, pattern, node.Text, path)
+		if typ == "ActiveOrHistoricCurrencyAndAmount" {
+			require.Len(t, node.Attrs, 1, path)
+			require.Equal(t, "Ccy", node.Attrs[0].Name.Local, path)
+			require.Regexp(t, camt053SimpleTypes["ActiveOrHistoricCurrencyCode"], node.Attrs[0].Value, path)
+		}
+		return
+	}
+
+	complexType, ok := camt053Schema[typ]
+	require.True(t, ok, "%s: no schema for type %q", path, typ)
+
+	if complexType.choice {
+		require.Len(t, node.Children, 1, "%s: choice must have exactly one element", path)
+		child := node.Children[0]
+		for _, el := range complexType.elements {
+			if el.name == child.XMLName.Local {
+				validateNode(t, path+"/"+el.name, child, el.typ)
+				return
+			}
+		}
+		require.Failf(t, "invalid choice", "%s: unexpected element %s", path, child.XMLName.Local)
+	}
+
+	i := 0
+	for _, el := range complexType.elements {
+		n := 0
+		for i < len(node.Children) && node.Children[i].XMLName.Local == el.name {
+			validateNode(t, fmt.Sprintf("%s/%s[%d]", path, el.name, n), node.Children[i], el.typ)
+			i++
+			n++
+		}
+		require.GreaterOrEqual(t, n, el.min, "%s: missing %s", path, el.name)
+		if el.max != unbounded {
+			require.LessOrEqual(t, n, el.max, "%s: too many %s", path, el.name)
+		}
+	}
+	if i < len(node.Children) {
+		require.Failf(t, "unexpected element", "%s: %s is not allowed here", path, node.Children[i].XMLName.Local)
+	}
+}
+
+func TestWriteCamt053(t *testing.T) {
+	st := testStatement(t)
+
+	var buf bytes.Buffer
+	err := WriteCamt053(&buf, st, time.Date(2026, 10, 1, 6, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	validateCamt053(t, buf.Bytes())
+
+	fixture, err := os.ReadFile("testdata/camt053.xml")
+	require.NoError(t, err)
+	require.Equal(t, string(fixture), buf.String())
+}
+
+func TestWriteCamt053NegativeBalance(t *testing.T) {
+	st := testStatement(t)
+	st.OpeningBalance = -100
+	st.Lines = nil
+
+	var buf bytes.Buffer
+	err := WriteCamt053(&buf, st, time.Now())
+	require.NoError(t, err)
+
+	validateCamt053(t, buf.Bytes())
+	require.Contains(t, buf.String(), "<Amt Ccy=\"USD\">1.00</Amt>\n        <CdtDbtInd>DBIT</CdtDbtInd>")
+}
@@ -0,0 +1,44 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"simplebank/util"
+	"strconv"
+	"time"
+)
+
+// WriteCSV renders the statement as CSV: one row per entry framed by the opening and closing balance rows
+func WriteCSV(w io.Writer, st Statement) error {
+	cw := csv.NewWriter(w)
+
+	rows := [][]string{
+		{"date", "entry_id", "transfer_id", "description", "amount", "balance", "currency"},
+		{st.Period.From.Format(time.DateOnly), "", "", "Opening balance", "", util.FormatAmount(st.OpeningBalance), st.Account.Currency},
+	}
+
+	for _, line := range st.Lines {
+		transferID := ""
+		if line.TransferID != 0 {
+			transferID = strconv.FormatInt(line.TransferID, 10)
+		}
+
+		rows = append(rows, []string{
+			line.BookedAt.UTC().Format(time.RFC3339),
+			strconv.FormatInt(line.EntryID, 10),
+			transferID,
+			line.Description,
+			util.FormatAmount(line.Amount),
+			util.FormatAmount(line.Balance),
+			st.Account.Currency,
+		})
+	}
+
+	closedOn := st.Period.To.AddDate(0, 0, -1)
+	rows = append(rows, []string{closedOn.Format(time.DateOnly), "", "", "Closing balance", "", util.FormatAmount(st.ClosingBalance), st.Account.Currency})
+
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
@@ -0,0 +1,99 @@
+package statement
+
+import (
+	"fmt"
+	"io"
+	"simplebank/util"
+	"strings"
+)
+
+// SWIFT field limits used by MT940
+const (
+	mt940ReferenceSize   = 16
+	mt940NarrativeLines  = 6
+	mt940NarrativeLength = 65
+)
+
+// WriteMT940 renders the statement as the text block of a SWIFT MT940 customer statement message
+func WriteMT940(w io.Writer, st Statement) error {
+	currency := st.Account.Currency
+	lastDay := st.Period.To.AddDate(0, 0, -1)
+
+	fields := []string{
+		":20:" + truncate(fmt.Sprintf("S%d%s", st.Account.ID, st.Period.From.Format("060102")), mt940ReferenceSize),
+		fmt.Sprintf(":25:%d", st.Account.ID),
+		fmt.Sprintf(":28C:%s/1", st.Period.From.Format("0601")),
+		":60F:" + mt940Balance(st.OpeningBalance, st.Period.From.Format("060102"), currency),
+	}
+
+	for _, line := range st.Lines {
+		mark, amount := "C", line.Amount
+		if amount < 0 {
+			mark, amount = "D", -amount
+		}
+
+		reference := "NONREF"
+		if line.TransferID != 0 {
+			reference = fmt.Sprint(line.TransferID)
+		}
+
+		booked := line.BookedAt.UTC()
+		fields = append(fields,
+			fmt.Sprintf(":61:%s%s%s%sNTRF%s//%s",
+				booked.Format("060102"),
+				booked.Format("0102"),
+				mark,
+				mt940Amount(amount),
+				truncate(reference, mt940ReferenceSize),
+				truncate(fmt.Sprint(line.EntryID), mt940ReferenceSize),
+			),
+			":86:"+mt940Narrative(line.Description),
+		)
+	}
+
+	fields = append(fields, ":62F:"+mt940Balance(st.ClosingBalance, lastDay.Format("060102"), currency))
+
+	_, err := io.WriteString(w, strings.Join(fields, "\r\n")+"\r\n")
+	return err
+}
+
+func mt940Balance(balance int64, date, currency string) string {
+	mark := "C"
+	if balance < 0 {
+		mark, balance = "D", -balance
+	}
+	return mark + date + currency + mt940Amount(balance)
+}
+
+// mt940Amount uses a comma as decimal separator, as required by SWIFT
+func mt940Amount(amount int64) string {
+	return strings.Replace(util.FormatAmount(amount), ".", ",", 1)
+}
+
+// mt940Narrative wraps text to the 6*65x format of field 86, dropping characters outside the SWIFT x character set
+func mt940Narrative(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune(swiftCharset, r) {
+			sb.WriteRune(r)
+		}
+	}
+	text = sb.String()
+
+	var lines []string
+	for len(text) > 0 && len(lines) < mt940NarrativeLines {
+		n := min(mt940NarrativeLength, len(text))
+		lines = append(lines, text[:n])
+		text = text[n:]
+	}
+	return strings.Join(lines, "\r\n")
+}
+
+const swiftCharset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789/-?:().,'+ "
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,74 @@
+package statement
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// mt940Fields matches one field of the MT940 text block with its SWIFT format
+var mt940Fields = map[string]*regexp.Regexp{
+	"20":  regexp.MustCompile(`^[^/].{0,15}$`),
+	"25":  regexp.MustCompile(`^.{1,35}$`),
+	"28C": regexp.MustCompile(`^\d{1,5}(/\d{1,5})?$`),
+	"60F": regexp.MustCompile(`^[CD]\d{6}[A-Z]{3}\d{1,12},\d{0,2}$`),
+	"61":  regexp.MustCompile(`^\d{6}(\d{4})?[CD]\d{1,12},\d{0,2}N[A-Z0-9]{3}.{1,16}(//.{1,16})?$`),
+	"86":  regexp.MustCompile(`^.{1,65}(\r\n.{1,65}){0,5}$`),
+	"62F": regexp.MustCompile(`^[CD]\d{6}[A-Z]{3}\d{1,12},\d{0,2}$`),
+}
+
+func validateMT940(t *testing.T, data string) {
+	require.True(t, strings.HasSuffix(data, "\r\n"))
+
+	tags := regexp.MustCompile(`(?m)^:([0-9]{2}[A-Z]?):`)
+	locs := tags.FindAllStringSubmatchIndex(data, -1)
+	require.NotEmpty(t, locs)
+
+	var order []string
+	for i, loc := range locs {
+		tag := data[loc[2]:loc[3]]
+		end := len(data)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		value := strings.TrimSuffix(data[loc[1]:end], "\r\n")
+
+		pattern, ok := mt940Fields[tag]
+		require.True(t, ok, "unexpected field %s", tag)
+		require.Regexp(t, pattern, value, "field %s", tag)
+		order = append(order, tag)
+	}
+
+	sequence := strings.Join(order, " ")
+	require.Regexp(t, `^20 25 28C 60F( 61 86)* 62F$`, sequence)
+}
+
+func TestWriteMT940(t *testing.T) {
+	st := testStatement(t)
+
+	var buf bytes.Buffer
+	err := WriteMT940(&buf, st)
+	require.NoError(t, err)
+
+	validateMT940(t, buf.String())
+
+	fixture, err := os.ReadFile("testdata/mt940.txt")
+	require.NoError(t, err)
+	require.Equal(t, string(fixture), buf.String())
+}
+
+func TestMT940Narrative(t *testing.T) {
+	require.Equal(t, "Transfer to account 2", mt940Narrative("Transfer to account 2"))
+	require.Equal(t, "caf price 10", mt940Narrative("café price 10€"))
+
+	long := mt940Narrative(strings.Repeat("a", 500))
+	lines := strings.Split(long, "\r\n")
+	require.Len(t, lines, mt940NarrativeLines)
+	for _, line := range lines {
+		require.Len(t, line, mt940NarrativeLength)
+	}
+}
@@ -0,0 +1,135 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"simplebank/util"
+	"strings"
+	"time"
+)
+
+// page geometry in PDF points (A4)
+const (
+	pageWidth    = 595
+	pageHeight   = 842
+	marginLeft   = 50
+	marginTop    = 60
+	lineHeight   = 14
+	linesPerPage = 48
+	fontSize     = 9
+)
+
+// WritePDF renders the statement as a plain, single font PDF document.
+// It writes the PDF objects by hand so no external renderer is needed.
+func WritePDF(w io.Writer, st Statement) error {
+	var pages [][]string
+	lines := pdfLines(st)
+	for len(lines) > 0 {
+		n := min(linesPerPage, len(lines))
+		pages = append(pages, lines[:n])
+		lines = lines[n:]
+	}
+
+	doc := &pdfDocument{}
+	// object 1: catalog, 2: page tree, 3: font; pages follow in pairs of page + content
+	doc.add("<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	doc.add(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	doc.add("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range pages {
+		content := pageContent(page, i+1, len(pages))
+		doc.add(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 5+2*i,
+		))
+		doc.add(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	_, err := w.Write(doc.bytes())
+	return err
+}
+
+// pdfLines lays the statement out as fixed width text lines
+func pdfLines(st Statement) []string {
+	lastDay := st.Period.To.AddDate(0, 0, -1)
+	lines := []string{
+		"Simple Bank - Account statement",
+		"",
+		fmt.Sprintf("Account:  %d (%s)", st.Account.ID, st.Account.Owner),
+		fmt.Sprintf("Currency: %s", st.Account.Currency),
+		fmt.Sprintf("Period:   %s to %s", st.Period.From.Format(time.DateOnly), lastDay.Format(time.DateOnly)),
+		"",
+		fmt.Sprintf("%-20s %10s  %-32s %14s %14s", "Date", "Entry", "Description", "Amount", "Balance"),
+		strings.Repeat("-", 94),
+		fmt.Sprintf("%-20s %10s  %-32s %14s %14s", st.Period.From.Format(time.DateOnly), "", "Opening balance", "", util.FormatAmount(st.OpeningBalance)),
+	}
+
+	for _, line := range st.Lines {
+		lines = append(lines, fmt.Sprintf("%-20s %10d  %-32.32s %14s %14s",
+			line.BookedAt.UTC().Format("2006-01-02 15:04:05"),
+			line.EntryID,
+			line.Description,
+			util.FormatAmount(line.Amount),
+			util.FormatAmount(line.Balance),
+		))
+	}
+
+	lines = append(lines,
+		strings.Repeat("-", 94),
+		fmt.Sprintf("%-20s %10s  %-32s %14s %14s", lastDay.Format(time.DateOnly), "", "Closing balance", "", util.FormatAmount(st.ClosingBalance)),
+		"",
+		fmt.Sprintf("Total debits:  %s", util.FormatAmount(st.TotalDebits())),
+		fmt.Sprintf("Total credits: %s", util.FormatAmount(st.TotalCredits())),
+	)
+	return lines
+}
+
+func pageContent(lines []string, page, total int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", fontSize, lineHeight, marginLeft, pageHeight-marginTop)
+	for _, line := range lines {
+		fmt.Fprintf(&sb, "(%s) '\n", escapePDFText(line))
+	}
+	fmt.Fprintf(&sb, "ET\nBT\n/F1 %d Tf\n%d %d Td\n(Page %d of %d) Tj\nET", fontSize, pageWidth-marginLeft-80, marginTop/2, page, total)
+	return sb.String()
+}
+
+func escapePDFText(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`)
+	return r.Replace(s)
+}
+
+// pdfDocument collects numbered indirect objects and writes them with a cross-reference table
+type pdfDocument struct {
+	objects []string
+}
+
+func (doc *pdfDocument) add(object string) {
+	doc.objects = append(doc.objects, object)
+}
+
+func (doc *pdfDocument) bytes() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(doc.objects))
+	for i, object := range doc.objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(doc.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(doc.objects)+1, xref)
+
+	return buf.Bytes()
+}
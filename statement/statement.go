@@ -0,0 +1,141 @@
+package statement
+
+import (
+	"fmt"
+	db "simplebank/db/sqlc"
+	"time"
+)
+
+// Period is the half-open time range [From, To) a statement covers
+type Period struct {
+	From time.Time
+	To   time.Time
+}
+
+// MonthPeriod returns the period covering the given calendar month ("2006-01") in UTC
+func MonthPeriod(month string) (Period, error) {
+	from, err := time.Parse("2006-01", month)
+	if err != nil {
+		return Period{}, fmt.Errorf("invalid month %q: must be formatted as YYYY-MM", month)
+	}
+
+	return Period{From: from, To: from.AddDate(0, 1, 0)}, nil
+}
+
+// DatePeriod returns the period covering the days from and to ("2006-01-02"), both inclusive, in UTC
+func DatePeriod(from, to string) (Period, error) {
+	fromDate, err := time.Parse(time.DateOnly, from)
+	if err != nil {
+		return Period{}, fmt.Errorf("invalid from date %q: must be formatted as YYYY-MM-DD", from)
+	}
+
+	toDate, err := time.Parse(time.DateOnly, to)
+	if err != nil {
+		return Period{}, fmt.Errorf("invalid to date %q: must be formatted as YYYY-MM-DD", to)
+	}
+
+	if toDate.Before(fromDate) {
+		return Period{}, fmt.Errorf("to date %s is before from date %s", to, from)
+	}
+
+	return Period{From: fromDate, To: toDate.AddDate(0, 0, 1)}, nil
+}
+
+// Line is a single booked entry on a statement
+type Line struct {
+	BookedAt    time.Time
+	EntryID     int64
+	TransferID  int64
+	Description string
+	Amount      int64
+	Balance     int64
+}
+
+// Statement lists every entry of an account for a period, between its opening and closing balance
+type Statement struct {
+	Period         Period
+	Account        db.Account
+	Lines          []Line
+	OpeningBalance int64
+	ClosingBalance int64
+}
+
+// New builds the statement of account for period. entries and transfers must be
+// the ones booked on the account during the period, ordered by id.
+func New(account db.Account, period Period, openingBalance int64, entries []db.Entry, transfers []db.Transfer) Statement {
+	st := Statement{
+		Account:        account,
+		Period:         period,
+		OpeningBalance: openingBalance,
+		Lines:          make([]Line, 0, len(entries)),
+	}
+
+	matched := make([]bool, len(transfers))
+	balance := openingBalance
+	for _, entry := range entries {
+		balance += entry.Amount
+		line := Line{
+			BookedAt:    entry.CreatedAt,
+			EntryID:     entry.ID,
+			Description: "Entry",
+			Amount:      entry.Amount,
+			Balance:     balance,
+		}
+
+		if i := matchTransfer(account.ID, entry, transfers, matched); i >= 0 {
+			matched[i] = true
+			transfer := transfers[i]
+			line.TransferID = transfer.ID
+			if transfer.FromAccountID == account.ID {
+				line.Description = fmt.Sprintf("Transfer to account %d", transfer.ToAccountID)
+			} else {
+				line.Description = fmt.Sprintf("Transfer from account %d", transfer.FromAccountID)
+			}
+		}
+
+		st.Lines = append(st.Lines, line)
+	}
+
+	st.ClosingBalance = balance
+	return st
+}
+
+// matchTransfer finds the transfer that produced entry. TransferTx books the transfer
+// and both of its entries in one transaction, so they share the same created_at.
+func matchTransfer(accountID int64, entry db.Entry, transfers []db.Transfer, matched []bool) int {
+	for i, transfer := range transfers {
+		if matched[i] || !transfer.CreatedAt.Equal(entry.CreatedAt) {
+			continue
+		}
+
+		if entry.Amount < 0 && transfer.FromAccountID == accountID && transfer.Amount == -entry.Amount {
+			return i
+		}
+		if entry.Amount > 0 && transfer.ToAccountID == accountID && transfer.Amount == entry.Amount {
+			return i
+		}
+	}
+	return -1
+}
+
+// TotalDebits returns the sum of all outgoing amounts on the statement, as a positive number
+func (st Statement) TotalDebits() int64 {
+	var total int64
+	for _, line := range st.Lines {
+		if line.Amount < 0 {
+			total -= line.Amount
+		}
+	}
+	return total
+}
+
+// TotalCredits returns the sum of all incoming amounts on the statement
+func (st Statement) TotalCredits() int64 {
+	var total int64
+	for _, line := range st.Lines {
+		if line.Amount > 0 {
+			total += line.Amount
+		}
+	}
+	return total
+}
@@ -0,0 +1,140 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	db "simplebank/db/sqlc"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testStatement(t *testing.T) Statement {
+	period, err := MonthPeriod("2026-09")
+	require.NoError(t, err)
+
+	account := db.Account{ID: 1, Owner: "alice", Balance: 12500, Currency: "USD"}
+	booked := period.From.Add(36 * time.Hour)
+
+	entries := []db.Entry{
+		{ID: 10, AccountID: 1, Amount: 5000, CreatedAt: period.From.Add(time.Hour)},
+		{ID: 11, AccountID: 1, Amount: -2500, CreatedAt: booked},
+		{ID: 13, AccountID: 1, Amount: 1000, CreatedAt: booked.Add(time.Hour)},
+	}
+	transfers := []db.Transfer{
+		{ID: 7, FromAccountID: 1, ToAccountID: 2, Amount: 2500, CreatedAt: booked},
+		{ID: 8, FromAccountID: 3, ToAccountID: 1, Amount: 1000, CreatedAt: booked.Add(time.Hour)},
+	}
+
+	return New(account, period, 9000, entries, transfers)
+}
+
+func TestMonthPeriod(t *testing.T) {
+	period, err := MonthPeriod("2026-12")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC), period.From)
+	require.Equal(t, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), period.To)
+
+	_, err = MonthPeriod("2026-13")
+	require.Error(t, err)
+
+	_, err = MonthPeriod("")
+	require.Error(t, err)
+}
+
+func TestDatePeriod(t *testing.T) {
+	period, err := DatePeriod("2026-09-10", "2026-09-20")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 9, 10, 0, 0, 0, 0, time.UTC), period.From)
+	require.Equal(t, time.Date(2026, 9, 21, 0, 0, 0, 0, time.UTC), period.To)
+
+	_, err = DatePeriod("2026-09-20", "2026-09-10")
+	require.Error(t, err)
+
+	_, err = DatePeriod("2026-09-10", "")
+	require.Error(t, err)
+}
+
+func TestNew(t *testing.T) {
+	st := testStatement(t)
+
+	require.Equal(t, int64(9000), st.OpeningBalance)
+	require.Equal(t, int64(12500), st.ClosingBalance)
+	require.Equal(t, int64(2500), st.TotalDebits())
+	require.Equal(t, int64(6000), st.TotalCredits())
+	require.Len(t, st.Lines, 3)
+
+	require.Equal(t, "Entry", st.Lines[0].Description)
+	require.Zero(t, st.Lines[0].TransferID)
+	require.Equal(t, int64(14000), st.Lines[0].Balance)
+
+	require.Equal(t, "Transfer to account 2", st.Lines[1].Description)
+	require.Equal(t, int64(7), st.Lines[1].TransferID)
+	require.Equal(t, int64(11500), st.Lines[1].Balance)
+
+	require.Equal(t, "Transfer from account 3", st.Lines[2].Description)
+	require.Equal(t, int64(8), st.Lines[2].TransferID)
+	require.Equal(t, st.ClosingBalance, st.Lines[2].Balance)
+}
+
+func TestWriteCSV(t *testing.T) {
+	st := testStatement(t)
+
+	var buf bytes.Buffer
+	err := WriteCSV(&buf, st)
+	require.NoError(t, err)
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, len(st.Lines)+3)
+
+	require.Equal(t, []string{"2026-09-01", "", "", "Opening balance", "", "90.00", "USD"}, rows[1])
+	require.Equal(t, []string{"2026-09-02T12:00:00Z", "11", "7", "Transfer to account 2", "-25.00", "115.00", "USD"}, rows[3])
+	require.Equal(t, []string{"2026-09-30", "", "", "Closing balance", "", "125.00", "USD"}, rows[len(rows)-1])
+}
+
+func TestWritePDF(t *testing.T) {
+	st := testStatement(t)
+
+	var buf bytes.Buffer
+	err := WritePDF(&buf, st)
+	require.NoError(t, err)
+
+	data := buf.Bytes()
+	require.True(t, bytes.HasPrefix(data, []byte("%PDF-1.4\n")))
+	require.True(t, bytes.HasSuffix(data, []byte("%%EOF\n")))
+	require.Contains(t, buf.String(), "Transfer to account 2 ")
+	require.Contains(t, buf.String(), "(Page 1 of 1) Tj")
+
+	// every xref entry must point at the start of its object
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	require.NotNil(t, startxref)
+	xref, err := strconv.Atoi(string(startxref[1]))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(data[xref:], []byte("xref\n")))
+
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(data[xref:], -1)
+	require.Len(t, offsets, 5)
+	for i, offset := range offsets {
+		n, err := strconv.Atoi(string(offset[1]))
+		require.NoError(t, err)
+		require.True(t, bytes.HasPrefix(data[n:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))))
+	}
+}
+
+func TestWritePDFPages(t *testing.T) {
+	st := testStatement(t)
+	for i := 0; i < 2*linesPerPage; i++ {
+		st.Lines = append(st.Lines, st.Lines[0])
+	}
+
+	var buf bytes.Buffer
+	err := WritePDF(&buf, st)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "/Count 3")
+	require.Contains(t, buf.String(), "(Page 3 of 3) Tj")
+}
@@ -21,19 +21,21 @@ func NewJWTMaker(secretKey string) (*JWtMaker, error) {
 	return &JWtMaker{secretKey}, nil
 }
 
-func (maker *JWtMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload, err := NewPayload(username, duration)
+// CreateToken creates a token for username, options narrow down what it may
+// do. It returns the payload too, its ID names the session of a login.
+func (maker *JWtMaker) CreateToken(username string, duration time.Duration, options Options) (string, *Payload, error) {
+	payload, err := NewPayload(username, duration, options)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
 	ss, err := jwtToken.SignedString([]byte(maker.secretKey))
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	return ss, nil
+	return ss, payload, nil
 }
 
 func (maker *JWtMaker) VerifyToken(token string) (*Payload, error) {
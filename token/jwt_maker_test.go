@@ -19,7 +19,7 @@ func TestJWTMaker(t *testing.T) {
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
-	token, err := maker.CreateToken(username, duration)
+	token, created, err := maker.CreateToken(username, duration, Options{})
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 
@@ -29,16 +29,41 @@ func TestJWTMaker(t *testing.T) {
 
 	require.Equal(t, username, payload.Username)
 	require.NotZero(t, payload.ID)
+	require.Equal(t, created.ID, payload.ID)
 
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiresAt.Time, time.Second)
 }
 
+func TestJWTMakerOptions(t *testing.T) {
+	maker, err := NewJWTMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	username := util.RandomOwner()
+	options := Options{
+		Audience: AudienceGRPC,
+		Scopes:   []string{ScopeAccountsRead},
+		Session:  true,
+	}
+
+	token, _, err := maker.CreateToken(username, time.Minute, options)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, options.Audience, payload.Audience)
+	require.Equal(t, jwt.ClaimStrings{AudienceGRPC}, payload.RegisteredClaims.Audience)
+	require.Equal(t, options.Scopes, payload.Scopes)
+	require.True(t, payload.Session)
+	require.False(t, payload.IsThirdParty())
+}
+
 func TestExpiredJWTToken(t *testing.T) {
 	maker, err := NewJWTMaker(util.RandomString(32))
 	require.NoError(t, err)
 
-	token, err := maker.CreateToken(util.RandomOwner(), -time.Minute)
+	token, _, err := maker.CreateToken(util.RandomOwner(), -time.Minute, Options{})
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 
@@ -49,7 +74,7 @@ func TestExpiredJWTToken(t *testing.T) {
 }
 
 func TestInvalidJWTTokenAlgNone(t *testing.T) {
-	payload, err := NewPayload(util.RandomOwner(), time.Minute)
+	payload, err := NewPayload(util.RandomOwner(), time.Minute, Options{})
 	require.NoError(t, err)
 
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodNone, payload)
@@ -18,7 +18,7 @@ func TestPasetoMaker(t *testing.T) {
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
-	token, err := maker.CreateToken(username, duration)
+	token, created, err := maker.CreateToken(username, duration, Options{})
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 
@@ -28,16 +28,55 @@ func TestPasetoMaker(t *testing.T) {
 
 	require.Equal(t, username, payload.Username)
 	require.NotZero(t, payload.ID)
+	require.Equal(t, created.ID, payload.ID)
 
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
 }
 
+func TestPasetoMakerOptions(t *testing.T) {
+	maker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	username := util.RandomOwner()
+	options := Options{
+		Audience: AudienceHTTP,
+		ClientID: "client",
+		Scopes:   []string{ScopeAccountsRead, ScopeTransfersRead},
+	}
+
+	token, _, err := maker.CreateToken(username, time.Minute, options)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, options.Audience, payload.Audience)
+	require.Equal(t, options.ClientID, payload.ClientID)
+	require.Equal(t, options.Scopes, payload.Scopes)
+	require.True(t, payload.IsThirdParty())
+
+	token, _, err = maker.CreateToken(username, time.Minute, Options{})
+	require.NoError(t, err)
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.False(t, payload.IsThirdParty())
+	require.Empty(t, payload.Audience)
+	require.Empty(t, payload.Scopes)
+	require.False(t, payload.Session)
+
+	token, _, err = maker.CreateToken(username, time.Minute, Options{Session: true})
+	require.NoError(t, err)
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.True(t, payload.Session)
+}
+
 func TestExpiredPasetoToken(t *testing.T) {
 	maker, err := NewPasetoMaker(util.RandomString(32))
 	require.NoError(t, err)
 
-	token, err := maker.CreateToken(util.RandomOwner(), -time.Minute)
+	token, _, err := maker.CreateToken(util.RandomOwner(), -time.Minute, Options{})
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 
@@ -2,6 +2,7 @@ package token
 
 import (
 	"errors"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -18,12 +19,32 @@ type Payload struct {
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
 	jwt.RegisteredClaims
-	Username string    `json:"username"`
-	ID       uuid.UUID `json:"id"`
+	Username string `json:"username"`
+	// Audience is the API the token is meant for, empty for all of them
+	Audience string `json:"audience,omitempty"`
+	// ClientID is the OAuth client a third-party token was issued to, empty
+	// for tokens of a login
+	ClientID string `json:"client_id,omitempty"`
+	// Scopes limit what the token may do, a token without scopes may do
+	// everything
+	Scopes []string `json:"scopes,omitempty"`
+	// Session tells the token opened a session of a login, the session has
+	// the ID of the token and revoking it revokes the token
+	Session bool      `json:"session,omitempty"`
+	ID      uuid.UUID `json:"id"`
 }
 
-// creates a new token payload with specific username and duration
-func NewPayload(username string, duration time.Duration) (*Payload, error) {
+// Options narrow down what a token may do. The zero value is a token
+// accepted by every API for everything.
+type Options struct {
+	Audience string
+	ClientID string
+	Scopes   []string
+	Session  bool
+}
+
+// creates a new token payload with specific username, duration and options
+func NewPayload(username string, duration time.Duration, options Options) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
@@ -34,6 +55,10 @@ func NewPayload(username string, duration time.Duration) (*Payload, error) {
 	payload := &Payload{
 		ID:        tokenID,
 		Username:  username,
+		Audience:  options.Audience,
+		ClientID:  options.ClientID,
+		Scopes:    options.Scopes,
+		Session:   options.Session,
 		IssuedAt:  nowTime,
 		ExpiredAt: expireTime,
 		RegisteredClaims: jwt.RegisteredClaims{
@@ -41,10 +66,33 @@ func NewPayload(username string, duration time.Duration) (*Payload, error) {
 			ExpiresAt: jwt.NewNumericDate(expireTime),
 		},
 	}
+	if options.Audience != "" {
+		payload.RegisteredClaims.Audience = jwt.ClaimStrings{options.Audience}
+	}
 
 	return payload, nil
 }
 
+// IsThirdParty tells whether the token was issued to an OAuth client
+func (payload *Payload) IsThirdParty() bool {
+	return payload.ClientID != ""
+}
+
+// IsScoped tells whether the scopes of the token limit what it may do
+func (payload *Payload) IsScoped() bool {
+	return len(payload.Scopes) > 0
+}
+
+// HasScope tells whether the token may do what scope grants
+func (payload *Payload) HasScope(scope string) bool {
+	return !payload.IsScoped() || slices.Contains(payload.Scopes, scope)
+}
+
+// IsFor tells whether the token is meant for audience
+func (payload *Payload) IsFor(audience string) bool {
+	return payload.Audience == "" || payload.Audience == audience
+}
+
 func (payload *Payload) Valid() error {
 	if time.Now().After(payload.ExpiredAt) {
 		return ErrExpiredToken
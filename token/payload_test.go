@@ -0,0 +1,36 @@
+package token
+
+import (
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayloadScopes(t *testing.T) {
+	payload, err := NewPayload(util.RandomOwner(), time.Minute, Options{})
+	require.NoError(t, err)
+	require.False(t, payload.IsScoped())
+	for _, scope := range Scopes {
+		require.True(t, payload.HasScope(scope))
+	}
+
+	payload, err = NewPayload(util.RandomOwner(), time.Minute, Options{Scopes: []string{ScopeAccountsRead}})
+	require.NoError(t, err)
+	require.True(t, payload.IsScoped())
+	require.True(t, payload.HasScope(ScopeAccountsRead))
+	require.False(t, payload.HasScope(ScopeTransfersWrite))
+}
+
+func TestPayloadAudience(t *testing.T) {
+	payload, err := NewPayload(util.RandomOwner(), time.Minute, Options{})
+	require.NoError(t, err)
+	require.True(t, payload.IsFor(AudienceHTTP))
+	require.True(t, payload.IsFor(AudienceGRPC))
+
+	payload, err = NewPayload(util.RandomOwner(), time.Minute, Options{Audience: AudienceHTTP})
+	require.NoError(t, err)
+	require.True(t, payload.IsFor(AudienceHTTP))
+	require.False(t, payload.IsFor(AudienceGRPC))
+}
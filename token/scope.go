@@ -0,0 +1,32 @@
+package token
+
+import "slices"
+
+// Scopes name what a credential may do. Access tokens of a login may do
+// everything unless they were asked for with scopes, API keys and
+// third-party tokens only what their scopes grant.
+const (
+	ScopeAccountsRead   = "accounts:read"
+	ScopeAccountsWrite  = "accounts:write"
+	ScopeTransfersRead  = "transfers:read"
+	ScopeTransfersWrite = "transfers:write"
+)
+
+// Scopes are all scopes a credential can be granted
+var Scopes = []string{
+	ScopeAccountsRead,
+	ScopeAccountsWrite,
+	ScopeTransfersRead,
+	ScopeTransfersWrite,
+}
+
+// Audiences of a token, the API it is meant for
+const (
+	AudienceHTTP = "http"
+	AudienceGRPC = "grpc"
+)
+
+// IsValidScope tells whether scope is one of Scopes
+func IsValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
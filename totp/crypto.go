@@ -0,0 +1,63 @@
+package totp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+const keySize = 32
+
+// sealer encrypts secrets at rest with AES-256-GCM, a copy of the database
+// alone isn't enough to generate codes
+type sealer struct {
+	aead cipher.AEAD
+}
+
+func newSealer(key string) (*sealer, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("invalid totp encryption key size: must be exactly %d characters", keySize)
+	}
+
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &sealer{aead: aead}, nil
+}
+
+// seal encrypts secret, the username is authenticated along so a secret can't
+// be copied to another user
+func (s *sealer) seal(username, secret string) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	ciphertext := s.aead.Seal(nonce, nonce, []byte(secret), []byte(username))
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+func (s *sealer) open(username, sealed string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < s.aead.NonceSize() {
+		return "", errors.New("sealed totp secret is too short")
+	}
+
+	nonce, ciphertext := data[:s.aead.NonceSize()], data[s.aead.NonceSize():]
+	secret, err := s.aead.Open(nil, nonce, ciphertext, []byte(username))
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
@@ -0,0 +1,38 @@
+package totp
+
+import (
+	"simplebank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealer(t *testing.T) {
+	s, err := newSealer(util.RandomString(32))
+	require.NoError(t, err)
+
+	sealed, err := s.seal("alice", rfcSecret)
+	require.NoError(t, err)
+	require.NotContains(t, sealed, rfcSecret)
+
+	secret, err := s.open("alice", sealed)
+	require.NoError(t, err)
+	require.Equal(t, rfcSecret, secret)
+
+	// sealed for another user
+	_, err = s.open("bob", sealed)
+	require.Error(t, err)
+
+	other, err := newSealer(util.RandomString(32))
+	require.NoError(t, err)
+	_, err = other.open("alice", sealed)
+	require.Error(t, err)
+
+	_, err = s.open("alice", "c2hvcnQ=")
+	require.Error(t, err)
+}
+
+func TestNewSealerInvalidKey(t *testing.T) {
+	_, err := newSealer(util.RandomString(16))
+	require.Error(t, err)
+}
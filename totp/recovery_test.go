@@ -0,0 +1,33 @@
+package totp
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, hashes, err := newRecoveryCodes(RecoveryCodeCount)
+	require.NoError(t, err)
+	require.Len(t, codes, RecoveryCodeCount)
+	require.Len(t, hashes, RecoveryCodeCount)
+
+	seen := map[string]bool{}
+	for i, code := range codes {
+		require.Regexp(t, regexp.MustCompile(`^[a-z2-9]{5}-[a-z2-9]{5}$`), code)
+		require.Equal(t, hashRecoveryCode(code), hashes[i])
+		require.False(t, seen[code])
+		seen[code] = true
+	}
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	hash := hashRecoveryCode("abcde-fghjk")
+	require.Equal(t, hash, hashRecoveryCode("ABCDE-FGHJK"))
+	require.Equal(t, hash, hashRecoveryCode("abcdefghjk"))
+	require.Equal(t, hash, hashRecoveryCode("abcde fghjk"))
+	require.NotEqual(t, hash, hashRecoveryCode("abcde-fghjm"))
+	require.False(t, strings.Contains(hash, "abcde"))
+}
@@ -0,0 +1,86 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// secret of the RFC 6238 test vectors, "12345678901234567890" in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// the last 6 digits of the SHA1 vectors of RFC 6238 appendix B
+	testCases := []struct {
+		time time.Time
+		code string
+	}{
+		{time: time.Unix(59, 0), code: "287082"},
+		{time: time.Unix(1111111109, 0), code: "081804"},
+		{time: time.Unix(1111111111, 0), code: "050471"},
+		{time: time.Unix(1234567890, 0), code: "005924"},
+		{time: time.Unix(2000000000, 0), code: "279037"},
+	}
+
+	for _, tc := range testCases {
+		code, err := Code(rfcSecret, Step(tc.time))
+		require.NoError(t, err)
+		require.Equal(t, tc.code, code)
+	}
+
+	_, err := Code("not base32!", 1)
+	require.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := Step(now)
+
+	step, ok := Validate(rfcSecret, "005924", now)
+	require.True(t, ok)
+	require.Equal(t, current, step)
+
+	previous, err := Code(rfcSecret, current-1)
+	require.NoError(t, err)
+	step, ok = Validate(rfcSecret, previous, now)
+	require.True(t, ok)
+	require.Equal(t, current-1, step)
+
+	tooOld, err := Code(rfcSecret, current-2)
+	require.NoError(t, err)
+	_, ok = Validate(rfcSecret, tooOld, now)
+	require.False(t, ok)
+
+	for _, code := range []string{"", "00592", "0059245", "abcdef"} {
+		_, ok = Validate(rfcSecret, code, now)
+		require.False(t, ok, code)
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	require.Len(t, secret, 32)
+
+	other, err := GenerateSecret()
+	require.NoError(t, err)
+	require.NotEqual(t, secret, other)
+
+	_, err = Code(secret, 1)
+	require.NoError(t, err)
+}
+
+func TestURI(t *testing.T) {
+	uri, err := url.Parse(URI("alice", rfcSecret))
+	require.NoError(t, err)
+
+	require.Equal(t, "otpauth", uri.Scheme)
+	require.Equal(t, "totp", uri.Host)
+	require.Equal(t, "/SimpleBank:alice", uri.Path)
+	require.Equal(t, rfcSecret, uri.Query().Get("secret"))
+	require.Equal(t, "SimpleBank", uri.Query().Get("issuer"))
+	require.Equal(t, "6", uri.Query().Get("digits"))
+	require.Equal(t, "30", uri.Query().Get("period"))
+}
@@ -0,0 +1,178 @@
+package totp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	db "simplebank/db/sqlc"
+	"time"
+)
+
+var (
+	// ErrRequired is returned when the user has TOTP enabled but sent no code
+	ErrRequired = errors.New("a one-time code is required")
+	// ErrInvalidCode is returned for wrong, expired or already used codes
+	ErrInvalidCode = errors.New("invalid one-time code")
+	// ErrNotEnabled is returned when a code is demanded from a user without TOTP
+	ErrNotEnabled = errors.New("two-factor authentication is not enabled")
+	// ErrAlreadyEnabled is returned when enrolling a user that has TOTP enabled
+	ErrAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrNotEnrolled is returned when confirming before enrolling
+	ErrNotEnrolled = errors.New("two-factor authentication enrollment was not started")
+)
+
+// Verifier enrolls users and checks their second factor against the store
+type Verifier struct {
+	store  db.Store
+	sealer *sealer
+	now    func() time.Time
+}
+
+// NewVerifier creates a Verifier encrypting the secrets with key, 32 characters long
+func NewVerifier(store db.Store, key string) (*Verifier, error) {
+	sealer, err := newSealer(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Verifier{
+		store:  store,
+		sealer: sealer,
+		now:    time.Now,
+	}, nil
+}
+
+// Enrollment is what the user adds to their authenticator app
+type Enrollment struct {
+	Secret string
+	URI    string
+}
+
+// Enroll gives user a new secret. It's only used once Confirm got a code of it,
+// enrolling again before that replaces the secret.
+func (verifier *Verifier) Enroll(ctx context.Context, user db.User) (Enrollment, error) {
+	if user.TotpEnabled {
+		return Enrollment{}, ErrAlreadyEnabled
+	}
+
+	secret, err := GenerateSecret()
+	if err != nil {
+		return Enrollment{}, err
+	}
+
+	sealed, err := verifier.sealer.seal(user.Username, secret)
+	if err != nil {
+		return Enrollment{}, err
+	}
+
+	_, err = verifier.store.SetTOTPSecret(ctx, db.SetTOTPSecretParams{
+		Username:   user.Username,
+		TotpSecret: sealed,
+	})
+	if err != nil {
+		// enabled in the meantime
+		if errors.Is(err, sql.ErrNoRows) {
+			return Enrollment{}, ErrAlreadyEnabled
+		}
+		return Enrollment{}, err
+	}
+
+	return Enrollment{Secret: secret, URI: URI(user.Username, secret)}, nil
+}
+
+// Confirm enables TOTP for user when code matches the enrolled secret and
+// returns the recovery codes, they are never shown again
+func (verifier *Verifier) Confirm(ctx context.Context, user db.User, code string) ([]string, error) {
+	if user.TotpEnabled {
+		return nil, ErrAlreadyEnabled
+	}
+	if user.TotpSecret == "" {
+		return nil, ErrNotEnrolled
+	}
+
+	step, err := verifier.validate(user, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = verifier.store.EnableTOTPTx(ctx, db.EnableTOTPTxParams{
+		Username:           user.Username,
+		Step:               step,
+		RecoveryCodeHashes: hashes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// VerifyLogin is the second factor of a login, a recovery code is accepted
+// instead of a code. Users without TOTP pass.
+func (verifier *Verifier) VerifyLogin(ctx context.Context, user db.User, code, recoveryCode string) error {
+	if !user.TotpEnabled {
+		return nil
+	}
+
+	if code == "" && recoveryCode != "" {
+		rows, err := verifier.store.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{
+			Username: user.Username,
+			CodeHash: hashRecoveryCode(recoveryCode),
+		})
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrInvalidCode
+		}
+		return nil
+	}
+
+	return verifier.Verify(ctx, user, code)
+}
+
+// Verify demands a fresh code from user, recovery codes aren't accepted
+func (verifier *Verifier) Verify(ctx context.Context, user db.User, code string) error {
+	if !user.TotpEnabled {
+		return ErrNotEnabled
+	}
+	if code == "" {
+		return ErrRequired
+	}
+
+	step, err := verifier.validate(user, code)
+	if err != nil {
+		return err
+	}
+
+	rows, err := verifier.store.UseTOTPStep(ctx, db.UseTOTPStepParams{
+		Username:     user.Username,
+		TotpLastStep: step,
+	})
+	if err != nil {
+		return err
+	}
+	// the code, or a later one, was already used
+	if rows == 0 {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+func (verifier *Verifier) validate(user db.User, code string) (int64, error) {
+	secret, err := verifier.sealer.open(user.Username, user.TotpSecret)
+	if err != nil {
+		return 0, err
+	}
+
+	step, ok := Validate(secret, code, verifier.now())
+	if !ok {
+		return 0, ErrInvalidCode
+	}
+	return step, nil
+}
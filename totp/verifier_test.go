@@ -0,0 +1,215 @@
+package totp
+
+import (
+	"context"
+	"database/sql"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Unix(1234567890, 0)
+
+func newTestVerifier(t *testing.T, store db.Store) *Verifier {
+	verifier, err := NewVerifier(store, util.RandomString(32))
+	require.NoError(t, err)
+	verifier.now = func() time.Time { return testNow }
+	return verifier
+}
+
+// enrolledUser returns a user with rfcSecret sealed by verifier
+func enrolledUser(t *testing.T, verifier *Verifier, enabled bool) db.User {
+	user := db.User{Username: util.RandomOwner(), TotpEnabled: enabled}
+	sealed, err := verifier.sealer.seal(user.Username, rfcSecret)
+	require.NoError(t, err)
+	user.TotpSecret = sealed
+	return user
+}
+
+func TestEnroll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	verifier := newTestVerifier(t, store)
+	user := db.User{Username: util.RandomOwner()}
+
+	var sealed string
+	store.EXPECT().
+		SetTOTPSecret(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.SetTOTPSecretParams) (db.User, error) {
+			require.Equal(t, user.Username, arg.Username)
+			sealed = arg.TotpSecret
+			return user, nil
+		})
+
+	enrollment, err := verifier.Enroll(context.Background(), user)
+	require.NoError(t, err)
+	require.Equal(t, URI(user.Username, enrollment.Secret), enrollment.URI)
+
+	secret, err := verifier.sealer.open(user.Username, sealed)
+	require.NoError(t, err)
+	require.Equal(t, enrollment.Secret, secret)
+
+	_, err = verifier.Enroll(context.Background(), db.User{Username: user.Username, TotpEnabled: true})
+	require.ErrorIs(t, err, ErrAlreadyEnabled)
+}
+
+func TestConfirm(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	verifier := newTestVerifier(t, store)
+	user := enrolledUser(t, verifier, false)
+
+	store.EXPECT().
+		EnableTOTPTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.EnableTOTPTxParams) (db.User, error) {
+			require.Equal(t, user.Username, arg.Username)
+			require.Equal(t, Step(testNow), arg.Step)
+			require.Len(t, arg.RecoveryCodeHashes, RecoveryCodeCount)
+			return user, nil
+		})
+
+	_, err := verifier.Confirm(context.Background(), user, "123456")
+	require.ErrorIs(t, err, ErrInvalidCode)
+
+	codes, err := verifier.Confirm(context.Background(), user, "005924")
+	require.NoError(t, err)
+	require.Len(t, codes, RecoveryCodeCount)
+
+	_, err = verifier.Confirm(context.Background(), db.User{Username: user.Username}, "005924")
+	require.ErrorIs(t, err, ErrNotEnrolled)
+}
+
+func TestVerify(t *testing.T) {
+	testCases := []struct {
+		name       string
+		enabled    bool
+		code       string
+		buildStubs func(store *mockdb.MockStore, user db.User)
+		err        error
+	}{
+		{
+			name:    "OK",
+			enabled: true,
+			code:    "005924",
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().
+					UseTOTPStep(gomock.Any(), gomock.Eq(db.UseTOTPStepParams{
+						Username:     user.Username,
+						TotpLastStep: Step(testNow),
+					})).
+					Times(1).
+					Return(int64(1), nil)
+			},
+		},
+		{
+			name:    "Replayed",
+			enabled: true,
+			code:    "005924",
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().
+					UseTOTPStep(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+			},
+			err: ErrInvalidCode,
+		},
+		{
+			name:    "WrongCode",
+			enabled: true,
+			code:    "123456",
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().
+					UseTOTPStep(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			err: ErrInvalidCode,
+		},
+		{
+			name:    "Missing",
+			enabled: true,
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+			},
+			err: ErrRequired,
+		},
+		{
+			name: "NotEnabled",
+			code: "005924",
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+			},
+			err: ErrNotEnabled,
+		},
+		{
+			name:    "StoreError",
+			enabled: true,
+			code:    "005924",
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().
+					UseTOTPStep(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), sql.ErrConnDone)
+			},
+			err: sql.ErrConnDone,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			verifier := newTestVerifier(t, store)
+			user := enrolledUser(t, verifier, tc.enabled)
+			tc.buildStubs(store, user)
+
+			err := verifier.Verify(context.Background(), user, tc.code)
+			if tc.err == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestVerifyLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	verifier := newTestVerifier(t, store)
+	user := enrolledUser(t, verifier, true)
+
+	// without TOTP nothing is asked
+	require.NoError(t, verifier.VerifyLogin(context.Background(), db.User{Username: user.Username}, "", ""))
+
+	require.ErrorIs(t, verifier.VerifyLogin(context.Background(), user, "", ""), ErrRequired)
+
+	store.EXPECT().
+		UseRecoveryCode(gomock.Any(), gomock.Eq(db.UseRecoveryCodeParams{
+			Username: user.Username,
+			CodeHash: hashRecoveryCode("abcde-fghjk"),
+		})).
+		Times(1).
+		Return(int64(1), nil)
+	require.NoError(t, verifier.VerifyLogin(context.Background(), user, "", "ABCDE-FGHJK"))
+
+	store.EXPECT().
+		UseRecoveryCode(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(int64(0), nil)
+	require.ErrorIs(t, verifier.VerifyLogin(context.Background(), user, "", "abcde-fghjk"), ErrInvalidCode)
+}
@@ -0,0 +1,35 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetupStdout(t *testing.T) {
+	var buf bytes.Buffer
+	shutdown, err := Setup(context.Background(), ExporterStdout, &buf)
+	require.NoError(t, err)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "TransferTx")
+	require.True(t, span.SpanContext().IsValid())
+	span.End()
+
+	require.NoError(t, shutdown(context.Background()))
+	require.Contains(t, buf.String(), `"Name": "TransferTx"`)
+	require.Contains(t, buf.String(), ServiceName)
+}
+
+func TestSetupNone(t *testing.T) {
+	shutdown, err := Setup(context.Background(), ExporterNone, nil)
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+}
+
+func TestSetupUnsupportedExporter(t *testing.T) {
+	_, err := Setup(context.Background(), "jaeger", nil)
+	require.EqualError(t, err, `unsupported tracing exporter "jaeger"`)
+}
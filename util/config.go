@@ -7,11 +7,52 @@ import (
 )
 
 type Config struct {
-	DBDrive             string        `mapstructure:"DB_DRIVER"`
-	DBSource            string        `mapstructure:"DB_SOURCE"`
-	ServerAddress       string        `mapstructure:"SERVER_ADDRESS"`
-	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	DBDrive               string        `mapstructure:"DB_DRIVER"`
+	DBSource              string        `mapstructure:"DB_SOURCE"`
+	ServerAddress         string        `mapstructure:"SERVER_ADDRESS"`
+	GRPCServerAddress     string        `mapstructure:"GRPC_SERVER_ADDRESS"`
+	HTTPGatewayAddress    string        `mapstructure:"HTTP_GATEWAY_ADDRESS"`
+	MetricsAddress        string        `mapstructure:"METRICS_ADDRESS"`
+	TokenSymmetricKey     string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	AccessTokenDuration   time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	CloseSigningKey       string        `mapstructure:"CLOSE_SIGNING_KEY"`
+	LogLevel              string        `mapstructure:"LOG_LEVEL"`
+	TracingExporter       string        `mapstructure:"TRACING_EXPORTER"`
+	ShutdownTimeout       time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	RateLimitBackend      string        `mapstructure:"RATE_LIMIT_BACKEND"`
+	RateLimitPublic       string        `mapstructure:"RATE_LIMIT_PUBLIC"`
+	RateLimitAPI          string        `mapstructure:"RATE_LIMIT_API"`
+	RateLimitAPIByIP      string        `mapstructure:"RATE_LIMIT_API_BY_IP"`
+	RateLimitTransfers    string        `mapstructure:"RATE_LIMIT_TRANSFERS"`
+	TrustedProxies        []string      `mapstructure:"TRUSTED_PROXIES"`
+	LoginMaxAttempts      int32         `mapstructure:"LOGIN_MAX_ATTEMPTS"`
+	LoginLockout          time.Duration `mapstructure:"LOGIN_LOCKOUT"`
+	LoginMaxLockout       time.Duration `mapstructure:"LOGIN_MAX_LOCKOUT"`
+	TOTPEncryptionKey     string        `mapstructure:"TOTP_ENCRYPTION_KEY"`
+	TOTPTransferThreshold int64         `mapstructure:"TOTP_TRANSFER_THRESHOLD"`
+	TOTPTransferRequired  bool          `mapstructure:"TOTP_TRANSFER_REQUIRED"`
+	MailSender            string        `mapstructure:"MAIL_SENDER"`
+	MailFileDir           string        `mapstructure:"MAIL_FILE_DIR"`
+	MailFrom              string        `mapstructure:"MAIL_FROM"`
+	SMTPHost              string        `mapstructure:"SMTP_HOST"`
+	SMTPPort              int           `mapstructure:"SMTP_PORT"`
+	SMTPUsername          string        `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword          string        `mapstructure:"SMTP_PASSWORD"`
+	AppURL                string        `mapstructure:"APP_URL"`
+	PasswordResetDuration time.Duration `mapstructure:"PASSWORD_RESET_DURATION"`
+	EmailVerifyDuration   time.Duration `mapstructure:"EMAIL_VERIFY_DURATION"`
+	PasswordMinLength     int           `mapstructure:"PASSWORD_MIN_LENGTH"`
+	PasswordMinClasses    int           `mapstructure:"PASSWORD_MIN_CLASSES"`
+	PasswordRejectCommon  bool          `mapstructure:"PASSWORD_REJECT_COMMON"`
+}
+
+// PasswordPolicy is the policy new passwords are checked against
+func (config Config) PasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:    config.PasswordMinLength,
+		MinClasses:   config.PasswordMinClasses,
+		RejectCommon: config.PasswordRejectCommon,
+	}
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -1,5 +1,11 @@
 package util
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // 所有支援的幣種
 const (
 	USD = "USD"
@@ -14,3 +20,34 @@ func IsSupportedCurrency(currency string) bool {
 	}
 	return false
 }
+
+// FormatAmount formats an amount kept in minor units (cents) as a decimal string, e.g. -1234 -> "-12.34"
+func FormatAmount(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
+
+// ParseAmount parses a decimal amount with at most two fraction digits into minor units, e.g. "12.3" -> 1230
+func ParseAmount(s string) (int64, error) {
+	whole, fraction, found := strings.Cut(s, ".")
+	if whole == "" || len(fraction) > 2 || (found && fraction == "") {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+
+	for _, r := range whole + fraction {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("invalid amount %q", s)
+		}
+	}
+
+	fraction += strings.Repeat("0", 2-len(fraction))
+	amount, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return amount, nil
+}
@@ -0,0 +1,36 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatAmount(t *testing.T) {
+	require.Equal(t, "0.00", FormatAmount(0))
+	require.Equal(t, "0.05", FormatAmount(5))
+	require.Equal(t, "12.34", FormatAmount(1234))
+	require.Equal(t, "-12.34", FormatAmount(-1234))
+	require.Equal(t, "-0.50", FormatAmount(-50))
+}
+
+func TestParseAmount(t *testing.T) {
+	testCases := map[string]int64{
+		"0":       0,
+		"12":      1200,
+		"12.3":    1230,
+		"12.34":   1234,
+		"0.05":    5,
+		"1000.00": 100000,
+	}
+	for s, want := range testCases {
+		amount, err := ParseAmount(s)
+		require.NoError(t, err, s)
+		require.Equal(t, want, amount, s)
+	}
+
+	for _, s := range []string{"", ".5", "12.", "12.345", "-1", "1e3", "12,34", "99999999999999999999"} {
+		_, err := ParseAmount(s)
+		require.Error(t, err, s)
+	}
+}
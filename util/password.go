@@ -1,21 +1,112 @@
 package util
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword returns bcrypt hash of the password
+// argon2id parameters of new hashes, the second recommendation of OWASP.
+// Hashes made with other parameters still verify and are rehashed at login.
+const (
+	argon2Memory  = 19 * 1024 // KiB
+	argon2Time    = 2
+	argon2Threads = 1
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+var errUnsupportedHash = errors.New("unsupported password hash")
+
+// HashPassword returns the argon2id hash of the password in the PHC string
+// format, $argon2id$v=19$m=...,t=...,p=...$salt$key
 func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
 		return "", fmt.Errorf("failed to hash password: %w", err)
 	}
-	return string(hashedPassword), nil
+
+	params := argon2Params{memory: argon2Memory, time: argon2Time, threads: argon2Threads}
+	return params.encode(salt, argon2IDKey(password, salt, params)), nil
 }
 
-// CheckPassword checks if the provided password is correct or not
+// CheckPassword checks if the provided password is correct or not. It
+// accepts argon2id and bcrypt hashes, a wrong password is
+// bcrypt.ErrMismatchedHashAndPassword for both.
 func CheckPassword(password, hashedPassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	if !strings.HasPrefix(hashedPassword, "$argon2id$") {
+		return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	}
+
+	params, salt, key, err := decodeArgon2(hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return bcrypt.ErrMismatchedHashAndPassword
+	}
+	return nil
+}
+
+// NeedsRehash tells whether hashedPassword is a bcrypt hash or an argon2id
+// hash with other parameters than HashPassword uses. Call it once the
+// password checked out and store a new hash.
+func NeedsRehash(hashedPassword string) bool {
+	params, _, key, err := decodeArgon2(hashedPassword)
+	if err != nil {
+		return true
+	}
+	return params != argon2Params{memory: argon2Memory, time: argon2Time, threads: argon2Threads} ||
+		len(key) != argon2KeyLen
+}
+
+type argon2Params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+func argon2IDKey(password string, salt []byte, params argon2Params) []byte {
+	return argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, argon2KeyLen)
+}
+
+func (params argon2Params) encode(salt, key []byte) string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.memory, params.time, params.threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+}
+
+func decodeArgon2(hashedPassword string) (params argon2Params, salt, key []byte, err error) {
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errUnsupportedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errUnsupportedHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil {
+		return params, nil, nil, errUnsupportedHash
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, errUnsupportedHash
+	}
+	key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errUnsupportedHash
+	}
+	return params, salt, key, nil
 }
@@ -0,0 +1,77 @@
+package util
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ErrWeakPassword is wrapped by every error of PasswordPolicy.Validate
+var ErrWeakPassword = errors.New("password is too weak")
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+// commonPasswords holds the lower-cased entries of common_passwords.txt
+var commonPasswords = sync.OnceValue(func() map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, line := range strings.Split(commonPasswordList, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set[strings.ToLower(line)] = struct{}{}
+	}
+	return set
+})
+
+// PasswordPolicy are the rules a new password has to follow, zero values
+// disable a rule. Existing passwords are never checked against it.
+type PasswordPolicy struct {
+	MinLength int
+	// MinClasses is how many of lower case, upper case, digits and other
+	// characters the password needs
+	MinClasses int
+	// RejectCommon refuses passwords of the embedded list of common and
+	// breached passwords, ignoring case
+	RejectCommon bool
+}
+
+// Validate returns an error wrapping ErrWeakPassword when password breaks a
+// rule of the policy
+func (policy PasswordPolicy) Validate(password string) error {
+	if length := len([]rune(password)); length < policy.MinLength {
+		return fmt.Errorf("%w: must contain at least %d characters", ErrWeakPassword, policy.MinLength)
+	}
+
+	if classes := countCharClasses(password); classes < policy.MinClasses {
+		return fmt.Errorf("%w: must mix at least %d of lower case, upper case, digits and symbols", ErrWeakPassword, policy.MinClasses)
+	}
+
+	if policy.RejectCommon {
+		if _, ok := commonPasswords()[strings.ToLower(password)]; ok {
+			return fmt.Errorf("%w: it is too common, choose another one", ErrWeakPassword)
+		}
+	}
+	return nil
+}
+
+func countCharClasses(password string) int {
+	var lower, upper, digit, other int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+	return lower + upper + digit + other
+}
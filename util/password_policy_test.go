@@ -0,0 +1,51 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 10, MinClasses: 3, RejectCommon: true}
+
+	testCases := []struct {
+		name     string
+		password string
+		valid    bool
+	}{
+		{name: "OK", password: "correct-Horse-battery", valid: true},
+		{name: "Unicode", password: "Grüße-aus-Köln", valid: true},
+		{name: "TooShort", password: "Ab1-xyz"},
+		{name: "TooFewClasses", password: "alllowercase1"},
+		{name: "Common", password: "Password123"},
+		{name: "CommonOtherCase", password: "pASSWORD123"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := policy.Validate(tc.password)
+			if tc.valid {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, ErrWeakPassword)
+		})
+	}
+}
+
+func TestPasswordPolicyZero(t *testing.T) {
+	require.NoError(t, PasswordPolicy{}.Validate("password"))
+}
+
+func TestCommonPasswords(t *testing.T) {
+	passwords := commonPasswords()
+	require.Contains(t, passwords, "123456")
+	require.Contains(t, passwords, "qwerty")
+	require.NotContains(t, passwords, "")
+	for password := range passwords {
+		require.NotContains(t, password, "#")
+	}
+}
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -13,6 +14,8 @@ func TestPassword(t *testing.T) {
 	hashedPassword1, err := HashPassword(password)
 	require.NoError(t, err)
 	require.NotEmpty(t, hashedPassword1)
+	require.True(t, strings.HasPrefix(hashedPassword1, "$argon2id$v=19$"))
+	require.False(t, NeedsRehash(hashedPassword1))
 
 	err = CheckPassword(password, hashedPassword1)
 	require.NoError(t, err)
@@ -26,3 +29,40 @@ func TestPassword(t *testing.T) {
 	require.NotEmpty(t, hashedPassword2)
 	require.NotEqual(t, hashedPassword1, hashedPassword2)
 }
+
+func TestPasswordBcrypt(t *testing.T) {
+	password := RandomString(6)
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+	require.True(t, NeedsRehash(string(hashedPassword)))
+
+	require.NoError(t, CheckPassword(password, string(hashedPassword)))
+	err = CheckPassword(RandomString(6), string(hashedPassword))
+	require.ErrorIs(t, err, bcrypt.ErrMismatchedHashAndPassword)
+}
+
+func TestPasswordOtherParams(t *testing.T) {
+	password := RandomString(6)
+
+	// a hash of weaker parameters still verifies but is due for a rehash
+	salt := []byte(RandomString(argon2SaltLen))
+	params := argon2Params{memory: 8 * 1024, time: 1, threads: 1}
+	hashedPassword := params.encode(salt, argon2IDKey(password, salt, params))
+
+	require.NoError(t, CheckPassword(password, hashedPassword))
+	require.True(t, NeedsRehash(hashedPassword))
+}
+
+func TestPasswordInvalidHash(t *testing.T) {
+	for _, hashedPassword := range []string{
+		"$argon2id$v=19$m=19456,t=2,p=1$c2FsdA",
+		"$argon2id$v=18$m=19456,t=2,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=x,t=2,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=19456,t=2,p=1$!!$a2V5",
+	} {
+		err := CheckPassword("secret", hashedPassword)
+		require.ErrorIs(t, err, errUnsupportedHash, hashedPassword)
+		require.True(t, NeedsRehash(hashedPassword))
+	}
+}
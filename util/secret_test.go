@@ -0,0 +1,22 @@
+package util
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretToken(t *testing.T) {
+	token1, hash1, err := NewSecretToken()
+	require.NoError(t, err)
+	require.Len(t, token1, 43)
+	require.Equal(t, token1, url.QueryEscape(token1))
+	require.Equal(t, HashSecretToken(token1), hash1)
+	require.Len(t, hash1, 64)
+
+	token2, hash2, err := NewSecretToken()
+	require.NoError(t, err)
+	require.NotEqual(t, token1, token2)
+	require.NotEqual(t, hash1, hash2)
+}
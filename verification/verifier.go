@@ -0,0 +1,82 @@
+// Package verification proves users own the email address they signed up
+// with, through links carrying single-use tokens
+package verification
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	db "simplebank/db/sqlc"
+	"simplebank/mail"
+	"simplebank/util"
+	"time"
+)
+
+var (
+	// ErrInvalidToken is returned for unknown, expired or already used tokens
+	ErrInvalidToken = errors.New("verification token is invalid or expired")
+	// ErrAlreadyVerified is returned when sending a link to a verified address
+	ErrAlreadyVerified = errors.New("email address is already verified")
+)
+
+// Verifier emails verification links and checks the tokens they carry
+type Verifier struct {
+	store    db.Store
+	sender   mail.Sender
+	appURL   string
+	duration time.Duration
+}
+
+// NewVerifier creates a Verifier whose links point at appURL and stay valid
+// for duration
+func NewVerifier(store db.Store, sender mail.Sender, appURL string, duration time.Duration) *Verifier {
+	return &Verifier{
+		store:    store,
+		sender:   sender,
+		appURL:   appURL,
+		duration: duration,
+	}
+}
+
+// Send emails a verification link to the current address of user. Links sent
+// before stay valid until they expire.
+func (verifier *Verifier) Send(ctx context.Context, user db.User) error {
+	if user.EmailVerified {
+		return ErrAlreadyVerified
+	}
+
+	token, tokenHash, err := util.NewSecretToken()
+	if err != nil {
+		return err
+	}
+
+	_, err = verifier.store.CreateEmailVerification(ctx, db.CreateEmailVerificationParams{
+		TokenHash: tokenHash,
+		Username:  user.Username,
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(verifier.duration),
+	})
+	if err != nil {
+		return err
+	}
+
+	return verifier.sender.Send(ctx, mail.Email{
+		To:      user.Email,
+		Subject: "Verify your SimpleBank email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nopen the link below within %s to confirm this is your email address:\n\n%s/verify-email?token=%s\n\nUntil then you can't open accounts or make transfers.\n",
+			user.FullName, verifier.duration, verifier.appURL, url.QueryEscape(token),
+		),
+	})
+}
+
+// Verify marks the address the token was sent to as verified
+func (verifier *Verifier) Verify(ctx context.Context, token string) (db.User, error) {
+	user, err := verifier.store.VerifyEmailTx(ctx, util.HashSecretToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, ErrInvalidToken
+	}
+	return user, err
+}
@@ -0,0 +1,129 @@
+package verification
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"regexp"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/mail"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSender struct {
+	emails []mail.Email
+	err    error
+}
+
+func (sender *recordingSender) Send(ctx context.Context, email mail.Email) error {
+	sender.emails = append(sender.emails, email)
+	return sender.err
+}
+
+func randomUser() db.User {
+	return db.User{
+		Username: util.RandomOwner(),
+		FullName: util.RandomString(6),
+		Email:    util.RandomEmail(),
+	}
+}
+
+func TestSend(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	sender := &recordingSender{}
+	verifier := NewVerifier(store, sender, "https://bank.example", time.Hour)
+	user := randomUser()
+
+	var tokenHash string
+	store.EXPECT().
+		CreateEmailVerification(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateEmailVerificationParams) (db.EmailVerification, error) {
+			require.Equal(t, user.Username, arg.Username)
+			require.Equal(t, user.Email, arg.Email)
+			require.WithinDuration(t, time.Now().Add(time.Hour), arg.ExpiresAt, time.Second)
+			tokenHash = arg.TokenHash
+			return db.EmailVerification{}, nil
+		})
+
+	require.NoError(t, verifier.Send(context.Background(), user))
+	require.Len(t, sender.emails, 1)
+	require.Equal(t, user.Email, sender.emails[0].To)
+
+	match := regexp.MustCompile(`https://bank\.example/verify-email\?token=(\S+)`).FindStringSubmatch(sender.emails[0].Body)
+	require.Len(t, match, 2)
+	token, err := url.QueryUnescape(match[1])
+	require.NoError(t, err)
+	require.Equal(t, tokenHash, util.HashSecretToken(token))
+}
+
+func TestSendErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	sender := &recordingSender{err: errors.New("smtp is down")}
+	verifier := NewVerifier(store, sender, "https://bank.example", time.Hour)
+	user := randomUser()
+
+	verified := user
+	verified.EmailVerified = true
+	require.ErrorIs(t, verifier.Send(context.Background(), verified), ErrAlreadyVerified)
+
+	store.EXPECT().
+		CreateEmailVerification(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.EmailVerification{}, sql.ErrConnDone)
+	require.ErrorIs(t, verifier.Send(context.Background(), user), sql.ErrConnDone)
+	require.Empty(t, sender.emails)
+
+	store.EXPECT().
+		CreateEmailVerification(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.EmailVerification{}, nil)
+	require.ErrorIs(t, verifier.Send(context.Background(), user), sender.err)
+}
+
+func TestVerify(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	verifier := NewVerifier(store, &recordingSender{}, "https://bank.example", time.Hour)
+	user := randomUser()
+	user.EmailVerified = true
+	token, tokenHash, err := util.NewSecretToken()
+	require.NoError(t, err)
+
+	store.EXPECT().
+		VerifyEmailTx(gomock.Any(), gomock.Eq(tokenHash)).
+		Times(1).
+		Return(user, nil)
+	verified, err := verifier.Verify(context.Background(), token)
+	require.NoError(t, err)
+	require.True(t, verified.EmailVerified)
+
+	store.EXPECT().
+		VerifyEmailTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.User{}, sql.ErrNoRows)
+	_, err = verifier.Verify(context.Background(), token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	store.EXPECT().
+		VerifyEmailTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.User{}, sql.ErrConnDone)
+	_, err = verifier.Verify(context.Background(), token)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}